@@ -45,6 +45,15 @@ if errorlevel 1 (
     exit /b 1
 )
 
+REM 构建MCP检查器
+echo 📦 构建MCP检查器...
+go build -o mcp-inspect.exe ./cmd/mcp-inspect
+if errorlevel 1 (
+    echo ❌ 构建MCP检查器失败
+    pause
+    exit /b 1
+)
+
 echo ✅ 构建完成！
 echo.
 echo 📋 可执行文件：
@@ -52,6 +61,7 @@ echo   - sayhi-server.exe (Hello MCP服务器)
 echo   - database-server.exe (数据库MCP服务器)
 echo   - redis-server.exe (Redis MCP服务器)
 echo   - test-client.exe (测试客户端)
+echo   - mcp-inspect.exe (MCP检查器)
 echo.
 echo 🎯 运行Hello服务器：
 echo   sayhi-server.exe
@@ -63,6 +73,9 @@ echo 🔴 运行Redis服务器：
 echo   redis-server.exe
 echo.
 echo 🧪 运行测试：
-echo   test-client.exe
+echo   test-client.exe sayhi-server.exe
+echo.
+echo 🔍 调试服务器：
+echo   mcp-inspect.exe hello-mcp-server
 echo.
 pause 
\ No newline at end of file
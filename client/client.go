@@ -0,0 +1,371 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"hello-mcp-server/types"
+)
+
+// 客户端默认使用的协议版本
+const ProtocolVersion = "2024-11-05"
+
+// 默认请求超时
+const DefaultTimeout = 30 * time.Second
+
+// NotificationHandler 通知回调
+type NotificationHandler func(msg *types.JSONRPCMessage)
+
+// RPCError 服务器返回的 JSON-RPC 错误
+type RPCError struct {
+	Code    int
+	Message string
+	Data    interface{}
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// Client MCP客户端，按ID关联请求与响应并分发通知
+type Client struct {
+	transport Transport
+	timeout   time.Duration
+
+	pendingMu sync.Mutex
+	pending   map[int]chan *types.JSONRPCMessage
+	nextID    int
+
+	handlersMu sync.RWMutex
+	handlers   map[string][]NotificationHandler
+
+	notifications chan *types.JSONRPCMessage
+
+	initResult *types.InitializeResult
+
+	closeOnce sync.Once
+	done      chan struct{}
+	err       error
+}
+
+// NewClient 在通道上创建客户端并开始接收消息
+func NewClient(transport Transport) *Client {
+	c := &Client{
+		transport:     transport,
+		timeout:       DefaultTimeout,
+		pending:       make(map[int]chan *types.JSONRPCMessage),
+		handlers:      make(map[string][]NotificationHandler),
+		notifications: make(chan *types.JSONRPCMessage, 64),
+		done:          make(chan struct{}),
+	}
+
+	go c.readLoop()
+	go c.notifyLoop()
+	return c
+}
+
+// NewStdioClient 启动服务器进程并创建客户端
+func NewStdioClient(command string, args []string, opts *StdioOptions) (*Client, error) {
+	transport, err := NewStdioTransport(command, args, opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(transport), nil
+}
+
+// NewHTTPClient 连接 HTTP 服务器并创建客户端
+func NewHTTPClient(url string) *Client {
+	return NewClient(NewHTTPTransport(url, nil))
+}
+
+// SetTimeout 设置请求超时
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// OnNotification 注册通知回调，method 为空时接收所有通知
+func (c *Client) OnNotification(method string, handler NotificationHandler) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+	c.handlers[method] = append(c.handlers[method], handler)
+}
+
+// Initialize 完成 initialize 握手并发送 initialized 通知
+func (c *Client) Initialize(clientInfo types.ClientInfo, capabilities types.ClientCapabilities) (*types.InitializeResult, error) {
+	params := types.InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    capabilities,
+		ClientInfo:      clientInfo,
+	}
+
+	var result types.InitializeResult
+	if err := c.Call("initialize", params, &result); err != nil {
+		return nil, err
+	}
+
+	if err := c.Notify("notifications/initialized", nil); err != nil {
+		return nil, err
+	}
+
+	c.initResult = &result
+	return &result, nil
+}
+
+// ServerInfo 返回握手时服务器提供的信息，未初始化时为nil
+func (c *Client) ServerInfo() *types.InitializeResult {
+	return c.initResult
+}
+
+// Ping 检查服务器是否存活
+func (c *Client) Ping() error {
+	return c.Call("ping", nil, nil)
+}
+
+// ListTools 获取工具列表
+func (c *Client) ListTools() (*types.ListToolsResult, error) {
+	var result types.ListToolsResult
+	if err := c.Call("tools/list", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CallTool 调用工具
+func (c *Client) CallTool(name string, arguments map[string]interface{}) (*types.CallToolResult, error) {
+	params := types.CallToolParams{
+		Name:      name,
+		Arguments: arguments,
+	}
+
+	var result types.CallToolResult
+	if err := c.Call("tools/call", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListResources 获取资源列表
+func (c *Client) ListResources() (*types.ListResourcesResult, error) {
+	var result types.ListResourcesResult
+	if err := c.Call("resources/list", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ReadResource 读取资源内容
+func (c *Client) ReadResource(uri string) (*types.ReadResourceResult, error) {
+	var result types.ReadResourceResult
+	if err := c.Call("resources/read", types.ReadResourceParams{URI: uri}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListPrompts 获取提示词列表
+func (c *Client) ListPrompts() (*types.ListPromptsResult, error) {
+	var result types.ListPromptsResult
+	if err := c.Call("prompts/list", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetPrompt 获取提示词
+func (c *Client) GetPrompt(name string, arguments map[string]string) (*types.GetPromptResult, error) {
+	params := types.GetPromptParams{
+		Name:      name,
+		Arguments: arguments,
+	}
+
+	var result types.GetPromptResult
+	if err := c.Call("prompts/get", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Call 发送请求并等待响应，result 非nil时解码响应结果
+func (c *Client) Call(method string, params interface{}, result interface{}) error {
+	c.pendingMu.Lock()
+	c.nextID++
+	id := c.nextID
+	responseCh := make(chan *types.JSONRPCMessage, 1)
+	c.pending[id] = responseCh
+	c.pendingMu.Unlock()
+
+	defer func() {
+		c.pendingMu.Lock()
+		delete(c.pending, id)
+		c.pendingMu.Unlock()
+	}()
+
+	err := c.transport.Send(&types.JSONRPCMessage{
+		JSONRPC: "2.0",
+		ID:      &id,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send %s: %v", method, err)
+	}
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+
+	var response *types.JSONRPCMessage
+	select {
+	case response = <-responseCh:
+	case <-timer.C:
+		return fmt.Errorf("%s timed out after %v", method, c.timeout)
+	case <-c.done:
+		return fmt.Errorf("%s aborted: %v", method, c.closeErr())
+	}
+
+	if response.Error != nil {
+		return &RPCError{
+			Code:    response.Error.Code,
+			Message: response.Error.Message,
+			Data:    response.Error.Data,
+		}
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	// Result 已被解码为通用结构，重新编码后解码到目标类型
+	resultBytes, err := json.Marshal(response.Result)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s result: %v", method, err)
+	}
+	if err := json.Unmarshal(resultBytes, result); err != nil {
+		return fmt.Errorf("failed to decode %s result: %v", method, err)
+	}
+	return nil
+}
+
+// Notify 发送通知
+func (c *Client) Notify(method string, params interface{}) error {
+	return c.transport.Send(&types.JSONRPCMessage{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+}
+
+// Close 关闭客户端和底层通道
+func (c *Client) Close() error {
+	err := c.transport.Close()
+	c.shutdown(io.EOF)
+	return err
+}
+
+// Done 在连接结束时关闭
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// readLoop 接收消息：响应交给等待中的请求，通知交给回调，服务器请求直接应答
+func (c *Client) readLoop() {
+	for {
+		msg, err := c.transport.Receive()
+		if err != nil {
+			c.shutdown(err)
+			return
+		}
+
+		switch {
+		case msg.Method == "" && msg.ID != nil:
+			c.deliver(msg)
+		case msg.Method != "" && msg.ID != nil:
+			c.handleServerRequest(msg)
+		case msg.Method != "":
+			select {
+			case c.notifications <- msg:
+			case <-c.done:
+				return
+			}
+		default:
+			// 无ID的错误响应（如服务器解析失败）
+			if msg.Error != nil {
+				log.Printf("Server error without id: %d %s", msg.Error.Code, msg.Error.Message)
+			}
+		}
+	}
+}
+
+// notifyLoop 在独立协程中执行回调，回调内可以继续发起请求
+func (c *Client) notifyLoop() {
+	for {
+		select {
+		case msg := <-c.notifications:
+			c.handlersMu.RLock()
+			handlers := append(append([]NotificationHandler{}, c.handlers[msg.Method]...), c.handlers[""]...)
+			c.handlersMu.RUnlock()
+
+			for _, handler := range handlers {
+				handler(msg)
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// deliver 将响应交给对应ID的请求
+func (c *Client) deliver(msg *types.JSONRPCMessage) {
+	c.pendingMu.Lock()
+	responseCh, ok := c.pending[*msg.ID]
+	c.pendingMu.Unlock()
+
+	if !ok {
+		log.Printf("Received response for unknown request: id=%d", *msg.ID)
+		return
+	}
+
+	select {
+	case responseCh <- msg:
+	default:
+		log.Printf("Dropped duplicate response: id=%d", *msg.ID)
+	}
+}
+
+// handleServerRequest 应答服务器发来的请求，目前只支持 ping
+func (c *Client) handleServerRequest(msg *types.JSONRPCMessage) {
+	response := &types.JSONRPCMessage{
+		JSONRPC: "2.0",
+		ID:      msg.ID,
+	}
+
+	if msg.Method == "ping" {
+		response.Result = struct{}{}
+	} else {
+		response.Error = &types.JSONRPCError{
+			Code:    -32601,
+			Message: fmt.Sprintf("Method not found: %s", msg.Method),
+		}
+	}
+
+	if err := c.transport.Send(response); err != nil {
+		log.Printf("Failed to answer server request: %v", err)
+	}
+}
+
+func (c *Client) shutdown(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		close(c.done)
+	})
+}
+
+func (c *Client) closeErr() error {
+	if c.err == nil || c.err == io.EOF {
+		return fmt.Errorf("connection closed")
+	}
+	return c.err
+}
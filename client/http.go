@@ -0,0 +1,207 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"hello-mcp-server/types"
+)
+
+// HTTPTransport 基于 Streamable HTTP 的通道：每条消息一个 POST，
+// 响应可以是 JSON（单条或数组）或 SSE 事件流
+type HTTPTransport struct {
+	url        string
+	httpClient *http.Client
+	headers    map[string]string
+
+	sessionMu sync.Mutex
+	sessionID string
+
+	incoming  chan *types.JSONRPCMessage
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// NewHTTPTransport 创建 HTTP 通道，httpClient 为空时使用 http.DefaultClient
+func NewHTTPTransport(url string, httpClient *http.Client) *HTTPTransport {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &HTTPTransport{
+		url:        url,
+		httpClient: httpClient,
+		headers:    make(map[string]string),
+		incoming:   make(chan *types.JSONRPCMessage, 64),
+		closed:     make(chan struct{}),
+	}
+}
+
+// SetHeader 设置每个请求附带的 HTTP 头（如认证信息）
+func (t *HTTPTransport) SetHeader(key, value string) {
+	t.headers[key] = value
+}
+
+// Send 以 POST 发送消息，响应内容在后台解析后交给 Receive
+func (t *HTTPTransport) Send(msg *types.JSONRPCMessage) error {
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(msgBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+
+	t.sessionMu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	t.sessionMu.Unlock()
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post message: %v", err)
+	}
+
+	if sessionID := resp.Header.Get("Mcp-Session-Id"); sessionID != "" {
+		t.sessionMu.Lock()
+		t.sessionID = sessionID
+		t.sessionMu.Unlock()
+	}
+
+	if resp.StatusCode == http.StatusAccepted {
+		resp.Body.Close()
+		return nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("server returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	go t.readResponse(resp)
+	return nil
+}
+
+// readResponse 解析 POST 响应中的消息
+func (t *HTTPTransport) readResponse(resp *http.Response) {
+	defer resp.Body.Close()
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.readEventStream(resp.Body)
+		return
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Failed to read response body: %v", err)
+		return
+	}
+	t.deliver(body)
+}
+
+// readEventStream 解析 SSE 事件流，每个事件的 data 为一条 JSON-RPC 消息
+func (t *HTTPTransport) readEventStream(body io.Reader) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				t.deliver([]byte(strings.Join(data, "\n")))
+				data = nil
+			}
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	if len(data) > 0 {
+		t.deliver([]byte(strings.Join(data, "\n")))
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("Event stream error: %v", err)
+	}
+}
+
+// deliver 解析单条消息或消息数组并放入接收队列
+func (t *HTTPTransport) deliver(body []byte) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return
+	}
+
+	var messages []*types.JSONRPCMessage
+	if body[0] == '[' {
+		if err := json.Unmarshal(body, &messages); err != nil {
+			log.Printf("Failed to parse server batch: %v", err)
+			return
+		}
+	} else {
+		var msg types.JSONRPCMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			log.Printf("Failed to parse server message: %v", err)
+			return
+		}
+		messages = append(messages, &msg)
+	}
+
+	for _, msg := range messages {
+		select {
+		case t.incoming <- msg:
+		case <-t.closed:
+			return
+		}
+	}
+}
+
+// Receive 读取下一条消息
+func (t *HTTPTransport) Receive() (*types.JSONRPCMessage, error) {
+	select {
+	case msg := <-t.incoming:
+		return msg, nil
+	case <-t.closed:
+		return nil, io.EOF
+	}
+}
+
+// Close 关闭通道，存在会话时通知服务器结束会话
+func (t *HTTPTransport) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.closed)
+
+		t.sessionMu.Lock()
+		sessionID := t.sessionID
+		t.sessionMu.Unlock()
+		if sessionID == "" {
+			return
+		}
+
+		req, err := http.NewRequest(http.MethodDelete, t.url, nil)
+		if err != nil {
+			return
+		}
+		req.Header.Set("Mcp-Session-Id", sessionID)
+		if resp, err := t.httpClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	})
+	return nil
+}
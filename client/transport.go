@@ -0,0 +1,156 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"hello-mcp-server/types"
+)
+
+// Transport 客户端与服务器之间的消息通道
+type Transport interface {
+	// Send 发送一条消息
+	Send(msg *types.JSONRPCMessage) error
+	// Receive 阻塞读取下一条消息，通道关闭时返回 io.EOF
+	Receive() (*types.JSONRPCMessage, error)
+	// Close 关闭通道
+	Close() error
+}
+
+// StreamTransport 基于按行分隔 JSON 的流式通道
+type StreamTransport struct {
+	reader *bufio.Reader
+	writer io.WriteCloser
+	sendMu sync.Mutex
+}
+
+// NewStreamTransport 创建流式通道
+func NewStreamTransport(reader io.Reader, writer io.WriteCloser) *StreamTransport {
+	return &StreamTransport{
+		reader: bufio.NewReader(reader),
+		writer: writer,
+	}
+}
+
+// Send 发送一条消息
+func (t *StreamTransport) Send(msg *types.JSONRPCMessage) error {
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	t.sendMu.Lock()
+	defer t.sendMu.Unlock()
+
+	_, err = t.writer.Write(append(msgBytes, '\n'))
+	return err
+}
+
+// Receive 读取下一条消息，跳过空行和无法解析的行
+func (t *StreamTransport) Receive() (*types.JSONRPCMessage, error) {
+	for {
+		line, err := t.reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+
+		if line != "" {
+			var msg types.JSONRPCMessage
+			if jsonErr := json.Unmarshal([]byte(line), &msg); jsonErr != nil {
+				log.Printf("Failed to parse server message: %v", jsonErr)
+			} else {
+				return &msg, nil
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Close 关闭写入端
+func (t *StreamTransport) Close() error {
+	return t.writer.Close()
+}
+
+// StdioOptions 启动服务器进程的选项
+type StdioOptions struct {
+	// 追加到当前进程环境变量之后
+	Env []string
+	// 工作目录，为空时使用当前目录
+	Dir string
+	// 服务器 stderr 输出位置，为空时转发到当前进程的 stderr
+	Stderr io.Writer
+}
+
+// 关闭 stdin 后等待服务器进程自行退出的时间
+const shutdownTimeout = 3 * time.Second
+
+// StdioTransport 通过子进程 stdin/stdout 通信的通道
+type StdioTransport struct {
+	*StreamTransport
+	cmd *exec.Cmd
+}
+
+// NewStdioTransport 启动服务器进程并建立通道
+func NewStdioTransport(command string, args []string, opts *StdioOptions) (*StdioTransport, error) {
+	if opts == nil {
+		opts = &StdioOptions{}
+	}
+
+	cmd := exec.Command(command, args...)
+	cmd.Dir = opts.Dir
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Env...)
+	}
+	cmd.Stderr = opts.Stderr
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdin pipe: %v", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdout pipe: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start server: %v", err)
+	}
+
+	return &StdioTransport{
+		StreamTransport: NewStreamTransport(stdout, stdin),
+		cmd:             cmd,
+	}, nil
+}
+
+// Close 关闭 stdin 并等待服务器进程退出
+func (t *StdioTransport) Close() error {
+	t.StreamTransport.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- t.cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(shutdownTimeout):
+		if t.cmd.Process != nil {
+			t.cmd.Process.Kill()
+		}
+		return <-done
+	}
+}
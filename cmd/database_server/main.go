@@ -1,21 +1,25 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"hello-mcp-server/config"
 	"hello-mcp-server/database"
+	"hello-mcp-server/server"
 	"hello-mcp-server/types"
 )
 
 // 数据库查询工具参数
 type DatabaseQueryParams struct {
-	SQL string `json:"sql"`
+	SQL    string        `json:"sql"`
+	Params []interface{} `json:"params,omitempty"`
 }
 
 type DatabaseQueryResult struct {
@@ -27,208 +31,1969 @@ type DatabaseQueryResult struct {
 // DatabaseMCPServer 数据库MCP服务器
 type DatabaseMCPServer struct {
 	serverInfo types.ServerInfo
-	dbManager  *database.DatabaseManager
-	dbConfig   *config.DatabaseConfig
+	// 具名数据库连接
+	connections map[string]*dbConnection
+	// 按名称排序的连接名
+	connectionNames []string
+	// 未指定 database 参数时使用的连接
+	defaultDatabase string
+	serverConfig    *config.ServerConfig
+}
+
+// dbConnection 具名数据库连接及其配置
+type dbConnection struct {
+	name    string
+	manager *database.DatabaseManager
+	config  *config.DatabaseConfig
 }
 
 func NewDatabaseMCPServer(configPath string) *DatabaseMCPServer {
 	// 加载配置文件
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
-		log.Printf("Warning: Failed to load config file %s, using default config: %v", configPath, err)
-		cfg = config.LoadDefaultConfig()
+		log.Printf("Warning: Failed to load config file %s, using default config: %v", configPath, err)
+		cfg = config.LoadDefaultConfig()
+	}
+
+	// 为每个数据库配置创建管理器
+	connections := make(map[string]*dbConnection)
+	var names []string
+	for name, dbConfig := range cfg.GetDatabaseConfigs() {
+		connections[name] = &dbConnection{
+			name:    name,
+			manager: database.NewDatabaseManager(dbConfig),
+			config:  dbConfig,
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return &DatabaseMCPServer{
+		serverInfo: types.ServerInfo{
+			Name:    "database-mcp-server",
+			Version: "1.0.0",
+		},
+		connections:     connections,
+		connectionNames: names,
+		defaultDatabase: cfg.GetDefaultDatabase(),
+		serverConfig:    cfg.GetServerConfig(),
+	}
+}
+
+func (s *DatabaseMCPServer) handleInitialize(params *types.InitializeParams) *types.InitializeResult {
+	log.Printf("Initialize request: protocolVersion=%s, client=%s %s",
+		params.ProtocolVersion, params.ClientInfo.Name, params.ClientInfo.Version)
+
+	// 尝试连接所有数据库
+	for _, name := range s.connectionNames {
+		conn := s.connections[name]
+		if err := conn.manager.Connect(); err != nil {
+			log.Printf("Warning: Failed to connect to database %s: %v", name, err)
+		} else {
+			log.Printf("Successfully connected to database %s: %s", name, conn.config.Name)
+		}
+	}
+
+	return &types.InitializeResult{
+		ProtocolVersion: "2024-11-05",
+		Capabilities: types.ServerCapabilities{
+			Tools: &types.ToolsCapability{
+				ListChanged: false,
+			},
+			Resources: &types.ResourcesCapability{},
+		},
+		ServerInfo: s.serverInfo,
+	}
+}
+
+func (s *DatabaseMCPServer) handleListTools() *types.ListToolsResult {
+	tools := []types.Tool{
+		{
+			Name:        "database_query",
+			Description: "执行SQL查询并返回结果",
+			InputSchema: types.InputSchema{
+				Type: "object",
+				Properties: map[string]types.Property{
+					"database": s.databaseProperty(),
+					"sql": {
+						Type:        "string",
+						Description: "要执行的SQL查询语句，值请使用占位符（MySQL/SQLite 为 ?，PostgreSQL 为 $1、$2…）",
+					},
+					"params": {
+						Type:        "array",
+						Description: "按顺序绑定到占位符的参数值",
+					},
+					"transaction_id": {
+						Type:        "string",
+						Description: "database_begin 返回的事务句柄，在该事务中执行",
+					},
+					"offset": {
+						Type:        "integer",
+						Description: "跳过的行数，用于获取后续页（取上一页结果中的 next_offset）",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "本页最多返回的行数，不超过服务器配置的 max_rows",
+					},
+					"format": formatProperty(),
+				},
+				Required: []string{"sql"},
+			},
+		},
+		{
+			Name:        "database_tables",
+			Description: "获取数据库中的所有表名",
+			InputSchema: types.InputSchema{
+				Type: "object",
+				Properties: map[string]types.Property{
+					"database": s.databaseProperty(),
+					"format":   formatProperty(),
+				},
+			},
+		},
+		{
+			Name:        "database_schema",
+			Description: "获取指定表的结构信息",
+			InputSchema: types.InputSchema{
+				Type: "object",
+				Properties: map[string]types.Property{
+					"database": s.databaseProperty(),
+					"table_name": {
+						Type:        "string",
+						Description: "要查看结构的表名",
+					},
+					"structured": {
+						Type:        "boolean",
+						Description: "以 JSON 返回完整的表结构，包括索引、外键、检查约束、注释和统计信息（忽略 format）",
+					},
+					"format": formatProperty(),
+				},
+				Required: []string{"table_name"},
+			},
+		},
+		{
+			Name:        "database_relationships",
+			Description: "获取整个数据库的外键关系图，可只查看与某张表相关的外键",
+			InputSchema: types.InputSchema{
+				Type: "object",
+				Properties: map[string]types.Property{
+					"database": s.databaseProperty(),
+					"table_name": {
+						Type:        "string",
+						Description: "只返回引用该表或被该表引用的外键",
+					},
+					"format": formatProperty(),
+				},
+			},
+		},
+		{
+			Name:        "database_overview",
+			Description: "获取整个数据库的紧凑结构概览：所有表的字段、类型、主键/唯一键和外键关系，按 token 预算截断，适合在写SQL前了解数据库",
+			InputSchema: types.InputSchema{
+				Type: "object",
+				Properties: map[string]types.Property{
+					"database": s.databaseProperty(),
+					"include": {
+						Type:        "array",
+						Description: "只包含匹配的表，支持 * ? [...] 通配符，大小写不敏感",
+					},
+					"exclude": {
+						Type:        "array",
+						Description: "排除匹配的表，优先于 include",
+					},
+					"max_tokens": {
+						Type:        "integer",
+						Description: "概览的 token 预算（估算值），超出后剩余的表只列出名称，默认使用服务器配置",
+					},
+					"refresh": {
+						Type:        "boolean",
+						Description: "忽略缓存，重新读取表结构",
+					},
+				},
+			},
+		},
+		{
+			Name:        "database_search_schema",
+			Description: "按名称或注释搜索表和字段，返回匹配的 表.字段 及其类型，适合在大库中定位相关的表",
+			InputSchema: types.InputSchema{
+				Type: "object",
+				Properties: map[string]types.Property{
+					"database": s.databaseProperty(),
+					"pattern": {
+						Type:        "string",
+						Description: "搜索内容",
+					},
+					"mode": {
+						Type:        "string",
+						Description: "匹配方式：substring 包含（默认）、glob 通配符、regex 正则；glob 需匹配完整名称，regex 只需部分匹配",
+						Enum:        []string{database.SearchSubstring, database.SearchGlob, database.SearchRegex},
+					},
+					"in": {
+						Type:        "array",
+						Description: "搜索范围：tables、columns、comments 的任意组合，默认全部",
+					},
+					"case_sensitive": {
+						Type:        "boolean",
+						Description: "区分大小写，默认不区分",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: fmt.Sprintf("最多返回的条数，默认%d", database.DefaultSearchLimit),
+					},
+					"format": formatProperty(),
+				},
+				Required: []string{"pattern"},
+			},
+		},
+		{
+			Name:        "database_profile",
+			Description: "分析表或查询结果中每个字段的数据分布：空值比例、不同值个数、最小/最大值、平均长度和高频值，默认只抽样部分行",
+			InputSchema: types.InputSchema{
+				Type: "object",
+				Properties: map[string]types.Property{
+					"database": s.databaseProperty(),
+					"table_name": {
+						Type:        "string",
+						Description: "要分析的表名，与 sql 二选一",
+					},
+					"sql": {
+						Type:        "string",
+						Description: "要分析的单条 SELECT 查询，与 table_name 二选一",
+					},
+					"params": {
+						Type:        "array",
+						Description: "按顺序绑定到 sql 中占位符的参数值",
+					},
+					"columns": {
+						Type:        "array",
+						Description: "只分析这些字段，默认全部",
+					},
+					"sample_rows": {
+						Type:        "integer",
+						Description: fmt.Sprintf("抽样行数，默认%d", database.DefaultProfileSampleRows),
+					},
+					"random_sample": {
+						Type:        "boolean",
+						Description: "随机抽样，默认取前 sample_rows 行（随机抽样需要排序全部行，代价更高）",
+					},
+					"full": {
+						Type:        "boolean",
+						Description: "分析全部行，忽略 sample_rows",
+					},
+					"distinct": {
+						Type:        "string",
+						Description: "不同值个数：exact 精确计数（默认），approximate 优先使用数据库统计信息估算（仅对表有效）",
+						Enum:        []string{"exact", "approximate"},
+					},
+					"top": {
+						Type:        "integer",
+						Description: fmt.Sprintf("每个字段列出的高频值个数，默认%d，最多%d", database.DefaultProfileTopN, database.MaxProfileTopN),
+					},
+					"format": formatProperty(),
+				},
+			},
+		},
+		{
+			Name:        "database_status",
+			Description: "检查数据库连接状态",
+			InputSchema: types.InputSchema{
+				Type: "object",
+				Properties: map[string]types.Property{
+					"database": s.databaseProperty(),
+				},
+			},
+		},
+		{
+			Name:        "database_explain",
+			Description: "查看查询的执行计划，标出全表扫描、未使用索引和预计行数",
+			InputSchema: types.InputSchema{
+				Type: "object",
+				Properties: map[string]types.Property{
+					"database": s.databaseProperty(),
+					"sql": {
+						Type:        "string",
+						Description: "要分析的单条SQL语句",
+					},
+					"params": {
+						Type:        "array",
+						Description: "按顺序绑定到占位符的参数值",
+					},
+					"analyze": {
+						Type:        "boolean",
+						Description: "实际执行查询以获取真实行数和耗时（EXPLAIN ANALYZE），只允许只读语句",
+					},
+					"raw": {
+						Type:        "boolean",
+						Description: "同时返回数据库输出的原始执行计划",
+					},
+				},
+				Required: []string{"sql"},
+			},
+		},
+		{
+			Name:        "database_begin",
+			Description: "开启事务并返回事务句柄，供 database_query/database_execute 使用；空闲超时后自动回滚",
+			InputSchema: types.InputSchema{
+				Type: "object",
+				Properties: map[string]types.Property{
+					"database": s.databaseProperty(),
+				},
+			},
+		},
+		{
+			Name:        "database_commit",
+			Description: "提交事务",
+			InputSchema: types.InputSchema{
+				Type: "object",
+				Properties: map[string]types.Property{
+					"database": s.databaseProperty(),
+					"transaction_id": {
+						Type:        "string",
+						Description: "database_begin 返回的事务句柄",
+					},
+				},
+				Required: []string{"transaction_id"},
+			},
+		},
+		{
+			Name:        "database_rollback",
+			Description: "回滚事务",
+			InputSchema: types.InputSchema{
+				Type: "object",
+				Properties: map[string]types.Property{
+					"database": s.databaseProperty(),
+					"transaction_id": {
+						Type:        "string",
+						Description: "database_begin 返回的事务句柄",
+					},
+				},
+				Required: []string{"transaction_id"},
+			},
+		},
+		{
+			Name:        "database_connections",
+			Description: "列出配置的数据库连接及其健康状态",
+			InputSchema: types.InputSchema{
+				Type: "object",
+				Properties: map[string]types.Property{
+					"format": formatProperty(),
+				},
+			},
+		},
+	}
+
+	// 修改语句工具需在配置中显式开启，至少一个连接开启时提供
+	if s.executeEnabled() {
+		tools = append(tools, types.Tool{
+			Name:        "database_execute",
+			Description: "执行 INSERT/UPDATE/DELETE 或 DDL 语句，返回影响行数和自增ID",
+			InputSchema: types.InputSchema{
+				Type: "object",
+				Properties: map[string]types.Property{
+					"database": s.databaseProperty(),
+					"sql": {
+						Type:        "string",
+						Description: "要执行的SQL语句，值请使用占位符（MySQL/SQLite 为 ?，PostgreSQL 为 $1、$2…）",
+					},
+					"params": {
+						Type:        "array",
+						Description: "按顺序绑定到占位符的参数值",
+					},
+					"dry_run": {
+						Type:        "boolean",
+						Description: "在事务中执行后回滚，只报告影响行数",
+					},
+					"transaction_id": {
+						Type:        "string",
+						Description: "database_begin 返回的事务句柄，在该事务中执行（不能与 dry_run 同时使用）",
+					},
+				},
+				Required: []string{"sql"},
+			},
+			Annotations: &types.ToolAnnotations{
+				Title:           "Execute SQL statement",
+				ReadOnlyHint:    types.BoolPtr(false),
+				DestructiveHint: types.BoolPtr(true),
+				IdempotentHint:  types.BoolPtr(false),
+				OpenWorldHint:   types.BoolPtr(false),
+			},
+		})
+	}
+
+	return &types.ListToolsResult{
+		Tools: tools,
+	}
+}
+
+func (s *DatabaseMCPServer) handleCallTool(ctx context.Context, params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
+	switch params.Name {
+	case "database_query":
+		return s.handleDatabaseQuery(ctx, params)
+	case "database_tables":
+		return s.handleDatabaseTables(params)
+	case "database_schema":
+		return s.handleDatabaseSchema(params)
+	case "database_relationships":
+		return s.handleDatabaseRelationships(params)
+	case "database_overview":
+		return s.handleDatabaseOverview(params)
+	case "database_search_schema":
+		return s.handleDatabaseSearchSchema(params)
+	case "database_profile":
+		return s.handleDatabaseProfile(params)
+	case "database_status":
+		return s.handleDatabaseStatus(params)
+	case "database_execute":
+		return s.handleDatabaseExecute(ctx, params)
+	case "database_explain":
+		return s.handleDatabaseExplain(params)
+	case "database_begin":
+		return s.handleDatabaseBegin(params)
+	case "database_commit":
+		return s.handleDatabaseCommit(params)
+	case "database_rollback":
+		return s.handleDatabaseRollback(params)
+	case "database_connections":
+		return s.handleDatabaseConnections(params)
+	default:
+		return nil, &types.JSONRPCError{
+			Code:    -32601,
+			Message: fmt.Sprintf("Unknown tool: %s", params.Name),
+		}
+	}
+}
+
+func (s *DatabaseMCPServer) handleDatabaseQuery(ctx context.Context, params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
+	// 获取SQL参数
+	sqlQuery, ok := params.Arguments["sql"].(string)
+	if !ok || sqlQuery == "" {
+		return nil, &types.JSONRPCError{
+			Code:    -32602,
+			Message: "SQL query is required",
+		}
+	}
+
+	// 选择数据库连接
+	conn, rpcErr := s.connection(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 只读模式或未开启 allow_execute 时拒绝修改语句
+	if err := conn.manager.CheckStatement(sqlQuery); err != nil {
+		return nil, &types.JSONRPCError{
+			Code:    -32602,
+			Message: fmt.Sprintf("Statement not allowed: %v", err),
+			Data: map[string]interface{}{
+				"read_only": conn.manager.IsReadOnly(),
+				"statement": database.ClassifyStatement(sqlQuery),
+			},
+		}
+	}
+
+	// 检查数据库连接
+	if !conn.manager.IsConnected() {
+		if err := conn.manager.Connect(); err != nil {
+			return nil, &types.JSONRPCError{
+				Code:    -32603,
+				Message: fmt.Sprintf("Database connection failed: %v", err),
+			}
+		}
+	}
+
+	// 拒绝引用不可访问的表的语句
+	if rpcErr := accessError(conn.manager, sqlQuery); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 拒绝无法按列名追溯脱敏字段的查询
+	if rpcErr := redactionError(conn.manager, sqlQuery); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 获取绑定参数
+	queryParams, rpcErr := bindParams(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 获取事务句柄
+	txID, rpcErr := transactionID(params, false)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 获取输出格式
+	formatter, rpcErr := formatArgument(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 获取分页参数
+	offset, rpcErr := intArgument(params, "offset")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	limit, rpcErr := intArgument(params, "limit")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	page := database.Page{Offset: offset, Limit: limit}
+
+	// 执行查询
+	var result *database.QueryResult
+	if txID != "" {
+		result = conn.manager.ExecuteQueryTxContext(ctx, txID, sqlQuery, page, queryParams...)
+	} else {
+		result = conn.manager.ExecuteQueryContext(ctx, sqlQuery, page, queryParams...)
+	}
+	if violation := result.CostViolation; violation != nil {
+		return nil, &types.JSONRPCError{
+			Code:    -32602,
+			Message: fmt.Sprintf("Query rejected by cost guard: %s", strings.Join(violation.Reasons, "; ")),
+			Data: map[string]interface{}{
+				"cost_guard": violation.Reasons,
+				"plan":       violation.Plan,
+			},
+		}
+	}
+	if result.Error != "" {
+		return nil, &types.JSONRPCError{
+			Code:    -32603,
+			Message: fmt.Sprintf("Query execution failed: %v", result.Error),
+		}
+	}
+
+	// 按指定格式输出，截断提示单独作为一项内容，不影响解析
+	if formatter != nil {
+		content, rpcErr := formatContent(formatter, result)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		if result.Truncated {
+			content = append(content, types.ContentItem{
+				Type: "text",
+				Text: truncationNote(result),
+			})
+		}
+		if result.Redaction != nil {
+			content = append(content, types.ContentItem{
+				Type: "text",
+				Text: redactionNote(result.Redaction),
+			})
+		}
+		return &types.CallToolResult{Content: content}, nil
+	}
+
+	// 格式化结果
+	resultText := fmt.Sprintf("✅ 查询执行成功！\n\n")
+	if txID != "" {
+		resultText += fmt.Sprintf("🔐 事务：%s\n\n", txID)
+	}
+	resultText += "📊 查询结果：\n"
+	resultText += fmt.Sprintf("📋 列数：%d\n", len(result.Columns))
+	if result.Offset > 0 {
+		resultText += fmt.Sprintf("📝 行数：%d（第 %d-%d 行）\n\n", result.Count, result.Offset+1, result.Offset+result.Count)
+	} else {
+		resultText += fmt.Sprintf("📝 行数：%d\n\n", result.Count)
+	}
+
+	// 添加列名
+	if len(result.Columns) > 0 {
+		resultText += "🏷️  列名：\n"
+		for i, col := range result.ColumnTypes {
+			if col.DatabaseType != "" {
+				resultText += fmt.Sprintf("  %d. %s (%s)\n", i+1, col.Name, col.DatabaseType)
+			} else {
+				resultText += fmt.Sprintf("  %d. %s\n", i+1, col.Name)
+			}
+		}
+		resultText += "\n"
+	}
+
+	// 添加数据行
+	resultText += "📊 数据行：\n"
+	for i, row := range result.Rows {
+		resultText += fmt.Sprintf("  行 %d: ", result.Offset+i+1)
+		for j, cell := range row {
+			if j > 0 {
+				resultText += " | "
+			}
+			resultText += database.FormatValue(cell)
+		}
+		resultText += "\n"
+	}
+
+	if result.Truncated {
+		resultText += "\n" + truncationNote(result)
+	}
+	if result.Redaction != nil {
+		resultText += "\n" + redactionNote(result.Redaction)
+	}
+
+	return &types.CallToolResult{
+		Content: []types.ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+func (s *DatabaseMCPServer) handleDatabaseExecute(ctx context.Context, params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
+	// 所有连接都未开启时与未知工具一致
+	if !s.executeEnabled() {
+		return nil, &types.JSONRPCError{
+			Code:    -32601,
+			Message: fmt.Sprintf("Unknown tool: %s", params.Name),
+		}
+	}
+
+	// 获取SQL参数
+	sqlQuery, ok := params.Arguments["sql"].(string)
+	if !ok || sqlQuery == "" {
+		return nil, &types.JSONRPCError{
+			Code:    -32602,
+			Message: "SQL statement is required",
+		}
+	}
+
+	dryRun, rpcErr := boolArgument(params, "dry_run")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 获取事务句柄
+	txID, rpcErr := transactionID(params, false)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if txID != "" && dryRun {
+		return nil, &types.JSONRPCError{
+			Code:    -32602,
+			Message: "dry_run cannot be combined with transaction_id; roll back the transaction instead",
+		}
+	}
+
+	// 选择数据库连接
+	conn, rpcErr := s.connection(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	if !conn.config.ExecuteEnabled() {
+		return nil, &types.JSONRPCError{
+			Code:    -32602,
+			Message: fmt.Sprintf("execute is disabled for database %q (allow_execute is false or read_only is true)", conn.name),
+		}
+	}
+
+	// 检查数据库连接
+	if !conn.manager.IsConnected() {
+		if err := conn.manager.Connect(); err != nil {
+			return nil, &types.JSONRPCError{
+				Code:    -32603,
+				Message: fmt.Sprintf("Database connection failed: %v", err),
+			}
+		}
+	}
+
+	// 拒绝引用不可访问的表的语句
+	if rpcErr := accessError(conn.manager, sqlQuery); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 获取绑定参数
+	execParams, rpcErr := bindParams(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 执行语句
+	var result *database.ExecResult
+	if txID != "" {
+		result = conn.manager.ExecuteStatementTxContext(ctx, txID, sqlQuery, execParams...)
+	} else {
+		result = conn.manager.ExecuteStatementContext(ctx, sqlQuery, dryRun, execParams...)
+	}
+	if result.Error != "" {
+		return nil, &types.JSONRPCError{
+			Code:    -32603,
+			Message: fmt.Sprintf("Statement execution failed: %v", result.Error),
+			Data: map[string]interface{}{
+				"class":          result.Class,
+				"dry_run":        dryRun,
+				"transaction_id": txID,
+			},
+		}
+	}
+
+	// 格式化结果
+	var resultText string
+	if result.DryRun {
+		resultText = "🧪 演练执行成功（已回滚，未修改数据）！\n\n"
+	} else if txID != "" {
+		resultText = fmt.Sprintf("✅ 语句已在事务中执行（提交前不会生效）！\n\n🔐 事务：%s\n", txID)
+	} else {
+		resultText = "✅ 语句执行成功！\n\n"
+	}
+	resultText += fmt.Sprintf("🏷️  语句类型：%s\n", result.Class)
+	resultText += fmt.Sprintf("📝 影响行数：%d\n", result.RowsAffected)
+	if result.LastInsertID != nil {
+		resultText += fmt.Sprintf("🔑 自增ID：%d\n", *result.LastInsertID)
+	}
+
+	return &types.CallToolResult{
+		Content: []types.ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+func (s *DatabaseMCPServer) handleDatabaseExplain(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
+	// 获取SQL参数
+	sqlQuery, ok := params.Arguments["sql"].(string)
+	if !ok || sqlQuery == "" {
+		return nil, &types.JSONRPCError{
+			Code:    -32602,
+			Message: "SQL query is required",
+		}
+	}
+
+	analyze, rpcErr := boolArgument(params, "analyze")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	includeRaw, rpcErr := boolArgument(params, "raw")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 选择数据库连接
+	conn, rpcErr := s.connection(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 检查数据库连接
+	if !conn.manager.IsConnected() {
+		if err := conn.manager.Connect(); err != nil {
+			return nil, &types.JSONRPCError{
+				Code:    -32603,
+				Message: fmt.Sprintf("Database connection failed: %v", err),
+			}
+		}
+	}
+
+	// 拒绝引用不可访问的表的语句
+	if rpcErr := accessError(conn.manager, sqlQuery); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 获取绑定参数
+	queryParams, rpcErr := bindParams(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	plan, err := conn.manager.ExplainQuery(sqlQuery, analyze, queryParams...)
+	if err != nil {
+		return nil, &types.JSONRPCError{
+			Code:    -32603,
+			Message: fmt.Sprintf("Explain failed: %v", err),
+		}
+	}
+
+	// 格式化结果
+	resultText := fmt.Sprintf("📈 执行计划（%s", plan.Dialect)
+	if plan.Analyzed {
+		resultText += "，已实际执行"
+	}
+	resultText += "）\n"
+	if plan.EstimatedRows != nil {
+		resultText += fmt.Sprintf("📝 预计行数：%s\n", database.FormatPlanNumber(*plan.EstimatedRows))
+	}
+	if plan.TotalCost != nil {
+		resultText += fmt.Sprintf("💰 预计成本：%s\n", database.FormatPlanNumber(*plan.TotalCost))
+	}
+	if plan.ExecutionTimeMs != nil {
+		resultText += fmt.Sprintf("⏱️  执行耗时：%.3f ms\n", *plan.ExecutionTimeMs)
+	}
+
+	resultText += "\n🌲 计划树：\n"
+	plan.Walk(func(node *database.PlanNode, depth int) {
+		resultText += strings.Repeat("  ", depth+1) + "- " + describePlanNode(node) + "\n"
+	})
+
+	// 需要关注的节点
+	var warnings []string
+	plan.Walk(func(node *database.PlanNode, depth int) {
+		name := node.Table
+		if name == "" {
+			name = node.Operation
+		}
+		if node.FullScan {
+			warning := fmt.Sprintf("%s：全表扫描", name)
+			if node.Index != "" {
+				warning = fmt.Sprintf("%s：全索引扫描（%s）", name, node.Index)
+			}
+			if node.EstimatedRows != nil {
+				warning += fmt.Sprintf("，预计 %s 行", database.FormatPlanNumber(*node.EstimatedRows))
+			}
+			warnings = append(warnings, warning)
+		}
+		if node.MissingIndex {
+			warning := fmt.Sprintf("%s：按条件过滤但未使用索引", name)
+			if len(node.PossibleIndexes) > 0 {
+				warning += fmt.Sprintf("（可用索引：%s）", strings.Join(node.PossibleIndexes, ", "))
+			} else if node.Condition != "" {
+				warning += fmt.Sprintf("，考虑为 %s 建立索引", node.Condition)
+			}
+			warnings = append(warnings, warning)
+		}
+		if node.TempStructure {
+			warnings = append(warnings, fmt.Sprintf("%s：使用临时表或额外排序", name))
+		}
+	})
+
+	if len(warnings) > 0 {
+		resultText += "\n⚠️  需要关注：\n"
+		for _, warning := range warnings {
+			resultText += "  - " + warning + "\n"
+		}
+	} else {
+		resultText += "\n✅ 未发现全表扫描或未使用索引的情况\n"
+	}
+
+	content := []types.ContentItem{
+		{
+			Type: "text",
+			Text: resultText,
+		},
+	}
+	if includeRaw {
+		content = append(content, types.ContentItem{
+			Type: "text",
+			Text: plan.Raw,
+		})
+	}
+
+	return &types.CallToolResult{
+		Content: content,
+	}, nil
+}
+
+// describePlanNode 单个计划节点的一行描述
+func describePlanNode(node *database.PlanNode) string {
+	text := node.Operation
+	if node.Table != "" && !strings.Contains(text, node.Table) {
+		text += " " + node.Table
+	}
+	if node.Index != "" && !strings.Contains(text, node.Index) {
+		text += " 使用索引 " + node.Index
+	}
+	if node.EstimatedRows != nil {
+		text += fmt.Sprintf("  预计行数=%s", database.FormatPlanNumber(*node.EstimatedRows))
+	}
+	if node.ActualRows != nil {
+		text += fmt.Sprintf("  实际行数=%s", database.FormatPlanNumber(*node.ActualRows))
+	}
+	if node.Cost != nil {
+		text += fmt.Sprintf("  成本=%s", database.FormatPlanNumber(*node.Cost))
+	}
+	if node.Condition != "" && !strings.Contains(text, node.Condition) {
+		text += fmt.Sprintf("  条件：%s", node.Condition)
+	}
+	if node.FullScan || node.MissingIndex {
+		text += " ⚠️"
+	}
+	return text
+}
+
+// formatLimit 格式化时长限制，0 显示为不限制
+func formatLimit(d time.Duration) string {
+	if d <= 0 {
+		return "不限制"
+	}
+	return d.String()
+}
+
+func (s *DatabaseMCPServer) handleDatabaseBegin(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
+	// 选择数据库连接
+	conn, rpcErr := s.connection(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 检查数据库连接
+	if !conn.manager.IsConnected() {
+		if err := conn.manager.Connect(); err != nil {
+			return nil, &types.JSONRPCError{
+				Code:    -32603,
+				Message: fmt.Sprintf("Database connection failed: %v", err),
+			}
+		}
+	}
+
+	info, err := conn.manager.BeginTransaction()
+	if err != nil {
+		return nil, &types.JSONRPCError{
+			Code:    -32603,
+			Message: fmt.Sprintf("Failed to begin transaction: %v", err),
+		}
+	}
+
+	// 格式化结果
+	resultText := "✅ 事务已开启！\n\n"
+	resultText += fmt.Sprintf("🔐 事务句柄：%s\n", info.ID)
+	if info.ReadOnly {
+		resultText += "🔒 模式：只读\n"
+	}
+	resultText += fmt.Sprintf("⏱️  空闲超时：%v（超时后自动回滚）\n\n", conn.config.GetTransactionIdleTimeout())
+	resultText += "💡 在 database_query / database_execute 中传入 transaction_id 使用该事务，完成后调用 database_commit 或 database_rollback"
+
+	return &types.CallToolResult{
+		Content: []types.ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+func (s *DatabaseMCPServer) handleDatabaseCommit(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
+	txID, rpcErr := transactionID(params, true)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 选择数据库连接
+	conn, rpcErr := s.connection(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	info, err := conn.manager.CommitTransaction(txID)
+	if err != nil {
+		return nil, &types.JSONRPCError{
+			Code:    -32603,
+			Message: fmt.Sprintf("Commit failed: %v", err),
+		}
+	}
+
+	resultText := "✅ 事务已提交！\n\n"
+	resultText += fmt.Sprintf("🔐 事务：%s\n", info.ID)
+	resultText += fmt.Sprintf("📝 执行语句数：%d\n", info.Statements)
+	resultText += fmt.Sprintf("⏱️  持续时间：%v\n", time.Since(info.StartedAt).Round(time.Millisecond))
+
+	return &types.CallToolResult{
+		Content: []types.ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+func (s *DatabaseMCPServer) handleDatabaseRollback(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
+	txID, rpcErr := transactionID(params, true)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 选择数据库连接
+	conn, rpcErr := s.connection(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	info, err := conn.manager.RollbackTransaction(txID)
+	if err != nil {
+		return nil, &types.JSONRPCError{
+			Code:    -32603,
+			Message: fmt.Sprintf("Rollback failed: %v", err),
+		}
+	}
+
+	resultText := "↩️  事务已回滚！\n\n"
+	resultText += fmt.Sprintf("🔐 事务：%s\n", info.ID)
+	resultText += fmt.Sprintf("📝 撤销语句数：%d\n", info.Statements)
+
+	return &types.CallToolResult{
+		Content: []types.ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+func (s *DatabaseMCPServer) handleDatabaseConnections(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
+	// 获取输出格式
+	formatter, rpcErr := formatArgument(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	result := &database.QueryResult{
+		Columns: []string{"name", "driver", "location", "default", "read_only", "execute", "connected", "latency_ms", "error"},
+	}
+	resultText := fmt.Sprintf("🔗 数据库连接（%d 个）\n\n", len(s.connectionNames))
+
+	for _, name := range s.connectionNames {
+		conn := s.connections[name]
+		health := checkHealth(conn)
+
+		var latency, healthError interface{}
+		if health.connected {
+			latency = float64(health.latency.Microseconds()) / 1000
+		} else {
+			healthError = health.err
+		}
+		result.Rows = append(result.Rows, []interface{}{
+			name, conn.config.Driver, conn.config.GetLocation(), name == s.defaultDatabase,
+			conn.config.ReadOnly, conn.config.ExecuteEnabled(), health.connected, latency, healthError,
+		})
+
+		if name == s.defaultDatabase {
+			resultText += fmt.Sprintf("⭐ %s（默认）\n", name)
+		} else {
+			resultText += fmt.Sprintf("🗄️  %s\n", name)
+		}
+		resultText += fmt.Sprintf("   🔌 驱动：%s\n", conn.config.Driver)
+		resultText += fmt.Sprintf("   📍 位置：%s\n", conn.config.GetLocation())
+		switch {
+		case conn.config.ReadOnly:
+			resultText += "   🔒 模式：只读\n"
+		case conn.config.ExecuteEnabled():
+			resultText += "   ✏️  模式：允许修改语句\n"
+		}
+		if health.connected {
+			resultText += fmt.Sprintf("   📊 状态：✅ 已连接（%v）\n\n", health.latency.Round(time.Microsecond))
+		} else {
+			resultText += fmt.Sprintf("   📊 状态：❌ 连接失败：%s\n\n", health.err)
+		}
+	}
+	result.Count = len(result.Rows)
+
+	if formatter != nil {
+		content, rpcErr := formatContent(formatter, result)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		return &types.CallToolResult{Content: content}, nil
+	}
+
+	return &types.CallToolResult{
+		Content: []types.ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+// connectionHealth 连接的健康检查结果
+type connectionHealth struct {
+	connected bool
+	latency   time.Duration
+	err       string
+}
+
+// checkHealth 检查连接是否可用，未连接时尝试连接
+func checkHealth(conn *dbConnection) connectionHealth {
+	start := time.Now()
+	if conn.manager.IsConnected() {
+		return connectionHealth{connected: true, latency: time.Since(start)}
+	}
+
+	start = time.Now()
+	if err := conn.manager.Connect(); err != nil {
+		return connectionHealth{err: err.Error()}
+	}
+	return connectionHealth{connected: true, latency: time.Since(start)}
+}
+
+// databaseProperty database 参数的定义
+func (s *DatabaseMCPServer) databaseProperty() types.Property {
+	return types.Property{
+		Type:        "string",
+		Description: fmt.Sprintf("数据库连接名称，默认为 %s（可通过 database_connections 查看）", s.defaultDatabase),
+		Enum:        s.connectionNames,
+	}
+}
+
+// connection 按 database 参数选择连接。
+// 未指定时，若 transaction_id 属于某个连接则使用该连接，否则使用默认连接
+func (s *DatabaseMCPServer) connection(params *types.CallToolParams) (*dbConnection, *types.JSONRPCError) {
+	raw, exists := params.Arguments["database"]
+	if !exists || raw == nil || raw == "" {
+		if txID, ok := params.Arguments["transaction_id"].(string); ok && txID != "" {
+			for _, name := range s.connectionNames {
+				if s.connections[name].manager.HasTransaction(txID) {
+					return s.connections[name], nil
+				}
+			}
+		}
+		return s.connections[s.defaultDatabase], nil
+	}
+
+	name, ok := raw.(string)
+	if !ok {
+		return nil, &types.JSONRPCError{
+			Code:    -32602,
+			Message: "database must be a string",
+		}
+	}
+
+	conn, ok := s.connections[name]
+	if !ok {
+		return nil, &types.JSONRPCError{
+			Code:    -32602,
+			Message: fmt.Sprintf("unknown database: %q (available: %s)", name, strings.Join(s.connectionNames, ", ")),
+		}
+	}
+	return conn, nil
+}
+
+// executeEnabled 是否有连接允许执行修改语句
+func (s *DatabaseMCPServer) executeEnabled() bool {
+	for _, conn := range s.connections {
+		if conn.config.ExecuteEnabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// truncationNote 结果截断提示
+func truncationNote(result *database.QueryResult) string {
+	reason := "达到每页行数"
+	if result.TruncatedBy == database.TruncatedByMaxBytes {
+		reason = "达到结果大小上限"
+	}
+	return fmt.Sprintf("⚠️  结果已截断（%s），还有更多数据。使用 offset=%d 获取下一页", reason, result.NextOffset)
+}
+
+// redactionNote 脱敏说明，列出被处理的列、方式和匹配的规则
+func redactionNote(report *database.RedactionReport) string {
+	parts := make([]string, 0, len(report.Columns))
+	for _, col := range report.Columns {
+		if col.Action == config.RedactDrop {
+			parts = append(parts, fmt.Sprintf("%s（已删除，规则 %s）", col.Column, col.Rule))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s（%s，规则 %s，%d 个值）", col.Column, col.Action, col.Rule, col.Values))
+	}
+	return "🛡️  已按脱敏策略处理：" + strings.Join(parts, "；")
+}
+
+// formatProperty format 参数的定义
+func formatProperty() types.Property {
+	return types.Property{
+		Type:        "string",
+		Description: "输出格式，不指定时为带说明的文本",
+		Enum:        database.FormatNames(),
+	}
+}
+
+// formatArgument 读取 format 参数，未指定时返回nil
+func formatArgument(params *types.CallToolParams) (database.Formatter, *types.JSONRPCError) {
+	raw, exists := params.Arguments["format"]
+	if !exists || raw == nil || raw == "" {
+		return nil, nil
+	}
+
+	name, ok := raw.(string)
+	if !ok {
+		return nil, &types.JSONRPCError{
+			Code:    -32602,
+			Message: "format must be a string",
+		}
+	}
+
+	formatter, err := database.GetFormatter(name)
+	if err != nil {
+		return nil, &types.JSONRPCError{
+			Code:    -32602,
+			Message: err.Error(),
+		}
+	}
+	return formatter, nil
+}
+
+// formatContent 使用格式化器生成工具结果内容
+func formatContent(formatter database.Formatter, result *database.QueryResult) ([]types.ContentItem, *types.JSONRPCError) {
+	text, err := formatter.Format(result)
+	if err != nil {
+		return nil, &types.JSONRPCError{
+			Code:    -32603,
+			Message: fmt.Sprintf("Failed to format result as %s: %v", formatter.Name(), err),
+		}
+	}
+	return []types.ContentItem{
+		{
+			Type: "text",
+			Text: text,
+		},
+	}, nil
+}
+
+// boolArgument 读取可选的布尔参数，未提供时返回 false
+func boolArgument(params *types.CallToolParams, name string) (bool, *types.JSONRPCError) {
+	raw, exists := params.Arguments[name]
+	if !exists || raw == nil {
+		return false, nil
+	}
+
+	value, ok := raw.(bool)
+	if !ok {
+		return false, &types.JSONRPCError{
+			Code:    -32602,
+			Message: fmt.Sprintf("%s must be a boolean", name),
+		}
+	}
+	return value, nil
+}
+
+// intArgument 读取可选的非负整数参数，未提供时返回0
+func intArgument(params *types.CallToolParams, name string) (int, *types.JSONRPCError) {
+	raw, exists := params.Arguments[name]
+	if !exists || raw == nil {
+		return 0, nil
+	}
+
+	value, ok := raw.(float64)
+	if !ok || value != float64(int(value)) || value < 0 {
+		return 0, &types.JSONRPCError{
+			Code:    -32602,
+			Message: fmt.Sprintf("%s must be a non-negative integer", name),
+		}
+	}
+	return int(value), nil
+}
+
+// stringListArgument 读取可选的字符串数组参数，未提供时返回nil
+func stringListArgument(params *types.CallToolParams, name string) ([]string, *types.JSONRPCError) {
+	raw, exists := params.Arguments[name]
+	if !exists || raw == nil {
+		return nil, nil
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, &types.JSONRPCError{
+			Code:    -32602,
+			Message: fmt.Sprintf("%s must be an array of strings", name),
+		}
+	}
+
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		value, ok := item.(string)
+		if !ok {
+			return nil, &types.JSONRPCError{
+				Code:    -32602,
+				Message: fmt.Sprintf("%s must be an array of strings", name),
+			}
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// transactionID 读取 transaction_id 参数，可选时未提供返回空字符串
+func transactionID(params *types.CallToolParams, required bool) (string, *types.JSONRPCError) {
+	raw, exists := params.Arguments["transaction_id"]
+	if !exists || raw == nil || raw == "" {
+		if required {
+			return "", &types.JSONRPCError{
+				Code:    -32602,
+				Message: "transaction_id is required",
+			}
+		}
+		return "", nil
+	}
+
+	id, ok := raw.(string)
+	if !ok {
+		return "", &types.JSONRPCError{
+			Code:    -32602,
+			Message: "transaction_id must be a string",
+		}
+	}
+	return id, nil
+}
+
+// bindParams 读取 params 参数并转换为绑定值
+func bindParams(params *types.CallToolParams) ([]interface{}, *types.JSONRPCError) {
+	raw, exists := params.Arguments["params"]
+	if !exists || raw == nil {
+		return nil, nil
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, &types.JSONRPCError{
+			Code:    -32602,
+			Message: "params must be an array",
+		}
+	}
+
+	values := make([]interface{}, 0, len(list))
+	for _, value := range list {
+		values = append(values, bindValue(value))
+	}
+	return values, nil
+}
+
+// bindValue 将JSON参数转换为驱动可绑定的值：整数形式的数字转为int64，数组和对象编码为JSON字符串
+func bindValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+		return v
+	case []interface{}, map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	default:
+		return v
+	}
+}
+
+func (s *DatabaseMCPServer) handleDatabaseTables(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
+	// 获取输出格式
+	formatter, rpcErr := formatArgument(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 选择数据库连接
+	conn, rpcErr := s.connection(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 检查数据库连接
+	if !conn.manager.IsConnected() {
+		if err := conn.manager.Connect(); err != nil {
+			return nil, &types.JSONRPCError{
+				Code:    -32603,
+				Message: fmt.Sprintf("Database connection failed: %v", err),
+			}
+		}
+	}
+
+	// 获取表列表
+	tables, err := conn.manager.GetTableInfo()
+	if err != nil {
+		return nil, &types.JSONRPCError{
+			Code:    -32603,
+			Message: fmt.Sprintf("Failed to get tables: %v", err),
+		}
+	}
+
+	if formatter != nil {
+		content, rpcErr := formatContent(formatter, database.TablesResult(tables))
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		return &types.CallToolResult{Content: content}, nil
+	}
+
+	// 格式化结果
+	resultText := fmt.Sprintf("📋 数据库表列表\n\n")
+	resultText += fmt.Sprintf("🔗 连接：%s\n", conn.name)
+	resultText += fmt.Sprintf("🗄️  数据库：%s\n", conn.config.Name)
+	resultText += fmt.Sprintf("📊 表总数：%d\n\n", len(tables))
+
+	if len(tables) > 0 {
+		resultText += "📝 表名列表：\n"
+		for i, table := range tables {
+			resultText += fmt.Sprintf("  %d. %s\n", i+1, table)
+		}
+	} else {
+		resultText += "❌ 没有找到任何表"
+	}
+
+	return &types.CallToolResult{
+		Content: []types.ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+func (s *DatabaseMCPServer) handleDatabaseSchema(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
+	// 获取表名参数
+	tableName, ok := params.Arguments["table_name"].(string)
+	if !ok || tableName == "" {
+		return nil, &types.JSONRPCError{
+			Code:    -32602,
+			Message: "Table name is required",
+		}
+	}
+
+	// 获取输出格式
+	formatter, rpcErr := formatArgument(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	structured, rpcErr := boolArgument(params, "structured")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 选择数据库连接
+	conn, rpcErr := s.connection(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 检查数据库连接
+	if !conn.manager.IsConnected() {
+		if err := conn.manager.Connect(); err != nil {
+			return nil, &types.JSONRPCError{
+				Code:    -32603,
+				Message: fmt.Sprintf("Database connection failed: %v", err),
+			}
+		}
+	}
+
+	// 获取表结构
+	schema, err := conn.manager.GetTableSchema(tableName)
+	if err != nil {
+		return nil, &types.JSONRPCError{
+			Code:    -32603,
+			Message: fmt.Sprintf("Failed to get table schema: %v", err),
+		}
+	}
+
+	if structured {
+		data, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return nil, &types.JSONRPCError{
+				Code:    -32603,
+				Message: fmt.Sprintf("Failed to encode table schema: %v", err),
+			}
+		}
+		return &types.CallToolResult{
+			Content: []types.ContentItem{
+				{
+					Type: "text",
+					Text: string(data),
+				},
+			},
+		}, nil
+	}
+
+	if formatter != nil {
+		content, rpcErr := formatContent(formatter, database.SchemaResult(schema))
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		return &types.CallToolResult{Content: content}, nil
+	}
+
+	// 格式化结果
+	resultText := fmt.Sprintf("🏗️  表结构信息\n\n")
+	resultText += fmt.Sprintf("📋 表名：%s\n", schema.Name)
+	if schema.Comment != "" {
+		resultText += fmt.Sprintf("💬 注释：%s\n", schema.Comment)
+	}
+	resultText += fmt.Sprintf("📊 字段数：%d\n", len(schema.Columns))
+	if stats := schema.Stats; stats != nil {
+		if stats.EstimatedRows != nil {
+			resultText += fmt.Sprintf("📈 预计行数：%d\n", *stats.EstimatedRows)
+		}
+		if stats.DataBytes != nil {
+			resultText += fmt.Sprintf("💾 数据大小：%s\n", formatBytes(*stats.DataBytes))
+		}
+		if stats.IndexBytes != nil {
+			resultText += fmt.Sprintf("🗂️  索引大小：%s\n", formatBytes(*stats.IndexBytes))
+		}
+	}
+	resultText += "\n"
+
+	if len(schema.Columns) > 0 {
+		resultText += "📝 字段详情：\n"
+		for i, col := range schema.Columns {
+			nullable := "NO"
+			if col.Nullable {
+				nullable = "YES"
+			}
+			defaultValue := "NULL"
+			if col.Default != nil {
+				defaultValue = *col.Default
+			}
+
+			resultText += fmt.Sprintf("  %d. %s\n", i+1, col.Name)
+			resultText += fmt.Sprintf("     类型: %s\n", col.Type)
+			resultText += fmt.Sprintf("     可空: %s\n", nullable)
+			resultText += fmt.Sprintf("     键: %s\n", col.Key)
+			resultText += fmt.Sprintf("     默认值: %s\n", defaultValue)
+			resultText += fmt.Sprintf("     额外: %s\n", col.Extra)
+			if col.Comment != "" {
+				resultText += fmt.Sprintf("     注释: %s\n", col.Comment)
+			}
+			resultText += "\n"
+		}
+	}
+
+	if len(schema.Indexes) > 0 {
+		resultText += "🔑 索引：\n"
+		for _, index := range schema.Indexes {
+			kind := "普通"
+			switch {
+			case index.Primary:
+				kind = "主键"
+			case index.Unique:
+				kind = "唯一"
+			}
+			if index.Type != "" {
+				kind += "，" + index.Type
+			}
+			resultText += fmt.Sprintf("  - %s（%s）：%s\n", index.Name, kind, strings.Join(index.Columns, ", "))
+		}
+		resultText += "\n"
+	}
+
+	if len(schema.ForeignKeys) > 0 {
+		resultText += "🔗 外键：\n"
+		for _, key := range schema.ForeignKeys {
+			resultText += "  - " + describeForeignKey(key) + "\n"
+		}
+		resultText += "\n"
+	}
+
+	if len(schema.ReferencedBy) > 0 {
+		resultText += "↩️  被引用：\n"
+		for _, key := range schema.ReferencedBy {
+			resultText += "  - " + describeForeignKey(key) + "\n"
+		}
+		resultText += "\n"
+	}
+
+	if len(schema.Checks) > 0 {
+		resultText += "✔️  检查约束：\n"
+		for _, check := range schema.Checks {
+			if check.Name != "" {
+				resultText += fmt.Sprintf("  - %s：%s\n", check.Name, check.Expression)
+			} else {
+				resultText += fmt.Sprintf("  - %s\n", check.Expression)
+			}
+		}
+		resultText += "\n"
+	}
+
+	return &types.CallToolResult{
+		Content: []types.ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+func (s *DatabaseMCPServer) handleDatabaseRelationships(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
+	// 可选的表名过滤
+	tableName := ""
+	if raw, exists := params.Arguments["table_name"]; exists && raw != nil {
+		name, ok := raw.(string)
+		if !ok {
+			return nil, &types.JSONRPCError{
+				Code:    -32602,
+				Message: "table_name must be a string",
+			}
+		}
+		tableName = name
+	}
+
+	// 获取输出格式
+	formatter, rpcErr := formatArgument(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 选择数据库连接
+	conn, rpcErr := s.connection(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 检查数据库连接
+	if !conn.manager.IsConnected() {
+		if err := conn.manager.Connect(); err != nil {
+			return nil, &types.JSONRPCError{
+				Code:    -32603,
+				Message: fmt.Sprintf("Database connection failed: %v", err),
+			}
+		}
+	}
+
+	// 获取外键关系
+	keys, err := conn.manager.GetRelationships(tableName)
+	if err != nil {
+		return nil, &types.JSONRPCError{
+			Code:    -32603,
+			Message: fmt.Sprintf("Failed to get relationships: %v", err),
+		}
+	}
+
+	if formatter != nil {
+		content, rpcErr := formatContent(formatter, database.RelationshipsResult(keys))
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		return &types.CallToolResult{Content: content}, nil
+	}
+
+	// 格式化结果
+	resultText := fmt.Sprintf("🕸️  外键关系图\n\n")
+	resultText += fmt.Sprintf("🔗 连接：%s\n", conn.name)
+	if tableName != "" {
+		resultText += fmt.Sprintf("📋 表：%s\n", tableName)
+	}
+	resultText += fmt.Sprintf("📊 外键数：%d\n\n", len(keys))
+
+	if len(keys) > 0 {
+		resultText += "📝 外键列表：\n"
+		for i, key := range keys {
+			resultText += fmt.Sprintf("  %d. %s\n", i+1, describeForeignKey(key))
+		}
+
+		// 按表汇总引用关系
+		references := make(map[string][]string)
+		referencedBy := make(map[string][]string)
+		var tables []string
+		for _, key := range keys {
+			for _, table := range []string{key.Table, key.ReferencedTable} {
+				if _, seen := references[table]; !seen {
+					references[table] = nil
+					tables = append(tables, table)
+				}
+			}
+			references[key.Table] = appendUnique(references[key.Table], key.ReferencedTable)
+			referencedBy[key.ReferencedTable] = appendUnique(referencedBy[key.ReferencedTable], key.Table)
+		}
+		sort.Strings(tables)
+
+		resultText += "\n📋 按表汇总：\n"
+		for _, table := range tables {
+			resultText += fmt.Sprintf("  - %s\n", table)
+			if len(references[table]) > 0 {
+				resultText += fmt.Sprintf("     引用：%s\n", strings.Join(references[table], ", "))
+			}
+			if len(referencedBy[table]) > 0 {
+				resultText += fmt.Sprintf("     被引用：%s\n", strings.Join(referencedBy[table], ", "))
+			}
+		}
+	} else {
+		resultText += "❌ 没有找到任何外键"
+	}
+
+	return &types.CallToolResult{
+		Content: []types.ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+// describeForeignKey 外键的一行描述，如 orders(user_id) → users(id) ON DELETE CASCADE
+func describeForeignKey(key database.ForeignKey) string {
+	text := fmt.Sprintf("%s(%s) → %s(%s)", key.Table, strings.Join(key.Columns, ", "),
+		key.ReferencedTable, strings.Join(key.ReferencedColumns, ", "))
+	if key.OnUpdate != "" && key.OnUpdate != "NO ACTION" {
+		text += " ON UPDATE " + key.OnUpdate
+	}
+	if key.OnDelete != "" && key.OnDelete != "NO ACTION" {
+		text += " ON DELETE " + key.OnDelete
+	}
+	if key.Name != "" {
+		text += fmt.Sprintf("（%s）", key.Name)
+	}
+	return text
+}
+
+// appendUnique 追加不重复的值
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// formatBytes 格式化字节数，如 16.0 KB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n)
+	suffixes := []string{"KB", "MB", "GB", "TB"}
+	i := -1
+	for value >= unit && i < len(suffixes)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, suffixes[i])
+}
+
+func (s *DatabaseMCPServer) handleDatabaseOverview(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
+	// 获取过滤条件和预算
+	include, rpcErr := stringListArgument(params, "include")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	exclude, rpcErr := stringListArgument(params, "exclude")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	maxTokens, rpcErr := intArgument(params, "max_tokens")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	refresh, rpcErr := boolArgument(params, "refresh")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 选择数据库连接
+	conn, rpcErr := s.connection(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	resultText, rpcErr := s.overviewText(conn, database.OverviewOptions{
+		Include:   include,
+		Exclude:   exclude,
+		MaxTokens: maxTokens,
+	}, refresh)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	return &types.CallToolResult{
+		Content: []types.ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+// overviewText 生成连接的数据库概览。配置中的 include/exclude 始终生效，
+// opts 中的过滤条件在此基础上进一步筛选，MaxTokens 为0时使用配置的预算
+func (s *DatabaseMCPServer) overviewText(conn *dbConnection, opts database.OverviewOptions, refresh bool) (string, *types.JSONRPCError) {
+	if opts.MaxTokens == 0 {
+		opts.MaxTokens = conn.config.Overview.GetMaxTokens()
+	}
+
+	// 检查数据库连接
+	if !conn.manager.IsConnected() {
+		if err := conn.manager.Connect(); err != nil {
+			return "", &types.JSONRPCError{
+				Code:    -32603,
+				Message: fmt.Sprintf("Database connection failed: %v", err),
+			}
+		}
+	}
+
+	snapshot, err := conn.manager.SchemaSnapshot(refresh)
+	if err != nil {
+		return "", &types.JSONRPCError{
+			Code:    -32603,
+			Message: fmt.Sprintf("Failed to get schema overview: %v", err),
+		}
+	}
+
+	// 先应用配置中的过滤条件
+	cfg := conn.config.Overview
+	for _, patterns := range [][]string{cfg.Include, cfg.Exclude} {
+		if err := database.ValidateTablePatterns(patterns); err != nil {
+			return "", &types.JSONRPCError{
+				Code:    -32603,
+				Message: fmt.Sprintf("Invalid overview configuration: %v", err),
+			}
+		}
+	}
+	snapshot.Tables = database.FilterTables(snapshot.Tables, cfg.Include, cfg.Exclude)
+
+	overview, err := database.BuildOverview(snapshot, opts)
+	if err != nil {
+		return "", &types.JSONRPCError{
+			Code:    -32602,
+			Message: err.Error(),
+		}
 	}
 
-	// 获取数据库配置
-	dbConfig := cfg.GetDatabaseConfig()
+	// 格式化结果
+	resultText := fmt.Sprintf("🗺️  数据库概览\n\n")
+	resultText += fmt.Sprintf("🔗 连接：%s（%s）\n", conn.name, conn.config.Driver)
+	resultText += fmt.Sprintf("📊 表总数：%d，展开 %d\n", overview.Tables, overview.Detailed)
+	source := "实时读取"
+	if snapshot.Cached {
+		source = "缓存"
+	}
+	resultText += fmt.Sprintf("🕒 结构读取于 %s（%s）\n", snapshot.BuiltAt.Format("2006-01-02 15:04:05"), source)
 
-	// 创建数据库管理器
-	dbManager := database.NewDatabaseManager(dbConfig)
+	if overview.Tables == 0 {
+		resultText += "\n❌ 没有找到任何表"
+		return resultText, nil
+	}
 
-	return &DatabaseMCPServer{
-		serverInfo: types.ServerInfo{
-			Name:    "database-mcp-server",
-			Version: "1.0.0",
-		},
-		dbManager: dbManager,
-		dbConfig:  dbConfig,
+	if overview.Detailed > 0 {
+		resultText += "🏷️  标记：PK 主键，UK 唯一，NN 非空，→ 外键引用\n\n"
+		resultText += overview.Text + "\n"
+	}
+
+	if overview.Truncated() {
+		resultText += fmt.Sprintf("\n⚠️  超出 token 预算（%d）", opts.MaxTokens)
+		if len(overview.Listed) > 0 {
+			resultText += fmt.Sprintf("，以下 %d 张表未展开：%s", len(overview.Listed), strings.Join(overview.Listed, ", "))
+		}
+		if overview.Omitted > 0 {
+			resultText += fmt.Sprintf("，另有 %d 张表未列出", overview.Omitted)
+		}
+		resultText += "\n💡 使用 database_schema 查看单张表，或用 include/exclude 缩小范围\n"
 	}
+	return resultText, nil
 }
 
-func (s *DatabaseMCPServer) handleInitialize(params *types.InitializeParams) *types.InitializeResult {
-	log.Printf("Initialize request: protocolVersion=%s, client=%s %s",
-		params.ProtocolVersion, params.ClientInfo.Name, params.ClientInfo.Version)
+// overviewResourceURI 连接概览资源的URI
+func overviewResourceURI(name string) string {
+	return "database://" + name + "/overview"
+}
 
-	// 尝试连接数据库
-	if err := s.dbManager.Connect(); err != nil {
-		log.Printf("Warning: Failed to connect to database: %v", err)
-	} else {
-		log.Printf("Successfully connected to database: %s", s.dbConfig.Name)
+func (s *DatabaseMCPServer) handleListResources() *types.ListResourcesResult {
+	resources := make([]types.Resource, 0, len(s.connectionNames))
+	for _, name := range s.connectionNames {
+		resources = append(resources, types.Resource{
+			URI:         overviewResourceURI(name),
+			Name:        fmt.Sprintf("%s 数据库概览", name),
+			Description: "所有表的字段、类型、主键/唯一键和外键关系（同 database_overview）",
+			MimeType:    "text/plain",
+		})
 	}
-
-	return &types.InitializeResult{
-		ProtocolVersion: "2024-11-05",
-		Capabilities: types.ServerCapabilities{
-			Tools: &types.ToolsCapability{
-				ListChanged: false,
-			},
-		},
-		ServerInfo: s.serverInfo,
+	return &types.ListResourcesResult{
+		Resources: resources,
 	}
 }
 
-func (s *DatabaseMCPServer) handleListTools() *types.ListToolsResult {
-	return &types.ListToolsResult{
-		Tools: []types.Tool{
-			{
-				Name:        "database_query",
-				Description: "执行SQL查询并返回结果",
-				InputSchema: types.InputSchema{
-					Type: "object",
-					Properties: map[string]types.Property{
-						"sql": {
-							Type:        "string",
-							Description: "要执行的SQL查询语句",
-						},
-					},
-					Required: []string{"sql"},
-				},
-			},
-			{
-				Name:        "database_tables",
-				Description: "获取数据库中的所有表名",
-				InputSchema: types.InputSchema{
-					Type:       "object",
-					Properties: map[string]types.Property{},
-				},
-			},
-			{
-				Name:        "database_schema",
-				Description: "获取指定表的结构信息",
-				InputSchema: types.InputSchema{
-					Type: "object",
-					Properties: map[string]types.Property{
-						"table_name": {
-							Type:        "string",
-							Description: "要查看结构的表名",
-						},
-					},
-					Required: []string{"table_name"},
-				},
-			},
-			{
-				Name:        "database_status",
-				Description: "检查数据库连接状态",
-				InputSchema: types.InputSchema{
-					Type:       "object",
-					Properties: map[string]types.Property{},
+func (s *DatabaseMCPServer) handleReadResource(params *types.ReadResourceParams) (*types.ReadResourceResult, *types.JSONRPCError) {
+	for _, name := range s.connectionNames {
+		if params.URI != overviewResourceURI(name) {
+			continue
+		}
+
+		text, rpcErr := s.overviewText(s.connections[name], database.OverviewOptions{}, false)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		return &types.ReadResourceResult{
+			Contents: []types.ResourceContents{
+				{
+					URI:      params.URI,
+					MimeType: "text/plain",
+					Text:     text,
 				},
 			},
-		},
+		}, nil
 	}
-}
 
-func (s *DatabaseMCPServer) handleCallTool(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
-	switch params.Name {
-	case "database_query":
-		return s.handleDatabaseQuery(params)
-	case "database_tables":
-		return s.handleDatabaseTables(params)
-	case "database_schema":
-		return s.handleDatabaseSchema(params)
-	case "database_status":
-		return s.handleDatabaseStatus(params)
-	default:
-		return nil, &types.JSONRPCError{
-			Code:    -32601,
-			Message: fmt.Sprintf("Unknown tool: %s", params.Name),
-		}
+	return nil, &types.JSONRPCError{
+		Code:    -32602,
+		Message: fmt.Sprintf("Unknown resource: %s", params.URI),
 	}
 }
 
-func (s *DatabaseMCPServer) handleDatabaseQuery(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
-	// 获取SQL参数
-	sqlQuery, ok := params.Arguments["sql"].(string)
-	if !ok || sqlQuery == "" {
+func (s *DatabaseMCPServer) handleDatabaseSearchSchema(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
+	// 获取搜索条件
+	pattern, ok := params.Arguments["pattern"].(string)
+	if !ok || pattern == "" {
 		return nil, &types.JSONRPCError{
 			Code:    -32602,
-			Message: "SQL query is required",
+			Message: "Search pattern is required",
 		}
 	}
-
-	// 检查数据库连接
-	if !s.dbManager.IsConnected() {
-		if err := s.dbManager.Connect(); err != nil {
+	mode := ""
+	if raw, exists := params.Arguments["mode"]; exists && raw != nil {
+		value, ok := raw.(string)
+		if !ok {
 			return nil, &types.JSONRPCError{
-				Code:    -32603,
-				Message: fmt.Sprintf("Database connection failed: %v", err),
+				Code:    -32602,
+				Message: "mode must be a string",
 			}
 		}
+		mode = value
 	}
-
-	// 执行查询
-	result := s.dbManager.ExecuteQuery(sqlQuery)
-	if result.Error != "" {
-		return nil, &types.JSONRPCError{
-			Code:    -32603,
-			Message: fmt.Sprintf("Query execution failed: %v", result.Error),
-		}
+	in, rpcErr := stringListArgument(params, "in")
+	if rpcErr != nil {
+		return nil, rpcErr
 	}
-
-	// 格式化结果
-	resultText := fmt.Sprintf("✅ 查询执行成功！\n\n📊 查询结果：\n")
-	resultText += fmt.Sprintf("📋 列数：%d\n", len(result.Columns))
-	resultText += fmt.Sprintf("📝 行数：%d\n\n", result.Count)
-
-	// 添加列名
-	if len(result.Columns) > 0 {
-		resultText += "🏷️  列名：\n"
-		for i, col := range result.Columns {
-			resultText += fmt.Sprintf("  %d. %s\n", i+1, col)
-		}
-		resultText += "\n"
+	caseSensitive, rpcErr := boolArgument(params, "case_sensitive")
+	if rpcErr != nil {
+		return nil, rpcErr
 	}
-
-	// 添加数据行（限制显示前10行）
-	maxRows := 10
-	if result.Count > maxRows {
-		resultText += fmt.Sprintf("📊 数据行（显示前%d行）：\n", maxRows)
-	} else {
-		resultText += "📊 数据行：\n"
+	limit, rpcErr := intArgument(params, "limit")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if limit == 0 {
+		limit = database.DefaultSearchLimit
 	}
 
-	for i, row := range result.Rows {
-		if i >= maxRows {
-			break
-		}
-		resultText += fmt.Sprintf("  行 %d: ", i+1)
-		for j, cell := range row {
-			if j > 0 {
-				resultText += " | "
-			}
-			resultText += fmt.Sprintf("%v", cell)
+	search := database.SchemaSearch{
+		Pattern:       pattern,
+		Mode:          mode,
+		In:            in,
+		CaseSensitive: caseSensitive,
+		Limit:         limit,
+	}
+	if err := database.ValidateSchemaSearch(search); err != nil {
+		return nil, &types.JSONRPCError{
+			Code:    -32602,
+			Message: err.Error(),
 		}
-		resultText += "\n"
 	}
 
-	if result.Count > maxRows {
-		resultText += fmt.Sprintf("\n... 还有 %d 行数据未显示", result.Count-maxRows)
+	// 获取输出格式
+	formatter, rpcErr := formatArgument(params)
+	if rpcErr != nil {
+		return nil, rpcErr
 	}
 
-	return &types.CallToolResult{
-		Content: []types.ContentItem{
-			{
-				Type: "text",
-				Text: resultText,
-			},
-		},
-	}, nil
-}
+	// 选择数据库连接
+	conn, rpcErr := s.connection(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
 
-func (s *DatabaseMCPServer) handleDatabaseTables(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
 	// 检查数据库连接
-	if !s.dbManager.IsConnected() {
-		if err := s.dbManager.Connect(); err != nil {
+	if !conn.manager.IsConnected() {
+		if err := conn.manager.Connect(); err != nil {
 			return nil, &types.JSONRPCError{
 				Code:    -32603,
 				Message: fmt.Sprintf("Database connection failed: %v", err),
@@ -236,27 +2001,54 @@ func (s *DatabaseMCPServer) handleDatabaseTables(params *types.CallToolParams) (
 		}
 	}
 
-	// 获取表列表
-	tables, err := s.dbManager.GetTableInfo()
+	// 搜索表结构
+	result, err := conn.manager.SearchSchema(search)
 	if err != nil {
 		return nil, &types.JSONRPCError{
 			Code:    -32603,
-			Message: fmt.Sprintf("Failed to get tables: %v", err),
+			Message: fmt.Sprintf("Failed to search schema: %v", err),
+		}
+	}
+
+	if formatter != nil {
+		content, rpcErr := formatContent(formatter, database.SearchSchemaResult(result))
+		if rpcErr != nil {
+			return nil, rpcErr
 		}
+		return &types.CallToolResult{Content: content}, nil
 	}
 
 	// 格式化结果
-	resultText := fmt.Sprintf("📋 数据库表列表\n\n")
-	resultText += fmt.Sprintf("🗄️  数据库：%s\n", s.dbConfig.Name)
-	resultText += fmt.Sprintf("📊 表总数：%d\n\n", len(tables))
+	resultText := fmt.Sprintf("🔍 结构搜索\n\n")
+	resultText += fmt.Sprintf("🔗 连接：%s\n", conn.name)
+	if mode == "" {
+		mode = database.SearchSubstring
+	}
+	resultText += fmt.Sprintf("🔎 搜索：%s（%s）\n", pattern, mode)
+	resultText += fmt.Sprintf("📊 匹配数：%d\n\n", len(result.Matches))
 
-	if len(tables) > 0 {
-		resultText += "📝 表名列表：\n"
-		for i, table := range tables {
-			resultText += fmt.Sprintf("  %d. %s\n", i+1, table)
-		}
+	if len(result.Matches) == 0 {
+		resultText += "📭 没有匹配的表或字段"
 	} else {
-		resultText += "❌ 没有找到任何表"
+		resultText += "📝 匹配结果：\n"
+		for i, m := range result.Matches {
+			name := m.Table
+			if m.Column != "" {
+				name += "." + m.Column
+			}
+			line := fmt.Sprintf("  %d. %s", i+1, name)
+			if m.Type != "" {
+				line += " " + m.Type
+			}
+			if m.Comment != "" {
+				line += fmt.Sprintf(" -- %s", m.Comment)
+			}
+			line += fmt.Sprintf("（匹配：%s）", strings.Join(m.MatchedOn, ", "))
+			resultText += line + "\n"
+		}
+		if result.Truncated {
+			resultText += fmt.Sprintf("\n⚠️  结果超过 %d 条已截断，可使用更精确的条件或增大 limit", limit)
+		}
 	}
 
 	return &types.CallToolResult{
@@ -269,19 +2061,91 @@ func (s *DatabaseMCPServer) handleDatabaseTables(params *types.CallToolParams) (
 	}, nil
 }
 
-func (s *DatabaseMCPServer) handleDatabaseSchema(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
-	// 获取表名参数
-	tableName, ok := params.Arguments["table_name"].(string)
-	if !ok || tableName == "" {
+func (s *DatabaseMCPServer) handleDatabaseProfile(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
+	// 表名和查询二选一
+	tableName, _ := params.Arguments["table_name"].(string)
+	sqlQuery, _ := params.Arguments["sql"].(string)
+	if (tableName == "") == (sqlQuery == "") {
 		return nil, &types.JSONRPCError{
 			Code:    -32602,
-			Message: "Table name is required",
+			Message: "Exactly one of table_name or sql is required",
+		}
+	}
+
+	// 选择数据库连接
+	conn, rpcErr := s.connection(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 查询只能是单条 SELECT
+	if sqlQuery != "" {
+		stmt, err := database.SingleStatement(sqlQuery)
+		if err == nil && stmt.Class != database.StatementRead {
+			err = fmt.Errorf("profile query must be a read-only SELECT")
+		}
+		if err == nil {
+			err = conn.manager.CheckStatement(sqlQuery)
+		}
+		if err != nil {
+			return nil, &types.JSONRPCError{
+				Code:    -32602,
+				Message: fmt.Sprintf("Statement not allowed: %v", err),
+				Data: map[string]interface{}{
+					"statement": database.ClassifyStatement(sqlQuery),
+				},
+			}
+		}
+	}
+
+	// 获取绑定参数和抽样设置
+	queryParams, rpcErr := bindParams(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	columns, rpcErr := stringListArgument(params, "columns")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	sampleRows, rpcErr := intArgument(params, "sample_rows")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	full, rpcErr := boolArgument(params, "full")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	random, rpcErr := boolArgument(params, "random_sample")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	topN, rpcErr := intArgument(params, "top")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	approxDistinct := false
+	if raw, exists := params.Arguments["distinct"]; exists && raw != nil {
+		switch raw {
+		case "exact":
+		case "approximate":
+			approxDistinct = true
+		default:
+			return nil, &types.JSONRPCError{
+				Code:    -32602,
+				Message: "distinct must be \"exact\" or \"approximate\"",
+			}
 		}
 	}
 
+	// 获取输出格式
+	formatter, rpcErr := formatArgument(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
 	// 检查数据库连接
-	if !s.dbManager.IsConnected() {
-		if err := s.dbManager.Connect(); err != nil {
+	if !conn.manager.IsConnected() {
+		if err := conn.manager.Connect(); err != nil {
 			return nil, &types.JSONRPCError{
 				Code:    -32603,
 				Message: fmt.Sprintf("Database connection failed: %v", err),
@@ -289,39 +2153,84 @@ func (s *DatabaseMCPServer) handleDatabaseSchema(params *types.CallToolParams) (
 		}
 	}
 
-	// 获取表结构
-	schema, err := s.dbManager.GetTableSchema(tableName)
+	// 拒绝引用不可访问的表的语句
+	if rpcErr := accessError(conn.manager, sqlQuery); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 拒绝无法按列名追溯脱敏字段的查询
+	if rpcErr := redactionError(conn.manager, sqlQuery); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// 计算字段画像
+	profile, err := conn.manager.ProfileTable(database.ProfileOptions{
+		Table:          tableName,
+		Query:          sqlQuery,
+		Args:           queryParams,
+		Columns:        columns,
+		SampleRows:     sampleRows,
+		Full:           full,
+		Random:         random,
+		ApproxDistinct: approxDistinct,
+		TopN:           topN,
+	})
 	if err != nil {
 		return nil, &types.JSONRPCError{
 			Code:    -32603,
-			Message: fmt.Sprintf("Failed to get table schema: %v", err),
+			Message: fmt.Sprintf("Failed to profile data: %v", err),
 		}
 	}
 
-	// 格式化结果
-	resultText := fmt.Sprintf("🏗️  表结构信息\n\n")
-	resultText += fmt.Sprintf("📋 表名：%s\n", tableName)
-	resultText += fmt.Sprintf("📊 字段数：%d\n\n", len(schema.Columns))
+	if formatter != nil {
+		content, rpcErr := formatContent(formatter, database.ProfileResult(profile))
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		return &types.CallToolResult{Content: content}, nil
+	}
 
-	if len(schema.Columns) > 0 {
-		resultText += "📝 字段详情：\n"
-		for i, row := range schema.Rows {
-			if len(row) >= 6 {
-				fieldName := fmt.Sprintf("%v", row[0])
-				fieldType := fmt.Sprintf("%v", row[1])
-				nullable := fmt.Sprintf("%v", row[2])
-				key := fmt.Sprintf("%v", row[3])
-				defaultValue := fmt.Sprintf("%v", row[4])
-				extra := fmt.Sprintf("%v", row[5])
+	// 格式化结果
+	resultText := fmt.Sprintf("🧪 字段画像\n\n")
+	resultText += fmt.Sprintf("🔗 连接：%s\n", conn.name)
+	if profile.Table != "" {
+		resultText += fmt.Sprintf("📋 表：%s\n", profile.Table)
+	} else {
+		resultText += fmt.Sprintf("📝 查询：%s\n", sqlQuery)
+	}
+	resultText += fmt.Sprintf("📊 分析行数：%d\n", profile.Rows)
+	switch {
+	case profile.Sampled && profile.Random:
+		resultText += fmt.Sprintf("🎲 随机抽样 %d 行，统计值可能与全部数据不同\n", profile.SampleRows)
+	case profile.Sampled:
+		resultText += fmt.Sprintf("✂️  只分析了前 %d 行，统计值可能与全部数据不同\n", profile.SampleRows)
+	}
+	resultText += "\n"
 
-				resultText += fmt.Sprintf("  %d. %s\n", i+1, fieldName)
-				resultText += fmt.Sprintf("     类型: %s\n", fieldType)
-				resultText += fmt.Sprintf("     可空: %s\n", nullable)
-				resultText += fmt.Sprintf("     键: %s\n", key)
-				resultText += fmt.Sprintf("     默认值: %s\n", defaultValue)
-				resultText += fmt.Sprintf("     额外: %s\n", extra)
-				resultText += "\n"
-			}
+	for i, col := range profile.Columns {
+		if col.Type != "" {
+			resultText += fmt.Sprintf("%d. %s %s\n", i+1, col.Name, col.Type)
+		} else {
+			resultText += fmt.Sprintf("%d. %s\n", i+1, col.Name)
+		}
+		resultText += fmt.Sprintf("   空值：%d (%.1f%%)\n", col.Nulls, col.NullRatio*100)
+		if col.Distinct != nil {
+			resultText += fmt.Sprintf("   不同值：%d（%s）\n", *col.Distinct, col.DistinctSource)
+		}
+		if col.Min != nil || col.Max != nil {
+			resultText += fmt.Sprintf("   范围：%s ~ %s\n", database.FormatValue(col.Min), database.FormatValue(col.Max))
+		}
+		if col.AvgLength != nil {
+			resultText += fmt.Sprintf("   平均长度：%.1f\n", *col.AvgLength)
+		}
+		if len(col.TopValues) > 0 {
+			resultText += fmt.Sprintf("   高频值：%s\n", database.FormatValueCounts(col.TopValues))
+		}
+		if col.Redaction != "" {
+			resultText += fmt.Sprintf("   🛡️  范围和高频值已脱敏（%s）\n", col.Redaction)
+		}
+		if col.Error != "" {
+			resultText += fmt.Sprintf("   ⚠️  %s\n", col.Error)
 		}
 	}
 
@@ -335,16 +2244,70 @@ func (s *DatabaseMCPServer) handleDatabaseSchema(params *types.CallToolParams) (
 	}, nil
 }
 
+// accessError 语句引用了访问控制禁止的 schema、表或系统目录时返回参数错误
+func accessError(manager *database.DatabaseManager, sqlQuery string) *types.JSONRPCError {
+	if sqlQuery == "" {
+		return nil
+	}
+	if err := manager.CheckAccess(sqlQuery); err != nil {
+		return &types.JSONRPCError{
+			Code:    -32602,
+			Message: fmt.Sprintf("Statement not allowed: %v", err),
+		}
+	}
+	return nil
+}
+
+// redactionError 查询结果中的脱敏字段无法按列名追溯时返回参数错误
+func redactionError(manager *database.DatabaseManager, sqlQuery string) *types.JSONRPCError {
+	if sqlQuery == "" {
+		return nil
+	}
+	if err := manager.CheckRedaction(sqlQuery); err != nil {
+		return &types.JSONRPCError{
+			Code:    -32602,
+			Message: fmt.Sprintf("Statement not allowed: %v", err),
+		}
+	}
+	return nil
+}
+
 func (s *DatabaseMCPServer) handleDatabaseStatus(params *types.CallToolParams) (*types.CallToolResult, *types.JSONRPCError) {
+	// 选择数据库连接
+	conn, rpcErr := s.connection(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
 	// 检查连接状态
-	isConnected := s.dbManager.IsConnected()
+	isConnected := conn.manager.IsConnected()
 
 	// 格式化结果
 	resultText := fmt.Sprintf("🔍 数据库连接状态\n\n")
-	resultText += fmt.Sprintf("🗄️  数据库：%s\n", s.dbConfig.Name)
-	resultText += fmt.Sprintf("🌐 主机：%s:%d\n", s.dbConfig.Host, s.dbConfig.Port)
-	resultText += fmt.Sprintf("👤 用户：%s\n", s.dbConfig.User)
-	resultText += fmt.Sprintf("🔌 驱动：%s\n", s.dbConfig.Driver)
+	resultText += fmt.Sprintf("🔗 连接：%s\n", conn.name)
+	resultText += fmt.Sprintf("🗄️  数据库：%s\n", conn.config.Name)
+	if conn.config.IsFileBased() {
+		resultText += fmt.Sprintf("📁 文件：%s\n", conn.config.Name)
+	} else {
+		if conn.config.Socket != "" {
+			resultText += fmt.Sprintf("🧦 Socket：%s\n", conn.config.Socket)
+		} else {
+			resultText += fmt.Sprintf("🌐 主机：%s:%d\n", conn.config.Host, conn.config.Port)
+		}
+		resultText += fmt.Sprintf("👤 用户：%s\n", conn.config.User)
+		resultText += fmt.Sprintf("🛡️  TLS：%s\n", conn.config.TLS.GetMode())
+	}
+	resultText += fmt.Sprintf("🔌 驱动：%s\n", conn.config.Driver)
+	if conn.config.ReadOnly {
+		resultText += "🔒 模式：只读\n"
+	}
+	if transactions := conn.manager.Transactions(); len(transactions) > 0 {
+		resultText += fmt.Sprintf("🔐 打开的事务：%d\n", len(transactions))
+		for _, tx := range transactions {
+			resultText += fmt.Sprintf("  - %s（%d 条语句，空闲 %v）\n",
+				tx.ID, tx.Statements, time.Since(tx.LastUsed).Round(time.Second))
+		}
+	}
 	resultText += fmt.Sprintf("📊 状态：")
 
 	if isConnected {
@@ -353,13 +2316,31 @@ func (s *DatabaseMCPServer) handleDatabaseStatus(params *types.CallToolParams) (
 		resultText += "❌ 未连接\n"
 
 		// 尝试重新连接
-		if err := s.dbManager.Connect(); err != nil {
+		if err := conn.manager.Connect(); err != nil {
 			resultText += fmt.Sprintf("🔄 重连失败：%v\n", err)
 		} else {
 			resultText += "🔄 重连成功！\n"
 		}
 	}
 
+	// 连接池配置和统计
+	pool := conn.manager.PoolSettings()
+	resultText += "\n🏊 连接池：\n"
+	resultText += fmt.Sprintf("  - 最大连接数：%d，最大空闲连接数：%d\n", pool.MaxOpenConns, pool.MaxIdleConns)
+	resultText += fmt.Sprintf("  - 连接最长使用：%s，最长空闲：%s\n",
+		formatLimit(pool.ConnMaxLifetime), formatLimit(pool.ConnMaxIdleTime))
+	if stats := conn.manager.Stats(); stats != nil {
+		resultText += fmt.Sprintf("  - 当前连接：%d（使用中 %d，空闲 %d）\n", stats.OpenConnections, stats.InUse, stats.Idle)
+		resultText += fmt.Sprintf("  - 等待连接：%d 次，共 %v\n", stats.WaitCount, stats.WaitDuration.Round(time.Millisecond))
+		resultText += fmt.Sprintf("  - 已关闭：空闲过多 %d，空闲超时 %d，使用超时 %d\n",
+			stats.MaxIdleClosed, stats.MaxIdleTimeClosed, stats.MaxLifetimeClosed)
+	}
+
+	timeout := conn.config.Timeout
+	resultText += fmt.Sprintf("\n⏱️  超时：连接 %s，读取 %s，写入 %s，查询 %s\n",
+		formatLimit(timeout.Connect), formatLimit(timeout.Read), formatLimit(timeout.Write),
+		formatLimit(conn.config.Query.Timeout))
+
 	return &types.CallToolResult{
 		Content: []types.ContentItem{
 			{
@@ -370,7 +2351,7 @@ func (s *DatabaseMCPServer) handleDatabaseStatus(params *types.CallToolParams) (
 	}, nil
 }
 
-func (s *DatabaseMCPServer) processMessage(msg *types.JSONRPCMessage) *types.JSONRPCMessage {
+func (s *DatabaseMCPServer) processMessage(ctx context.Context, msg *types.JSONRPCMessage) *types.JSONRPCMessage {
 	response := &types.JSONRPCMessage{
 		JSONRPC: "2.0",
 		ID:      msg.ID,
@@ -406,6 +2387,36 @@ func (s *DatabaseMCPServer) processMessage(msg *types.JSONRPCMessage) *types.JSO
 	case "tools/list":
 		response.Result = s.handleListTools()
 
+	case "resources/list":
+		response.Result = s.handleListResources()
+
+	case "resources/read":
+		// 解析资源读取参数
+		paramsBytes, err := json.Marshal(msg.Params)
+		if err != nil {
+			response.Error = &types.JSONRPCError{
+				Code:    -32602,
+				Message: "Invalid params",
+			}
+			return response
+		}
+
+		var readParams types.ReadResourceParams
+		if err := json.Unmarshal(paramsBytes, &readParams); err != nil {
+			response.Error = &types.JSONRPCError{
+				Code:    -32602,
+				Message: "Invalid read resource params",
+			}
+			return response
+		}
+
+		result, rpcErr := s.handleReadResource(&readParams)
+		if rpcErr != nil {
+			response.Error = rpcErr
+		} else {
+			response.Result = result
+		}
+
 	case "tools/call":
 		// 解析工具调用参数
 		paramsBytes, err := json.Marshal(msg.Params)
@@ -426,7 +2437,7 @@ func (s *DatabaseMCPServer) processMessage(msg *types.JSONRPCMessage) *types.JSO
 			return response
 		}
 
-		result, rpcErr := s.handleCallTool(&callParams)
+		result, rpcErr := s.handleCallTool(ctx, &callParams)
 		if rpcErr != nil {
 			response.Error = rpcErr
 		} else {
@@ -443,61 +2454,22 @@ func (s *DatabaseMCPServer) processMessage(msg *types.JSONRPCMessage) *types.JSO
 	return response
 }
 
-func (s *DatabaseMCPServer) sendMessage(msg *types.JSONRPCMessage) error {
-	msgBytes, err := json.Marshal(msg)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println(string(msgBytes))
-	return nil
-}
-
 func (s *DatabaseMCPServer) run() {
 	log.Println("Database MCP Server starting...")
-	log.Printf("Database config: %s@%s:%d/%s",
-		s.dbConfig.User, s.dbConfig.Host, s.dbConfig.Port, s.dbConfig.Name)
-
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
-
-		// 解析输入消息
-		var msg types.JSONRPCMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			log.Printf("Failed to parse JSON: %v", err)
-			errorMsg := &types.JSONRPCMessage{
-				JSONRPC: "2.0",
-				Error: &types.JSONRPCError{
-					Code:    -32700,
-					Message: "Parse error",
-				},
-			}
-			s.sendMessage(errorMsg)
-			continue
-		}
-
-		log.Printf("Received message: method=%s, id=%v", msg.Method, msg.ID)
-
-		// 处理消息
-		response := s.processMessage(&msg)
-		if response != nil {
-			if err := s.sendMessage(response); err != nil {
-				log.Printf("Failed to send response: %v", err)
-			}
-		}
+	for _, name := range s.connectionNames {
+		log.Printf("Database config %s: %s", name, s.connections[name].config.GetLocation())
 	}
 
-	if err := scanner.Err(); err != nil {
-		log.Printf("Scanner error: %v", err)
+	srv := server.NewServer(s.processMessage, s.serverConfig)
+	if err := srv.Run(); err != nil {
+		log.Printf("Server stopped with error: %v", err)
 	}
 
 	// 关闭数据库连接
-	if err := s.dbManager.Close(); err != nil {
-		log.Printf("Failed to close database connection: %v", err)
+	for _, name := range s.connectionNames {
+		if err := s.connections[name].manager.Close(); err != nil {
+			log.Printf("Failed to close database connection %s: %v", name, err)
+		}
 	}
 }
 
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hello-mcp-server/harness"
+)
+
+// usersSetup 测试用的表和数据
+var usersSetup = []string{
+	"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, email TEXT)",
+	"CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id), total REAL)",
+	"INSERT INTO users (name, email) VALUES ('alice', 'alice@example.com'), ('bob', NULL)",
+	"INSERT INTO orders (user_id, total) VALUES (1, 9.5), (1, 20), (2, 3)",
+}
+
+// newTestServer 在临时目录的 SQLite 文件中执行 setup，然后按 extra（database 段下的额外配置）
+// 启动服务器。setup 通过不带额外配置的连接执行，不受只读、访问控制等设置影响
+func newTestServer(t *testing.T, extra string, setup ...string) *harness.Harness {
+	t.Helper()
+
+	dir := t.TempDir()
+	base := "database:\n  enabled: true\n  driver: sqlite\n  name: \"" + filepath.Join(dir, "test.db") + "\"\n"
+
+	plainPath := filepath.Join(dir, "plain.yaml")
+	if err := os.WriteFile(plainPath, []byte(base+"  allow_execute: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	plain := NewDatabaseMCPServer(plainPath).connections["default"].manager
+	if err := plain.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	for _, stmt := range setup {
+		if result := plain.ExecuteStatement(stmt, false); result.Error != "" {
+			t.Fatalf("setup %q: %s", stmt, result.Error)
+		}
+	}
+	plain.Close()
+
+	configPath := filepath.Join(dir, "database.yaml")
+	if err := os.WriteFile(configPath, []byte(base+extra), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewDatabaseMCPServer(configPath)
+	h := harness.New(t, s.processMessage, nil)
+	t.Cleanup(func() {
+		for _, conn := range s.connections {
+			conn.manager.Close()
+		}
+	})
+	return h
+}
+
+// toolCase 一次工具调用及其期望：wantCode 不为0时期望该错误码，否则结果需包含 want 中的所有文本
+type toolCase struct {
+	name      string
+	tool      string
+	arguments map[string]interface{}
+	want      []string
+	wantCode  int
+}
+
+func runToolCases(t *testing.T, h *harness.Harness, tests []toolCase) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantCode != 0 {
+				err := h.CallToolError(tt.tool, tt.arguments)
+				if err.Code != tt.wantCode {
+					t.Fatalf("expected code %d, got %d: %s", tt.wantCode, err.Code, err.Message)
+				}
+				for _, want := range tt.want {
+					if !strings.Contains(err.Message, want) {
+						t.Errorf("error %q does not contain %q", err.Message, want)
+					}
+				}
+				return
+			}
+
+			text := h.CallToolText(tt.tool, tt.arguments)
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("result does not contain %q:\n%s", want, text)
+				}
+			}
+		})
+	}
+}
+
+func TestDatabaseTools(t *testing.T) {
+	h := newTestServer(t, "  allow_execute: true\n", usersSetup...)
+
+	runToolCases(t, h, []toolCase{
+		{
+			name: "list tables",
+			tool: "database_tables",
+			want: []string{"表总数：2", "orders", "users"},
+		},
+		{
+			name:      "query with params",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "SELECT name FROM users WHERE id = ?", "params": []interface{}{2}},
+			want:      []string{"bob"},
+		},
+		{
+			name:      "query as json",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "SELECT id, email FROM users ORDER BY id", "format": "json"},
+			want:      []string{`"alice@example.com"`, "null"},
+		},
+		{
+			name:      "missing sql",
+			tool:      "database_query",
+			arguments: map[string]interface{}{},
+			wantCode:  -32602,
+		},
+		{
+			name:      "schema",
+			tool:      "database_schema",
+			arguments: map[string]interface{}{"table_name": "ORDERS"},
+			want:      []string{"user_id", "→ users(id)"},
+		},
+		{
+			name:      "unknown table",
+			tool:      "database_schema",
+			arguments: map[string]interface{}{"table_name": "users; DROP TABLE users"},
+			want:      []string{"table not found"},
+			wantCode:  -32603,
+		},
+		{
+			name:      "execute",
+			tool:      "database_execute",
+			arguments: map[string]interface{}{"sql": "UPDATE orders SET total = total + 1 WHERE user_id = ?", "params": []interface{}{1}},
+			want:      []string{"影响行数：2"},
+		},
+		{
+			name:     "unknown tool",
+			tool:     "database_drop",
+			wantCode: -32601,
+		},
+	})
+}
+
+func TestReadOnlyRejectsWrites(t *testing.T) {
+	h := newTestServer(t, "  read_only: true\n", usersSetup...)
+
+	runToolCases(t, h, []toolCase{
+		{
+			name:      "select allowed",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "SELECT COUNT(*) AS n FROM orders"},
+			want:      []string{"3"},
+		},
+		{
+			name:      "delete rejected",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "DELETE FROM orders"},
+			want:      []string{"Statement not allowed"},
+			wantCode:  -32602,
+		},
+		{
+			name:      "write hidden in cte rejected",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "WITH d AS (DELETE FROM orders RETURNING id) SELECT * FROM d"},
+			wantCode:  -32602,
+		},
+		{
+			name:      "execute unavailable",
+			tool:      "database_execute",
+			arguments: map[string]interface{}{"sql": "DELETE FROM orders"},
+			wantCode:  -32601,
+		},
+	})
+}
+
+func TestQueryRejectsWritesWithoutAllowExecute(t *testing.T) {
+	h := newTestServer(t, "", usersSetup...)
+
+	runToolCases(t, h, []toolCase{
+		{
+			name:      "select allowed",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "SELECT COUNT(*) AS n FROM orders"},
+			want:      []string{"3"},
+		},
+		{
+			name:      "update rejected",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "UPDATE orders SET total = 0"},
+			want:      []string{"allow_execute is false"},
+			wantCode:  -32602,
+		},
+		{
+			name:      "insert rejected",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "INSERT INTO orders (user_id, total) VALUES (1, 1)"},
+			wantCode:  -32602,
+		},
+		{
+			name:      "ddl rejected",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "DROP TABLE orders"},
+			wantCode:  -32602,
+		},
+		{
+			name:      "table unchanged",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "SELECT SUM(total) AS s FROM orders"},
+			want:      []string{"32.5"},
+		},
+	})
+}
+
+func TestRedactionRejectsUntraceableColumns(t *testing.T) {
+	h := newTestServer(t, `  redaction:
+    hash_salt: "test"
+    rules:
+      - table: "users"
+        column: "email"
+`, usersSetup...)
+
+	runToolCases(t, h, []toolCase{
+		{
+			name:      "plain column redacted",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "SELECT email FROM users WHERE id = 1"},
+			want:      []string{"****"},
+		},
+		{
+			name:      "alias rejected",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "SELECT email AS e FROM users"},
+			want:      []string{"redacted column email"},
+			wantCode:  -32602,
+		},
+		{
+			name:      "expression rejected",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "SELECT email || '' FROM users"},
+			wantCode:  -32602,
+		},
+		{
+			name:      "profile query alias rejected",
+			tool:      "database_profile",
+			arguments: map[string]interface{}{"query": "SELECT email AS e FROM users"},
+			wantCode:  -32602,
+		},
+		{
+			name:      "profile table",
+			tool:      "database_profile",
+			arguments: map[string]interface{}{"table_name": "users"},
+			want:      []string{"email", "****"},
+		},
+	})
+}
+
+func TestAccessListsRejectHiddenReferences(t *testing.T) {
+	h := newTestServer(t, `  access:
+    tables:
+      deny: ["orders"]
+`, usersSetup...)
+
+	runToolCases(t, h, []toolCase{
+		{
+			name:      "allowed table",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "SELECT name FROM users WHERE id = 1"},
+			want:      []string{"alice"},
+		},
+		{
+			name:      "executable comment",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "SELECT 1 /*!, (SELECT total FROM orders) */"},
+			want:      []string{"table orders is not accessible"},
+			wantCode:  -32602,
+		},
+		{
+			name:      "cte body reads shadowed table",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "WITH orders AS (SELECT * FROM orders) SELECT * FROM orders"},
+			wantCode:  -32602,
+		},
+		{
+			name:      "cte shadows table",
+			tool:      "database_query",
+			arguments: map[string]interface{}{"sql": "WITH orders AS (SELECT 42 AS n) SELECT n FROM orders"},
+			want:      []string{"42"},
+		},
+	})
+}
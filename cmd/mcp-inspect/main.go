@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"hello-mcp-server/client"
+	"hello-mcp-server/types"
+)
+
+// MCPConfig mcp-config.json 文件结构
+type MCPConfig struct {
+	MCPServers   map[string]ServerEntry   `json:"mcpServers"`
+	ClientInfo   types.ClientInfo         `json:"clientInfo"`
+	Capabilities types.ClientCapabilities `json:"capabilities"`
+}
+
+// ServerEntry 单个服务器的启动配置
+type ServerEntry struct {
+	Command     string            `json:"command"`
+	Args        []string          `json:"args"`
+	Env         map[string]string `json:"env"`
+	Description string            `json:"description"`
+}
+
+// Console 串行化终端输出，使结果、通知和服务器日志互不交错
+type Console struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// Printf 输出一段文本
+func (c *Console) Printf(format string, args ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.out, format, args...)
+}
+
+// PrefixWriter 按行为输出加前缀
+type PrefixWriter struct {
+	console *Console
+	prefix  string
+	buf     []byte
+}
+
+func (w *PrefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		idx := strings.IndexByte(string(w.buf), '\n')
+		if idx < 0 {
+			break
+		}
+		w.console.Printf("%s%s\n", w.prefix, string(w.buf[:idx]))
+		w.buf = w.buf[idx+1:]
+	}
+	return len(p), nil
+}
+
+// Inspector 交互式检查器
+type Inspector struct {
+	client  *client.Client
+	console *Console
+	input   *bufio.Scanner
+	tools   map[string]types.Tool
+}
+
+func loadMCPConfig(path string) (*MCPConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	var cfg MCPConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %v", err)
+	}
+	return &cfg, nil
+}
+
+func printServers(cfg *MCPConfig) {
+	names := make([]string, 0, len(cfg.MCPServers))
+	for name := range cfg.MCPServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("📋 可用服务器：")
+	for _, name := range names {
+		entry := cfg.MCPServers[name]
+		fmt.Printf("  - %s: %s %s\n", name, entry.Command, strings.Join(entry.Args, " "))
+		if entry.Description != "" {
+			fmt.Printf("      %s\n", entry.Description)
+		}
+	}
+}
+
+func (in *Inspector) run() {
+	in.console.Printf("💡 输入 help 查看命令\n")
+	for {
+		in.console.Printf("mcp> ")
+		if !in.input.Scan() {
+			in.console.Printf("\n")
+			return
+		}
+
+		fields := strings.Fields(in.input.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		command, args := fields[0], fields[1:]
+		var err error
+		switch command {
+		case "help", "?":
+			in.printHelp()
+		case "tools":
+			err = in.listTools()
+		case "call":
+			err = in.callTool(args)
+		case "resources":
+			err = in.listResources()
+		case "read":
+			err = in.readResource(args)
+		case "prompts":
+			err = in.listPrompts()
+		case "prompt":
+			err = in.getPrompt(args)
+		case "ping":
+			if err = in.client.Ping(); err == nil {
+				in.console.Printf("✅ pong\n")
+			}
+		case "info":
+			in.printJSON(in.client.ServerInfo())
+		case "quit", "exit":
+			return
+		default:
+			in.console.Printf("❌ 未知命令: %s\n", command)
+		}
+
+		if err != nil {
+			in.console.Printf("❌ %v\n", err)
+		}
+	}
+}
+
+func (in *Inspector) printHelp() {
+	in.console.Printf(`命令：
+  tools              列出工具及其参数
+  call <tool>        调用工具，按参数定义逐项输入
+  resources          列出资源
+  read <uri>         读取资源
+  prompts            列出提示词
+  prompt <name>      获取提示词
+  ping               ping 服务器
+  info               显示服务器初始化信息
+  quit               退出
+`)
+}
+
+func (in *Inspector) listTools() error {
+	result, err := in.client.ListTools()
+	if err != nil {
+		return err
+	}
+
+	in.tools = make(map[string]types.Tool)
+	in.console.Printf("🔧 共 %d 个工具\n", len(result.Tools))
+	for _, tool := range result.Tools {
+		in.tools[tool.Name] = tool
+		in.console.Printf("\n%s\n  %s\n", tool.Name, tool.Description)
+		for _, name := range sortedProperties(tool.InputSchema) {
+			prop := tool.InputSchema.Properties[name]
+			marker := ""
+			if isRequired(tool.InputSchema, name) {
+				marker = " (必需)"
+			}
+			in.console.Printf("  - %s: %s%s  %s\n", name, propertyType(prop), marker, prop.Description)
+		}
+	}
+	return nil
+}
+
+func (in *Inspector) callTool(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: call <tool>")
+	}
+
+	if in.tools == nil {
+		if err := in.listTools(); err != nil {
+			return err
+		}
+	}
+
+	tool, ok := in.tools[args[0]]
+	if !ok {
+		return fmt.Errorf("未知工具: %s", args[0])
+	}
+
+	arguments, err := in.promptArguments(tool.InputSchema)
+	if err != nil {
+		return err
+	}
+
+	result, err := in.client.CallTool(tool.Name, arguments)
+	if err != nil {
+		return err
+	}
+
+	if result.IsError {
+		in.console.Printf("⚠️  工具返回错误\n")
+	}
+	for _, item := range result.Content {
+		if item.Type == "text" {
+			in.console.Printf("%s\n", item.Text)
+		} else {
+			in.printJSON(item)
+		}
+	}
+	return nil
+}
+
+func (in *Inspector) listResources() error {
+	result, err := in.client.ListResources()
+	if err != nil {
+		return err
+	}
+
+	in.console.Printf("📚 共 %d 个资源\n", len(result.Resources))
+	for _, resource := range result.Resources {
+		in.console.Printf("  - %s (%s) %s\n", resource.URI, resource.Name, resource.Description)
+	}
+	return nil
+}
+
+func (in *Inspector) readResource(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: read <uri>")
+	}
+
+	result, err := in.client.ReadResource(args[0])
+	if err != nil {
+		return err
+	}
+
+	for _, content := range result.Contents {
+		if content.Text != "" {
+			in.console.Printf("%s\n", content.Text)
+		} else {
+			in.printJSON(content)
+		}
+	}
+	return nil
+}
+
+func (in *Inspector) listPrompts() error {
+	result, err := in.client.ListPrompts()
+	if err != nil {
+		return err
+	}
+
+	in.console.Printf("📝 共 %d 个提示词\n", len(result.Prompts))
+	for _, prompt := range result.Prompts {
+		in.console.Printf("  - %s: %s\n", prompt.Name, prompt.Description)
+	}
+	return nil
+}
+
+func (in *Inspector) getPrompt(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: prompt <name> [key=value ...]")
+	}
+
+	arguments := make(map[string]string)
+	for _, arg := range args[1:] {
+		if key, value, ok := strings.Cut(arg, "="); ok {
+			arguments[key] = value
+		}
+	}
+
+	result, err := in.client.GetPrompt(args[0], arguments)
+	if err != nil {
+		return err
+	}
+
+	for _, msg := range result.Messages {
+		in.console.Printf("[%s] %s\n", msg.Role, msg.Content.Text)
+	}
+	return nil
+}
+
+func (in *Inspector) printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		in.console.Printf("%v\n", v)
+		return
+	}
+	in.console.Printf("%s\n", string(data))
+}
+
+func main() {
+	log.SetOutput(os.Stderr)
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	// 默认配置文件路径
+	configPath := "mcp-config.json"
+	args := os.Args[1:]
+	if len(args) > 1 && args[0] == "--config" {
+		configPath = args[1]
+		args = args[2:]
+	}
+
+	cfg, err := loadMCPConfig(configPath)
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
+	if len(args) == 0 {
+		fmt.Println("用法: mcp-inspect [--config mcp-config.json] <server-name>")
+		printServers(cfg)
+		return
+	}
+
+	entry, ok := cfg.MCPServers[args[0]]
+	if !ok {
+		fmt.Printf("❌ 配置中没有服务器: %s\n", args[0])
+		printServers(cfg)
+		os.Exit(1)
+	}
+
+	console := &Console{out: os.Stdout}
+
+	// 服务器命令和参数中的相对路径相对于配置文件所在目录
+	var env []string
+	for key, value := range entry.Env {
+		env = append(env, key+"="+value)
+	}
+	c, err := client.NewStdioClient(entry.Command, entry.Args, &client.StdioOptions{
+		Env:    env,
+		Dir:    filepath.Dir(configPath),
+		Stderr: &PrefixWriter{console: console, prefix: "  [stderr] "},
+	})
+	if err != nil {
+		log.Fatalf("启动服务器失败: %v", err)
+	}
+	defer c.Close()
+
+	c.OnNotification("", func(msg *types.JSONRPCMessage) {
+		params, _ := json.Marshal(msg.Params)
+		console.Printf("  [notify] %s %s\n", msg.Method, string(params))
+	})
+
+	clientInfo := cfg.ClientInfo
+	if clientInfo.Name == "" {
+		clientInfo = types.ClientInfo{Name: "mcp-inspect", Version: "1.0.0"}
+	}
+
+	result, err := c.Initialize(clientInfo, cfg.Capabilities)
+	if err != nil {
+		log.Fatalf("初始化失败: %v", err)
+	}
+	console.Printf("✅ 已连接 %s %s (协议 %s)\n",
+		result.ServerInfo.Name, result.ServerInfo.Version, result.ProtocolVersion)
+
+	inspector := &Inspector{
+		client:  c,
+		console: console,
+		input:   bufio.NewScanner(os.Stdin),
+	}
+	inspector.run()
+}
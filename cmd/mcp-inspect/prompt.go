@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"hello-mcp-server/types"
+)
+
+// promptArguments 根据 InputSchema 逐项提示输入参数，可选参数留空则跳过
+func (in *Inspector) promptArguments(schema types.InputSchema) (map[string]interface{}, error) {
+	arguments := make(map[string]interface{})
+
+	for _, name := range sortedProperties(schema) {
+		prop := schema.Properties[name]
+		required := isRequired(schema, name)
+
+		for {
+			marker := "可选"
+			if required {
+				marker = "必需"
+			}
+			in.console.Printf("  %s (%s, %s) %s\n", name, propertyType(prop), marker, prop.Description)
+			if len(prop.Enum) > 0 {
+				in.console.Printf("  可选值：%s\n", strings.Join(prop.Enum, " / "))
+			}
+			in.console.Printf("  > ")
+
+			if !in.input.Scan() {
+				return nil, fmt.Errorf("输入已结束")
+			}
+			text := strings.TrimSpace(in.input.Text())
+
+			if text == "" {
+				if required {
+					in.console.Printf("  ❌ %s 为必需参数\n", name)
+					continue
+				}
+				break
+			}
+
+			value, err := parseValue(prop, text)
+			if err != nil {
+				in.console.Printf("  ❌ %v\n", err)
+				continue
+			}
+			arguments[name] = value
+			break
+		}
+	}
+
+	return arguments, nil
+}
+
+// parseValue 按属性类型解析输入，数组可用 JSON 或逗号分隔
+func parseValue(prop types.Property, text string) (interface{}, error) {
+	switch prop.Type {
+	case "integer":
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("需要整数: %s", text)
+		}
+		return value, nil
+	case "number":
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("需要数字: %s", text)
+		}
+		return value, nil
+	case "boolean":
+		value, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("需要布尔值 (true/false): %s", text)
+		}
+		return value, nil
+	case "array":
+		if strings.HasPrefix(text, "[") {
+			var values []interface{}
+			if err := json.Unmarshal([]byte(text), &values); err != nil {
+				return nil, fmt.Errorf("无效的JSON数组: %v", err)
+			}
+			return values, nil
+		}
+
+		var values []interface{}
+		for _, part := range strings.Split(text, ",") {
+			item := types.Property{Type: "string"}
+			if prop.Items != nil {
+				item = *prop.Items
+			}
+			value, err := parseValue(item, strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case "object":
+		var value map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			return nil, fmt.Errorf("无效的JSON对象: %v", err)
+		}
+		return value, nil
+	default:
+		return text, nil
+	}
+}
+
+// sortedProperties 必需参数在前，其余按名称排序
+func sortedProperties(schema types.InputSchema) []string {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		ri, rj := isRequired(schema, names[i]), isRequired(schema, names[j])
+		if ri != rj {
+			return ri
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func isRequired(schema types.InputSchema, name string) bool {
+	for _, required := range schema.Required {
+		if required == name {
+			return true
+		}
+	}
+	return false
+}
+
+func propertyType(prop types.Property) string {
+	if prop.Type == "array" && prop.Items != nil {
+		return prop.Items.Type + "[]"
+	}
+	return prop.Type
+}
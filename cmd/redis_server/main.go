@@ -1,16 +1,16 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
-	"strings"
 	"time"
 
 	"hello-mcp-server/config"
 	"hello-mcp-server/redis"
+	"hello-mcp-server/server"
 	"hello-mcp-server/types"
 )
 
@@ -19,6 +19,7 @@ type RedisMCPServer struct {
 	serverInfo   types.ServerInfo
 	redisManager *redis.RedisManager
 	redisConfig  *config.RedisConfig
+	serverConfig *config.ServerConfig
 }
 
 func NewRedisMCPServer(configPath string) *RedisMCPServer {
@@ -42,6 +43,7 @@ func NewRedisMCPServer(configPath string) *RedisMCPServer {
 		},
 		redisManager: redisManager,
 		redisConfig:  redisConfig,
+		serverConfig: cfg.GetServerConfig(),
 	}
 }
 
@@ -749,7 +751,7 @@ func (s *RedisMCPServer) handleRedisStatus(params *types.CallToolParams) (*types
 	}, nil
 }
 
-func (s *RedisMCPServer) processMessage(msg *types.JSONRPCMessage) *types.JSONRPCMessage {
+func (s *RedisMCPServer) processMessage(ctx context.Context, msg *types.JSONRPCMessage) *types.JSONRPCMessage {
 	response := &types.JSONRPCMessage{
 		JSONRPC: "2.0",
 		ID:      msg.ID,
@@ -822,55 +824,13 @@ func (s *RedisMCPServer) processMessage(msg *types.JSONRPCMessage) *types.JSONRP
 	return response
 }
 
-func (s *RedisMCPServer) sendMessage(msg *types.JSONRPCMessage) error {
-	msgBytes, err := json.Marshal(msg)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println(string(msgBytes))
-	return nil
-}
-
 func (s *RedisMCPServer) run() {
 	log.Println("Redis MCP Server starting...")
 	log.Printf("Redis config: %s", s.redisConfig.GetAddr())
 
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
-
-		// 解析输入消息
-		var msg types.JSONRPCMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			log.Printf("Failed to parse JSON: %v", err)
-			errorMsg := &types.JSONRPCMessage{
-				JSONRPC: "2.0",
-				Error: &types.JSONRPCError{
-					Code:    -32700,
-					Message: "Parse error",
-				},
-			}
-			s.sendMessage(errorMsg)
-			continue
-		}
-
-		log.Printf("Received message: method=%s, id=%v", msg.Method, msg.ID)
-
-		// 处理消息
-		response := s.processMessage(&msg)
-		if response != nil {
-			if err := s.sendMessage(response); err != nil {
-				log.Printf("Failed to send response: %v", err)
-			}
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		log.Printf("Scanner error: %v", err)
+	srv := server.NewServer(s.processMessage, s.serverConfig)
+	if err := srv.Run(); err != nil {
+		log.Printf("Server stopped with error: %v", err)
 	}
 
 	// 关闭Redis连接
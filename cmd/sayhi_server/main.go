@@ -1,20 +1,22 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
-	"strings"
 	"time"
 
+	"hello-mcp-server/config"
+	"hello-mcp-server/server"
 	"hello-mcp-server/types"
 )
 
 // HelloMCPServer 问候MCP服务器
 type HelloMCPServer struct {
-	serverInfo types.ServerInfo
+	serverInfo   types.ServerInfo
+	serverConfig *config.ServerConfig
 }
 
 func NewHelloMCPServer() *HelloMCPServer {
@@ -23,6 +25,7 @@ func NewHelloMCPServer() *HelloMCPServer {
 			Name:    "hello-mcp-server",
 			Version: "1.0.0",
 		},
+		serverConfig: config.LoadDefaultConfig().GetServerConfig(),
 	}
 }
 
@@ -128,7 +131,7 @@ func (s *HelloMCPServer) writeLog(message string) error {
 	return err
 }
 
-func (s *HelloMCPServer) processMessage(msg *types.JSONRPCMessage) *types.JSONRPCMessage {
+func (s *HelloMCPServer) processMessage(ctx context.Context, msg *types.JSONRPCMessage) *types.JSONRPCMessage {
 	response := &types.JSONRPCMessage{
 		JSONRPC: "2.0",
 		ID:      msg.ID,
@@ -201,54 +204,12 @@ func (s *HelloMCPServer) processMessage(msg *types.JSONRPCMessage) *types.JSONRP
 	return response
 }
 
-func (s *HelloMCPServer) sendMessage(msg *types.JSONRPCMessage) error {
-	msgBytes, err := json.Marshal(msg)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println(string(msgBytes))
-	return nil
-}
-
 func (s *HelloMCPServer) run() {
 	log.Println("Hello MCP Server starting...")
 
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
-
-		// 解析输入消息
-		var msg types.JSONRPCMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			log.Printf("Failed to parse JSON: %v", err)
-			errorMsg := &types.JSONRPCMessage{
-				JSONRPC: "2.0",
-				Error: &types.JSONRPCError{
-					Code:    -32700,
-					Message: "Parse error",
-				},
-			}
-			s.sendMessage(errorMsg)
-			continue
-		}
-
-		log.Printf("Received message: method=%s, id=%v", msg.Method, msg.ID)
-
-		// 处理消息
-		response := s.processMessage(&msg)
-		if response != nil {
-			if err := s.sendMessage(response); err != nil {
-				log.Printf("Failed to send response: %v", err)
-			}
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		log.Printf("Scanner error: %v", err)
+	srv := server.NewServer(s.processMessage, s.serverConfig)
+	if err := srv.Run(); err != nil {
+		log.Printf("Server stopped with error: %v", err)
 	}
 }
 
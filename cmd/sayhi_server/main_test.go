@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"hello-mcp-server/harness"
+)
+
+// inTempDir 在临时目录中运行测试，问候日志写入该目录
+func inTempDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestSayHello(t *testing.T) {
+	inTempDir(t)
+	h := harness.New(t, NewHelloMCPServer().processMessage, nil)
+
+	text := h.CallToolText("say_hello", map[string]interface{}{"person_name": "Alice"})
+	if !strings.Contains(text, "Alice") {
+		t.Fatalf("unexpected result: %s", text)
+	}
+}
+
+func TestSayHelloArguments(t *testing.T) {
+	inTempDir(t)
+	h := harness.New(t, NewHelloMCPServer().processMessage, nil)
+
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		want      []string
+	}{
+		{
+			name:      "default greeting",
+			arguments: map[string]interface{}{"person_name": "Bob"},
+			want:      []string{"向 Bob 说: 你好", "Bob"},
+		},
+		{
+			name:      "custom greeting",
+			arguments: map[string]interface{}{"person_name": "Carol", "greeting_message": "早上好"},
+			want:      []string{"向 Carol 说: 早上好"},
+		},
+		{
+			name:      "missing name",
+			arguments: map[string]interface{}{},
+			want:      []string{"向 朋友 说: 你好"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := h.CallToolText("say_hello", tt.arguments)
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("result does not contain %q:\n%s", want, text)
+				}
+			}
+		})
+	}
+
+	log, err := os.ReadFile("hello_log.txt")
+	if err != nil {
+		t.Fatalf("greeting log not written: %v", err)
+	}
+	if n := strings.Count(string(log), "\n"); n != len(tests) {
+		t.Errorf("expected %d log entries, got %d", len(tests), n)
+	}
+}
+
+func TestListTools(t *testing.T) {
+	inTempDir(t)
+	h := harness.New(t, NewHelloMCPServer().processMessage, nil)
+
+	if h.Init.ServerInfo.Name != "hello-mcp-server" {
+		t.Errorf("unexpected server info: %+v", h.Init.ServerInfo)
+	}
+
+	tools := h.ListTools()
+	if len(tools) != 1 || tools[0].Name != "say_hello" {
+		t.Fatalf("unexpected tools: %+v", tools)
+	}
+	if required := tools[0].InputSchema.Required; len(required) != 1 || required[0] != "person_name" {
+		t.Errorf("unexpected required arguments: %v", required)
+	}
+}
+
+func TestUnknownTool(t *testing.T) {
+	inTempDir(t)
+	h := harness.New(t, NewHelloMCPServer().processMessage, nil)
+
+	err := h.CallToolError("say_goodbye", nil)
+	if err.Code != -32601 {
+		t.Errorf("expected -32601, got %d: %s", err.Code, err.Message)
+	}
+}
@@ -2,8 +2,12 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // DatabaseConfig 数据库配置结构
@@ -15,25 +19,480 @@ type DatabaseConfig struct {
 	User     string `yaml:"user" json:"user"`
 	Password string `yaml:"password" json:"password"`
 	Name     string `yaml:"name" json:"name"`
+
+	// 只读模式：只允许 SELECT/SHOW/EXPLAIN/DESCRIBE，并在只读事务中执行
+	ReadOnly bool `yaml:"read_only" json:"read_only"`
+	// 是否提供 database_execute 工具并允许 database_query 执行修改语句（只读模式下始终关闭）
+	AllowExecute bool `yaml:"allow_execute" json:"allow_execute"`
+
+	// 事务空闲多久后自动回滚，0 使用默认值
+	TransactionIdleTimeout time.Duration `yaml:"transaction_idle_timeout" json:"transaction_idle_timeout"`
+	// 同时打开的事务数上限，0 使用默认值
+	MaxTransactions int `yaml:"max_transactions" json:"max_transactions"`
+
+	// 查询结果限制
+	Query QueryConfig `yaml:"query" json:"query"`
+
+	// Unix socket 路径（MySQL 为 socket 文件，PostgreSQL 为 socket 所在目录），设置后忽略 host
+	Socket string `yaml:"socket" json:"socket"`
+	// TLS 配置
+	TLS TLSConfig `yaml:"tls" json:"tls"`
+	// 额外的连接参数，覆盖同名的默认参数
+	Params map[string]string `yaml:"params" json:"params"`
+
+	// 连接池配置
+	Pool PoolConfig `yaml:"pool" json:"pool"`
+	// 超时配置
+	Timeout TimeoutConfig `yaml:"timeout" json:"timeout"`
+
+	// 数据库概览（database_overview）配置
+	Overview OverviewConfig `yaml:"overview" json:"overview"`
+
+	// 查询结果脱敏配置
+	Redaction RedactionConfig `yaml:"redaction" json:"redaction"`
+
+	// 可访问的 schema、表和字段
+	Access AccessConfig `yaml:"access" json:"access"`
+}
+
+// TLS 验证模式
+const (
+	// TLSDisable 不使用 TLS
+	TLSDisable = "disable"
+	// TLSPrefer 服务器支持时使用 TLS，不验证证书（仅 MySQL）
+	TLSPrefer = "prefer"
+	// TLSRequire 必须使用 TLS，不验证证书
+	TLSRequire = "require"
+	// TLSVerifyCA 验证证书由受信任的 CA 签发
+	TLSVerifyCA = "verify-ca"
+	// TLSVerifyFull 验证证书并校验服务器名称
+	TLSVerifyFull = "verify-full"
+)
+
+// TLSConfig 数据库连接的 TLS 配置
+type TLSConfig struct {
+	// 验证模式：disable（默认）、prefer、require、verify-ca、verify-full
+	Mode string `yaml:"mode" json:"mode"`
+	// 验证服务器证书的 CA 文件（PEM），未设置时使用系统证书
+	CAFile string `yaml:"ca_file" json:"ca_file"`
+	// 客户端证书和私钥（PEM）
+	CertFile string `yaml:"cert_file" json:"cert_file"`
+	KeyFile  string `yaml:"key_file" json:"key_file"`
+	// 校验证书时使用的服务器名称，未设置时使用 host（仅 MySQL）
+	ServerName string `yaml:"server_name" json:"server_name"`
+}
+
+// GetMode 获取验证模式（未配置时为 disable）
+func (c *TLSConfig) GetMode() string {
+	if c.Mode == "" {
+		return TLSDisable
+	}
+	return strings.ToLower(c.Mode)
+}
+
+// Validate 检查验证模式和证书配置
+func (c *TLSConfig) Validate() error {
+	switch c.GetMode() {
+	case TLSDisable, TLSPrefer, TLSRequire, TLSVerifyCA, TLSVerifyFull:
+	default:
+		return fmt.Errorf("unsupported tls mode: %q (available: disable, prefer, require, verify-ca, verify-full)", c.Mode)
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("tls cert_file and key_file must be set together")
+	}
+	return nil
+}
+
+// PoolConfig 连接池配置，0 使用默认值（conn_max_idle_time 为0表示不限制）
+type PoolConfig struct {
+	MaxOpenConns int `yaml:"max_open_conns" json:"max_open_conns"`
+	MaxIdleConns int `yaml:"max_idle_conns" json:"max_idle_conns"`
+	// 连接最长使用时间，超过后关闭重建
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" json:"conn_max_lifetime"`
+	// 连接最长空闲时间
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" json:"conn_max_idle_time"`
+}
+
+// TimeoutConfig 网络超时，0 表示不限制
+type TimeoutConfig struct {
+	// 建立连接（含首次 ping）的超时
+	Connect time.Duration `yaml:"connect" json:"connect"`
+	// 单次读取和写入网络数据的超时（仅 MySQL）
+	Read  time.Duration `yaml:"read" json:"read"`
+	Write time.Duration `yaml:"write" json:"write"`
+}
+
+// 默认连接池配置
+const (
+	DefaultPoolMaxOpenConns    = 25
+	DefaultPoolMaxIdleConns    = 5
+	DefaultPoolConnMaxLifetime = 5 * time.Minute
+)
+
+// GetMaxOpenConns 获取最大打开连接数（未配置时使用默认值）
+func (c *PoolConfig) GetMaxOpenConns() int {
+	if c.MaxOpenConns <= 0 {
+		return DefaultPoolMaxOpenConns
+	}
+	return c.MaxOpenConns
+}
+
+// GetMaxIdleConns 获取最大空闲连接数（未配置时使用默认值），不超过最大打开连接数
+func (c *PoolConfig) GetMaxIdleConns() int {
+	idle := c.MaxIdleConns
+	if idle <= 0 {
+		idle = DefaultPoolMaxIdleConns
+	}
+	if idle > c.GetMaxOpenConns() {
+		idle = c.GetMaxOpenConns()
+	}
+	return idle
+}
+
+// GetConnMaxLifetime 获取连接最长使用时间（未配置时使用默认值）
+func (c *PoolConfig) GetConnMaxLifetime() time.Duration {
+	if c.ConnMaxLifetime <= 0 {
+		return DefaultPoolConnMaxLifetime
+	}
+	return c.ConnMaxLifetime
+}
+
+// QueryConfig 查询结果限制，读取结果时即生效，超出部分不会载入内存
+type QueryConfig struct {
+	// 单页最多返回的行数
+	MaxRows int `yaml:"max_rows" json:"max_rows"`
+	// 单页结果的最大字节数（按值大小估算）
+	MaxBytes int `yaml:"max_bytes" json:"max_bytes"`
+	// 未指定 limit 时的每页行数
+	DefaultLimit int `yaml:"default_limit" json:"default_limit"`
+	// 单条查询的执行超时，由数据库服务端中断（MySQL max_execution_time / PostgreSQL statement_timeout），0 表示不限制
+	Timeout time.Duration `yaml:"timeout" json:"timeout"`
+	// 执行前检查执行计划，拒绝代价过高的查询
+	CostGuard CostGuardConfig `yaml:"cost_guard" json:"cost_guard"`
+}
+
+// CostGuardConfig 查询代价检查，阈值为0时不检查该项
+type CostGuardConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// 预计返回（或连接产生）的最大行数
+	MaxEstimatedRows float64 `yaml:"max_estimated_rows" json:"max_estimated_rows"`
+	// 单个全表扫描的最大预计行数
+	MaxScanRows float64 `yaml:"max_scan_rows" json:"max_scan_rows"`
+	// 执行计划的最大总代价（仅 PostgreSQL 和 MySQL 提供）
+	MaxCost float64 `yaml:"max_cost" json:"max_cost"`
+	// 拒绝任何全表扫描
+	RejectFullScans bool `yaml:"reject_full_scans" json:"reject_full_scans"`
+}
+
+// OverviewConfig 数据库概览配置
+type OverviewConfig struct {
+	// 概览的 token 预算（按字符数估算），0 使用默认值
+	MaxTokens int `yaml:"max_tokens" json:"max_tokens"`
+	// 缓存的表结构最长使用时间，0 使用默认值；结构变化时缓存会提前失效
+	CacheTTL time.Duration `yaml:"cache_ttl" json:"cache_ttl"`
+	// 表名过滤，支持 * ? [...] 通配符，大小写不敏感；include 为空时包含所有表
+	Include []string `yaml:"include" json:"include"`
+	Exclude []string `yaml:"exclude" json:"exclude"`
+}
+
+// 默认概览配置
+const (
+	DefaultOverviewMaxTokens = 4000
+	DefaultOverviewCacheTTL  = 10 * time.Minute
+)
+
+// GetMaxTokens 获取概览的 token 预算（未配置时使用默认值）
+func (c *OverviewConfig) GetMaxTokens() int {
+	if c.MaxTokens <= 0 {
+		return DefaultOverviewMaxTokens
+	}
+	return c.MaxTokens
+}
+
+// GetCacheTTL 获取表结构缓存时长（未配置时使用默认值）
+func (c *OverviewConfig) GetCacheTTL() time.Duration {
+	if c.CacheTTL <= 0 {
+		return DefaultOverviewCacheTTL
+	}
+	return c.CacheTTL
+}
+
+// 脱敏方式
+const (
+	// RedactMask 替换为固定的掩码
+	RedactMask = "mask"
+	// RedactHash 替换为以 hash_salt 为密钥的 HMAC，相同的值哈希相同，仍可用于比较和分组
+	RedactHash = "hash"
+	// RedactPartial 只保留开头和结尾的部分字符
+	RedactPartial = "partial"
+	// RedactDrop 从结果中删除整列
+	RedactDrop = "drop"
+)
+
+// RedactionConfig 查询结果脱敏配置
+type RedactionConfig struct {
+	// 按顺序匹配，每列使用第一条匹配的整值规则
+	Rules []RedactionRule `yaml:"rules" json:"rules"`
+	// hash 方式的 HMAC 密钥，存在 hash 规则时必须设置
+	HashSalt string `yaml:"hash_salt" json:"hash_salt"`
+}
+
+// RedactionRule 脱敏规则。table、column 支持 * ? [...] 通配符，大小写不敏感，为空时匹配所有；
+// table 与查询语句中出现的标识符匹配。设置 detector 时只替换值中检测到的部分（如邮箱、卡号），
+// 否则替换整个值
+type RedactionRule struct {
+	Table    string `yaml:"table" json:"table"`
+	Column   string `yaml:"column" json:"column"`
+	Detector string `yaml:"detector" json:"detector"`
+	// mask（默认）、hash、partial、drop；detector 规则不支持 drop
+	Action string `yaml:"action" json:"action"`
+	// partial 方式保留开头和结尾的字符数
+	RevealFirst int `yaml:"reveal_first" json:"reveal_first"`
+	RevealLast  int `yaml:"reveal_last" json:"reveal_last"`
+}
+
+// GetAction 获取脱敏方式（未配置时为 mask）
+func (r *RedactionRule) GetAction() string {
+	if r.Action == "" {
+		return RedactMask
+	}
+	return strings.ToLower(r.Action)
+}
+
+// AccessConfig 可访问的 schema（MySQL 为库）、表和字段。不可访问的对象不出现在结构工具的结果中，
+// 查询中引用它们时拒绝执行
+type AccessConfig struct {
+	Schemas AccessList `yaml:"schemas" json:"schemas"`
+	Tables  AccessList `yaml:"tables" json:"tables"`
+	// 字段规则格式为 "表.字段"，不含点号时匹配所有表中的同名字段
+	Columns AccessList `yaml:"columns" json:"columns"`
+}
+
+// AccessList 白名单和黑名单，支持 * ? [...] 通配符，大小写不敏感。
+// allow 为空时允许所有，deny 优先
+type AccessList struct {
+	Allow []string `yaml:"allow" json:"allow"`
+	Deny  []string `yaml:"deny" json:"deny"`
+}
+
+// IsEmpty 是否未配置任何规则
+func (l *AccessList) IsEmpty() bool {
+	return len(l.Allow) == 0 && len(l.Deny) == 0
 }
 
-// GetDSN 获取数据库连接字符串
+// IsEmpty 是否未配置任何访问限制
+func (c *AccessConfig) IsEmpty() bool {
+	return c.Schemas.IsEmpty() && c.Tables.IsEmpty() && c.Columns.IsEmpty()
+}
+
+// 默认查询结果限制
+const (
+	DefaultQueryMaxRows  = 1000
+	DefaultQueryMaxBytes = 1 << 20
+	DefaultQueryLimit    = 100
+)
+
+// GetMaxRows 获取单页最大行数（未配置时使用默认值）
+func (c *QueryConfig) GetMaxRows() int {
+	if c.MaxRows <= 0 {
+		return DefaultQueryMaxRows
+	}
+	return c.MaxRows
+}
+
+// GetMaxBytes 获取单页最大字节数（未配置时使用默认值）
+func (c *QueryConfig) GetMaxBytes() int {
+	if c.MaxBytes <= 0 {
+		return DefaultQueryMaxBytes
+	}
+	return c.MaxBytes
+}
+
+// GetDefaultLimit 获取默认每页行数，不超过 GetMaxRows
+func (c *QueryConfig) GetDefaultLimit() int {
+	limit := c.DefaultLimit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	if limit > c.GetMaxRows() {
+		limit = c.GetMaxRows()
+	}
+	return limit
+}
+
+// 默认事务配置
+const (
+	DefaultTransactionIdleTimeout = 60 * time.Second
+	DefaultMaxTransactions        = 5
+)
+
+// GetTransactionIdleTimeout 获取事务空闲超时（未配置时使用默认值）
+func (c *DatabaseConfig) GetTransactionIdleTimeout() time.Duration {
+	if c.TransactionIdleTimeout <= 0 {
+		return DefaultTransactionIdleTimeout
+	}
+	return c.TransactionIdleTimeout
+}
+
+// GetMaxTransactions 获取事务数上限（未配置时使用默认值）。
+// SQLite 连接池只有一个连接，同一时刻只能有一个事务
+func (c *DatabaseConfig) GetMaxTransactions() int {
+	if c.IsFileBased() {
+		return 1
+	}
+	if c.MaxTransactions <= 0 {
+		return DefaultMaxTransactions
+	}
+	return c.MaxTransactions
+}
+
+// ExecuteEnabled 是否允许执行修改语句
+func (c *DatabaseConfig) ExecuteEnabled() bool {
+	return c.AllowExecute && !c.ReadOnly
+}
+
+// GetDSN 获取数据库连接字符串。
+// MySQL 的 TLS 设置需要注册证书，不写入连接字符串，由 database 包在连接时设置
 func (c *DatabaseConfig) GetDSN() string {
 	switch c.Driver {
 	case "mysql":
-		return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-			c.User, c.Password, c.Host, c.Port, c.Name)
+		address := fmt.Sprintf("tcp(%s:%d)", c.Host, c.Port)
+		if c.Socket != "" {
+			address = fmt.Sprintf("unix(%s)", c.Socket)
+		}
+
+		params := map[string]string{
+			"charset":   "utf8mb4",
+			"parseTime": "True",
+			"loc":       "Local",
+		}
+		if c.Timeout.Connect > 0 {
+			params["timeout"] = c.Timeout.Connect.String()
+		}
+		if c.Timeout.Read > 0 {
+			params["readTimeout"] = c.Timeout.Read.String()
+		}
+		if c.Timeout.Write > 0 {
+			params["writeTimeout"] = c.Timeout.Write.String()
+		}
+		// 未识别的参数由驱动作为会话变量设置，max_execution_time 只对 SELECT 生效
+		if ms := c.Query.Timeout.Milliseconds(); ms > 0 {
+			params["max_execution_time"] = strconv.FormatInt(ms, 10)
+		}
+		for key, value := range c.Params {
+			params[key] = value
+		}
+
+		query := make([]string, 0, len(params))
+		for _, key := range sortedKeys(params) {
+			query = append(query, key+"="+url.QueryEscape(params[key]))
+		}
+		return fmt.Sprintf("%s:%s@%s/%s?%s", c.User, c.Password, address, c.Name, strings.Join(query, "&"))
 	case "postgres":
-		return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-			c.Host, c.Port, c.User, c.Password, c.Name)
+		// host 以 / 开头时驱动将其作为 socket 目录
+		host := c.Host
+		if c.Socket != "" {
+			host = c.Socket
+		}
+
+		params := map[string]string{
+			"host":     host,
+			"port":     strconv.Itoa(c.Port),
+			"user":     c.User,
+			"password": c.Password,
+			"dbname":   c.Name,
+			"sslmode":  c.TLS.GetMode(),
+		}
+		if c.TLS.CAFile != "" {
+			params["sslrootcert"] = c.TLS.CAFile
+		}
+		if c.TLS.CertFile != "" {
+			params["sslcert"] = c.TLS.CertFile
+			params["sslkey"] = c.TLS.KeyFile
+		}
+		// connect_timeout 以秒为单位，不足1秒按1秒计
+		if c.Timeout.Connect > 0 {
+			seconds := int((c.Timeout.Connect + time.Second - 1) / time.Second)
+			params["connect_timeout"] = strconv.Itoa(seconds)
+		}
+		// 未识别的参数由驱动作为运行时参数发送
+		if ms := c.Query.Timeout.Milliseconds(); ms > 0 {
+			params["statement_timeout"] = strconv.FormatInt(ms, 10)
+		}
+		for key, value := range c.Params {
+			params[key] = value
+		}
+
+		pairs := make([]string, 0, len(params))
+		for _, key := range sortedKeys(params) {
+			pairs = append(pairs, key+"="+quotePostgresValue(params[key]))
+		}
+		return strings.Join(pairs, " ")
+	case "sqlite":
+		// Name 为数据库文件路径，":memory:" 为内存数据库
+		var params []string
+		if c.ReadOnly {
+			params = append(params, "_pragma=query_only(1)")
+		}
+		for _, key := range sortedKeys(c.Params) {
+			params = append(params, key+"="+url.QueryEscape(c.Params[key]))
+		}
+		if len(params) == 0 {
+			return c.Name
+		}
+
+		separator := "?"
+		if strings.Contains(c.Name, "?") {
+			separator = "&"
+		}
+		return c.Name + separator + strings.Join(params, "&")
 	default:
 		return ""
 	}
 }
 
+// quotePostgresValue 按 libpq 连接字符串规则引用值
+func quotePostgresValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+// sortedKeys 按名称排序的键，保证连接字符串稳定
+func sortedKeys(params map[string]string) []string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // IsValid 验证配置是否有效
 func (c *DatabaseConfig) IsValid() bool {
-	return c.Enabled && c.Host != "" && c.User != "" && c.Name != ""
+	if c.IsFileBased() {
+		return c.Enabled && c.Name != ""
+	}
+	return c.Enabled && (c.Host != "" || c.Socket != "") && c.User != "" && c.Name != ""
+}
+
+// IsFileBased 是否为无需主机和用户的文件数据库
+func (c *DatabaseConfig) IsFileBased() bool {
+	return c.Driver == "sqlite"
+}
+
+// GetLocation 获取用于日志和状态展示的连接位置（不含密码）
+func (c *DatabaseConfig) GetLocation() string {
+	if c.IsFileBased() {
+		return c.Name
+	}
+	if c.Socket != "" {
+		return fmt.Sprintf("%s@unix(%s)/%s", c.User, c.Socket, c.Name)
+	}
+	return fmt.Sprintf("%s@%s:%d/%s", c.User, c.Host, c.Port, c.Name)
 }
 
 // 环境变量辅助函数
@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"log"
 	"os"
+	"sort"
 
 	"gopkg.in/yaml.v3"
 )
@@ -10,7 +12,12 @@ import (
 // Config 主配置结构
 type Config struct {
 	Database DatabaseConfig `yaml:"database" json:"database"`
-	Redis    RedisConfig    `yaml:"redis" json:"redis"`
+	// 多个具名数据库连接，配置后忽略 database
+	Databases map[string]DatabaseConfig `yaml:"databases" json:"databases"`
+	// 工具未指定 database 参数时使用的连接
+	DefaultDatabase string       `yaml:"default_database" json:"default_database"`
+	Redis           RedisConfig  `yaml:"redis" json:"redis"`
+	Server          ServerConfig `yaml:"server" json:"server"`
 }
 
 // LoadConfig 从文件加载配置
@@ -44,6 +51,9 @@ func LoadDefaultConfig() *Config {
 			Port:    6379,
 			DB:      0,
 		},
+		Server: ServerConfig{
+			RequestTimeout: DefaultRequestTimeout,
+		},
 	}
 }
 
@@ -66,7 +76,56 @@ func (c *Config) GetDatabaseConfig() *DatabaseConfig {
 	return &c.Database
 }
 
+// DefaultConnectionName 只配置 database 时的连接名称
+const DefaultConnectionName = "default"
+
+// GetDatabaseConfigs 获取所有具名数据库配置，未配置 databases 时 database 作为名为 default 的连接
+func (c *Config) GetDatabaseConfigs() map[string]*DatabaseConfig {
+	configs := make(map[string]*DatabaseConfig)
+	if len(c.Databases) == 0 {
+		configs[DefaultConnectionName] = &c.Database
+		return configs
+	}
+
+	for name, dbConfig := range c.Databases {
+		dbConfig := dbConfig
+		configs[name] = &dbConfig
+	}
+	return configs
+}
+
+// GetDefaultDatabase 获取默认连接名称：优先使用 default_database，
+// 未配置时依次选择名为 default 的连接、唯一的连接、名称排序第一的连接
+func (c *Config) GetDefaultDatabase() string {
+	configs := c.GetDatabaseConfigs()
+	if c.DefaultDatabase != "" {
+		if _, ok := configs[c.DefaultDatabase]; ok {
+			return c.DefaultDatabase
+		}
+		log.Printf("Warning: default_database %q is not configured", c.DefaultDatabase)
+	}
+	if _, ok := configs[DefaultConnectionName]; ok {
+		return DefaultConnectionName
+	}
+
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > 1 {
+		log.Printf("Warning: default_database is not set, using %q", names[0])
+	}
+	return names[0]
+}
+
 // GetRedisConfig 获取Redis配置
 func (c *Config) GetRedisConfig() *RedisConfig {
 	return &c.Redis
 }
+
+// GetServerConfig 获取服务器配置（已应用环境变量覆盖）
+func (c *Config) GetServerConfig() *ServerConfig {
+	c.Server.ApplyEnv()
+	return &c.Server
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// ServerConfig MCP服务器通用配置
+type ServerConfig struct {
+	// 单个请求的处理超时，0 表示不限制
+	RequestTimeout time.Duration `yaml:"request_timeout" json:"request_timeout"`
+	// 主动 ping 客户端的间隔，0 表示不发送
+	PingInterval time.Duration `yaml:"ping_interval" json:"ping_interval"`
+	// 等待客户端 ping 响应的超时
+	PingTimeout time.Duration `yaml:"ping_timeout" json:"ping_timeout"`
+	// 批量请求中的各条消息是否并行处理
+	BatchParallel bool `yaml:"batch_parallel" json:"batch_parallel"`
+}
+
+// 默认服务器配置
+const (
+	DefaultRequestTimeout = 60 * time.Second
+	DefaultPingTimeout    = 10 * time.Second
+)
+
+// ApplyEnv 使用环境变量覆盖服务器配置
+func (c *ServerConfig) ApplyEnv() {
+	c.RequestTimeout = getEnvDuration("MCP_REQUEST_TIMEOUT", c.RequestTimeout)
+	c.PingInterval = getEnvDuration("MCP_PING_INTERVAL", c.PingInterval)
+	c.PingTimeout = getEnvDuration("MCP_PING_TIMEOUT", c.PingTimeout)
+	c.BatchParallel = getEnvBool("MCP_BATCH_PARALLEL", c.BatchParallel)
+}
+
+// GetPingTimeout 获取 ping 超时（未配置时使用默认值）
+func (c *ServerConfig) GetPingTimeout() time.Duration {
+	if c.PingTimeout <= 0 {
+		return DefaultPingTimeout
+	}
+	return c.PingTimeout
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,757 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"hello-mcp-server/config"
+)
+
+// AccessPolicy 按配置的白名单和黑名单判断 schema、表和字段是否可访问
+type AccessPolicy struct {
+	cfg config.AccessConfig
+}
+
+// NewAccessPolicy 校验通配符并创建访问策略，没有配置任何限制时返回nil
+func NewAccessPolicy(cfg config.AccessConfig) (*AccessPolicy, error) {
+	if cfg.IsEmpty() {
+		return nil, nil
+	}
+
+	for _, list := range []config.AccessList{cfg.Schemas, cfg.Tables, cfg.Columns} {
+		for _, patterns := range [][]string{list.Allow, list.Deny} {
+			if err := ValidateTablePatterns(patterns); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &AccessPolicy{cfg: cfg}, nil
+}
+
+// allowed 名称是否通过白名单和黑名单
+func allowed(list config.AccessList, name string) bool {
+	if len(list.Allow) > 0 && !MatchTablePattern(list.Allow, name) {
+		return false
+	}
+	return !MatchTablePattern(list.Deny, name)
+}
+
+// SchemaAllowed schema 是否可访问
+func (p *AccessPolicy) SchemaAllowed(schema string) bool {
+	return p == nil || allowed(p.cfg.Schemas, schema)
+}
+
+// TableAllowed schema 中的表是否可访问
+func (p *AccessPolicy) TableAllowed(schema, table string) bool {
+	return p == nil || (p.SchemaAllowed(schema) && allowed(p.cfg.Tables, table))
+}
+
+// ColumnAllowed 表中的字段是否可访问
+func (p *AccessPolicy) ColumnAllowed(table, column string) bool {
+	if p == nil {
+		return true
+	}
+	list := p.cfg.Columns
+	if len(list.Allow) > 0 && !matchColumnPattern(list.Allow, table, column) {
+		return false
+	}
+	return !matchColumnPattern(list.Deny, table, column)
+}
+
+// matchColumnPattern 字段是否匹配任一 "表.字段" 或 "字段" 通配符
+func matchColumnPattern(patterns []string, table, column string) bool {
+	for _, pattern := range patterns {
+		if i := strings.Index(pattern, "."); i >= 0 {
+			if MatchTablePattern([]string{pattern[:i]}, table) && MatchTablePattern([]string{pattern[i+1:]}, column) {
+				return true
+			}
+		} else if MatchTablePattern([]string{pattern}, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// tableVisible 当前库中的表（外键可能引用 "schema.表"）是否可访问
+func (dm *DatabaseManager) tableVisible(name string) bool {
+	if dm.access == nil {
+		return true
+	}
+	schema := dm.currentSchema
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		schema, name = name[:i], name[i+1:]
+	}
+	return dm.access.TableAllowed(schema, name)
+}
+
+// filterTableNames 去掉不可访问的表
+func (dm *DatabaseManager) filterTableNames(tables []string) []string {
+	if dm.access == nil {
+		return tables
+	}
+	visible := make([]string, 0, len(tables))
+	for _, table := range tables {
+		if dm.tableVisible(table) {
+			visible = append(visible, table)
+		}
+	}
+	return visible
+}
+
+// filterColumns 去掉表中不可访问的字段
+func (dm *DatabaseManager) filterColumns(table string, columns []ColumnInfo) []ColumnInfo {
+	if dm.access == nil {
+		return columns
+	}
+	visible := make([]ColumnInfo, 0, len(columns))
+	for _, col := range columns {
+		if dm.access.ColumnAllowed(table, col.Name) {
+			visible = append(visible, col)
+		}
+	}
+	return visible
+}
+
+// foreignKeyVisible 外键两端的表和字段是否都可访问
+func (dm *DatabaseManager) foreignKeyVisible(key ForeignKey) bool {
+	if dm.access == nil {
+		return true
+	}
+	if !dm.tableVisible(key.Table) || !dm.tableVisible(key.ReferencedTable) {
+		return false
+	}
+	referenced := key.ReferencedTable
+	if i := strings.LastIndex(referenced, "."); i >= 0 {
+		referenced = referenced[i+1:]
+	}
+	for _, col := range key.Columns {
+		if !dm.access.ColumnAllowed(key.Table, col) {
+			return false
+		}
+	}
+	for _, col := range key.ReferencedColumns {
+		if !dm.access.ColumnAllowed(referenced, col) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterForeignKeys 去掉涉及不可访问的表或字段的外键
+func (dm *DatabaseManager) filterForeignKeys(keys []ForeignKey) []ForeignKey {
+	if dm.access == nil {
+		return keys
+	}
+	var visible []ForeignKey
+	for _, key := range keys {
+		if dm.foreignKeyVisible(key) {
+			visible = append(visible, key)
+		}
+	}
+	return visible
+}
+
+// filterTableSchema 去掉表结构中不可访问的字段，以及包含这些字段的索引、外键和检查约束
+func (dm *DatabaseManager) filterTableSchema(schema *TableSchema) {
+	if dm.access == nil {
+		return
+	}
+
+	hidden := make(map[string]bool)
+	for _, col := range schema.Columns {
+		if !dm.access.ColumnAllowed(schema.Name, col.Name) {
+			hidden[strings.ToLower(col.Name)] = true
+		}
+	}
+	schema.Columns = dm.filterColumns(schema.Name, schema.Columns)
+
+	var indexes []IndexInfo
+	for _, index := range schema.Indexes {
+		if !containsHidden(index.Columns, hidden) {
+			indexes = append(indexes, index)
+		}
+	}
+	schema.Indexes = indexes
+
+	schema.ForeignKeys = dm.filterForeignKeys(schema.ForeignKeys)
+	schema.ReferencedBy = dm.filterForeignKeys(schema.ReferencedBy)
+
+	var checks []CheckConstraint
+	for _, check := range schema.Checks {
+		words := queryWords(check.Expression)
+		mentions := false
+		for name := range hidden {
+			if words[name] {
+				mentions = true
+				break
+			}
+		}
+		if !mentions {
+			checks = append(checks, check)
+		}
+	}
+	schema.Checks = checks
+}
+
+// containsHidden 字段列表中是否有不可访问的字段
+func containsHidden(columns []string, hidden map[string]bool) bool {
+	for _, col := range columns {
+		if hidden[strings.ToLower(col)] {
+			return true
+		}
+	}
+	return false
+}
+
+// 系统目录所在的 schema，配置了访问限制时禁止直接查询，以免绕过限制看到隐藏的表
+var catalogSchemas = map[string]bool{
+	"information_schema": true,
+	"pg_catalog":         true,
+	"pg_toast":           true,
+	"mysql":              true,
+	"performance_schema": true,
+	"sys":                true,
+}
+
+// 配置了访问限制时禁止的语句：SHOW、PRAGMA 列出结构，USE 切换默认库
+var accessDeniedKeywords = map[string]bool{
+	"SHOW":   true,
+	"PRAGMA": true,
+	"USE":    true,
+}
+
+// CheckAccess 检查语句引用的 schema 和表是否都可访问，并禁止查询系统目录。
+// 只做静态分析，字段级的检查在执行时进行
+func (dm *DatabaseManager) CheckAccess(query string) error {
+	_, err := dm.checkTableAccess(query)
+	return err
+}
+
+// checkTableAccess 检查语句引用的表，返回其中当前库中的表（不含 schema）
+func (dm *DatabaseManager) checkTableAccess(query string) ([]string, error) {
+	if dm.access == nil {
+		return nil, nil
+	}
+
+	var tables []string
+	seen := make(map[string]bool)
+	for _, mode := range []lexMode{standardLex, mysqlLex} {
+		for _, stmt := range analyze(query, mode) {
+			if accessDeniedKeywords[stmt.Keyword] {
+				return nil, fmt.Errorf("access denied: %s statements are not allowed when access lists are configured", stmt.Keyword)
+			}
+			if stmt.Keyword == "SET" && containsWord(stmt.tokens, "SEARCH_PATH") {
+				return nil, fmt.Errorf("access denied: changing search_path is not allowed when access lists are configured")
+			}
+
+			for _, ref := range tableReferences(stmt.tokens) {
+				if err := dm.checkTableRef(ref); err != nil {
+					return nil, err
+				}
+				if ref.function || (ref.schema != "" && !strings.EqualFold(ref.schema, dm.currentSchema)) {
+					continue
+				}
+				if key := strings.ToLower(ref.name); !seen[key] {
+					seen[key] = true
+					tables = append(tables, ref.name)
+				}
+			}
+		}
+	}
+	return tables, nil
+}
+
+// checkTableRef 检查单个表引用
+func (dm *DatabaseManager) checkTableRef(ref tableRef) error {
+	name := strings.ToLower(ref.name)
+	if ref.function {
+		// SQLite 的 pragma_table_info() 等表值函数可以读取任意表的结构
+		if strings.HasPrefix(name, "pragma_") {
+			return fmt.Errorf("access denied: %s() is not allowed when access lists are configured", ref.name)
+		}
+		return nil
+	}
+
+	schema := ref.schema
+	if schema == "" {
+		schema = dm.currentSchema
+		if strings.HasPrefix(name, "pg_") || strings.HasPrefix(name, "sqlite_") {
+			return fmt.Errorf("access denied: system catalog %s is not accessible when access lists are configured", ref.name)
+		}
+	}
+	if catalogSchemas[strings.ToLower(schema)] {
+		return fmt.Errorf("access denied: system catalog %s.%s is not accessible when access lists are configured", schema, ref.name)
+	}
+
+	if !dm.access.SchemaAllowed(schema) {
+		return fmt.Errorf("access denied: schema %s is not accessible", schema)
+	}
+	if !dm.access.TableAllowed(schema, ref.name) {
+		return fmt.Errorf("access denied: table %s is not accessible", ref.name)
+	}
+	return nil
+}
+
+// checkAccess 执行前的完整检查：表级检查，以及语句中不能出现引用的表中不可访问的字段名。
+// 返回引用的表中不可访问的字段名（小写），用于从 SELECT * 等结果中删除这些列
+func (dm *DatabaseManager) checkAccess(q Queryer, query string) (map[string]bool, error) {
+	tables, err := dm.checkTableAccess(query)
+	if err != nil || len(tables) == 0 || dm.access.cfg.Columns.IsEmpty() {
+		return nil, err
+	}
+
+	// 语句中的名称可能与实际大小写不同，按目录中的名称获取字段。
+	// 无法确认字段时拒绝执行，只有语句自身创建的表可以不存在
+	catalog, err := dm.dialect.ListTables(q)
+	if err != nil {
+		return nil, fmt.Errorf("access denied: cannot verify column access: %v", err)
+	}
+	created := createdTables(query)
+
+	hidden := make(map[string]string)
+	restricted := make(map[string]bool)
+	for _, name := range tables {
+		table, ok := matchTableName(catalog, name)
+		if !ok {
+			if created[strings.ToLower(name)] {
+				continue
+			}
+			return nil, fmt.Errorf("access denied: cannot verify column access for table %s: table not found", name)
+		}
+		columns, err := dm.dialect.DescribeTable(q, table)
+		if err != nil {
+			return nil, fmt.Errorf("access denied: cannot verify column access for table %s: %v", name, err)
+		}
+		for _, col := range columns {
+			if !dm.access.ColumnAllowed(table, col.Name) {
+				hidden[strings.ToLower(col.Name)] = table + "." + col.Name
+				restricted[strings.ToLower(table)] = true
+				restricted[strings.ToLower(name)] = true
+			}
+		}
+	}
+	if len(hidden) == 0 {
+		return nil, nil
+	}
+
+	for word := range queryWords(query) {
+		if name, ok := hidden[word]; ok {
+			return nil, fmt.Errorf("access denied: column %s is not accessible", name)
+		}
+	}
+	if err := checkWholeRows(query, restricted); err != nil {
+		return nil, err
+	}
+	if err := checkHiddenStars(query); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(hidden))
+	for name := range hidden {
+		names[name] = true
+	}
+	return names, nil
+}
+
+// queryWords 查询中出现的所有标识符（小写），用于查找不可访问的字段名。
+// 按两种词法规则分析，避免引号差异导致漏掉名称
+func queryWords(query string) map[string]bool {
+	words := make(map[string]bool)
+	for _, mode := range []lexMode{standardLex, mysqlLex} {
+		for _, stmt := range analyze(query, mode) {
+			for _, tok := range stmt.tokens {
+				if tok.kind == tokenWord || tok.kind == tokenQuotedIdent {
+					words[strings.ToLower(tok.raw)] = true
+				}
+			}
+		}
+	}
+	return words
+}
+
+// checkWholeRows 拒绝对包含不可访问字段的表的整行引用（如 PostgreSQL 的 SELECT u FROM users u、
+// to_json(u)、row_to_json(u)），它们不写出字段名就能读取所有字段。
+// CTE 和派生表可能包含这些表的所有字段，同样不能整行引用
+func checkWholeRows(query string, restricted map[string]bool) error {
+	for _, mode := range []lexMode{standardLex, mysqlLex} {
+		for _, stmt := range analyze(query, mode) {
+			l := analyzeLineage(stmt.tokens)
+			rows := cteNames(stmt.tokens)
+			for name := range l.derived {
+				rows[name] = true
+			}
+			for _, ref := range tableReferences(stmt.tokens) {
+				if restricted[strings.ToLower(ref.name)] {
+					rows[strings.ToLower(ref.name)] = true
+					if ref.alias != "" {
+						rows[strings.ToLower(ref.alias)] = true
+					}
+				}
+			}
+			if ref := wholeRowReference(stmt.tokens, l, rows); ref != "" {
+				return fmt.Errorf("access denied: whole-row reference %s is not allowed on tables with inaccessible columns", ref)
+			}
+		}
+	}
+	return nil
+}
+
+// checkHiddenStars 拒绝结果不按列名删除的 *：标量子查询和 UNION 后续分支中的 *（字段按位置输出，
+// 列名与来源无关），以及写入语句中的 *（如 INSERT ... SELECT *、CREATE TABLE ... AS SELECT *）。
+// 只在语句引用了包含不可访问字段的表时调用
+func checkHiddenStars(query string) error {
+	for _, mode := range []lexMode{standardLex, mysqlLex} {
+		for _, stmt := range analyze(query, mode) {
+			l := analyzeLineage(stmt.tokens)
+			for i, tok := range stmt.tokens {
+				if tok.kind != tokenPunct || tok.value != "*" || i == 0 {
+					continue
+				}
+				if l.uses[i].use == useExpression {
+					return fmt.Errorf("access denied: * in a subquery or UNION branch is not allowed on tables with inaccessible columns; list the columns")
+				}
+				switch stmt.tokens[i-1].value {
+				case "SELECT", "DISTINCT", "ALL", ",", ".":
+					if stmt.Class != StatementRead {
+						return fmt.Errorf("access denied: * is not allowed in %s statements on tables with inaccessible columns; list the columns", stmt.Keyword)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// createdTables 语句中 CREATE ... TABLE 创建的表名（小写）
+func createdTables(query string) map[string]bool {
+	names := make(map[string]bool)
+	for _, mode := range []lexMode{standardLex, mysqlLex} {
+		for _, stmt := range analyze(query, mode) {
+			if len(stmt.tokens) == 0 || stmt.tokens[0].value != "CREATE" {
+				continue
+			}
+			for i, tok := range stmt.tokens {
+				if tok.value == "(" || tok.value == "AS" {
+					break
+				}
+				if tok.kind == tokenWord && tok.value == "TABLE" {
+					if ref, _, ok := parseTableName(stmt.tokens, i+1, false); ok {
+						names[strings.ToLower(ref.name)] = true
+					}
+					break
+				}
+			}
+		}
+	}
+	return names
+}
+
+// hideColumns 从结果中删除不可访问的列
+func hideColumns(result *QueryResult, hidden map[string]bool) {
+	if len(hidden) == 0 || result.Error != "" {
+		return
+	}
+	var dropped []int
+	for i, col := range result.Columns {
+		if hidden[strings.ToLower(col)] {
+			dropped = append(dropped, i)
+		}
+	}
+	if len(dropped) > 0 {
+		dropColumns(result, dropped)
+	}
+}
+
+// tableRef 语句中引用的表
+type tableRef struct {
+	schema string
+	name   string
+	// 表值函数，如 FROM generate_series(...)
+	function bool
+	// 别名，未指定时为空
+	alias string
+}
+
+// 之后跟表名的关键字
+var tableRefKeywords = map[string]bool{
+	"FROM": true, "JOIN": true, "UPDATE": true, "INTO": true, "TABLE": true,
+	"TABLES": true, "USING": true, "TRUNCATE": true, "COPY": true,
+}
+
+// 表名之后不是别名的关键字
+var tableRefStopWords = map[string]bool{
+	"WHERE": true, "JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true,
+	"OUTER": true, "CROSS": true, "NATURAL": true, "STRAIGHT_JOIN": true, "ON": true,
+	"USING": true, "GROUP": true, "ORDER": true, "LIMIT": true, "OFFSET": true,
+	"HAVING": true, "UNION": true, "EXCEPT": true, "INTERSECT": true, "WINDOW": true,
+	"FOR": true, "SET": true, "VALUES": true, "RETURNING": true, "FETCH": true,
+	"SELECT": true, "INTO": true, "PARTITION": true, "TABLESAMPLE": true, "WITH": true,
+	"DEFAULT": true, "OUTFILE": true, "DUMPFILE": true, "READ": true, "WRITE": true,
+}
+
+// tableReferences 找出语句中引用的表：FROM、JOIN、UPDATE、INTO 等之后的名称及 FROM 后逗号分隔的列表，
+// 以及语句开头的 DESCRIBE。函数参数中的 FROM（如 EXTRACT(YEAR FROM d)）和可见范围内的 WITH 定义的名称不计入
+func tableReferences(tokens []sqlToken) []tableRef {
+	ctes := cteScopes(tokens)
+
+	var refs []tableRef
+	add := func(ref tableRef, pos int) {
+		if ref.schema == "" && !ref.function && inCTEScope(ctes, ref.name, pos) {
+			return
+		}
+		refs = append(refs, ref)
+	}
+
+	if len(tokens) > 1 && (tokens[0].value == "DESCRIBE" || tokens[0].value == "DESC") {
+		if ref, _, ok := parseTableName(tokens, 1, false); ok {
+			add(ref, 1)
+		}
+	}
+
+	// CREATE INDEX/TRIGGER 的第一个 ON 之后是表名，其他 ON 之后是连接条件
+	indexOn := len(tokens) > 0 && tokens[0].value == "CREATE" &&
+		(containsWord(tokens, "INDEX") || containsWord(tokens, "TRIGGER"))
+
+	// 每层括号是否为子查询，只有子查询（和最外层）中的 FROM 之后是表名
+	queryLevels := []bool{true}
+	for i, tok := range tokens {
+		if indexOn && tok.kind == tokenWord && tok.value == "ON" {
+			indexOn = false
+			if ref, _, ok := parseTableName(tokens, i+1, false); ok {
+				add(ref, i+1)
+			}
+			continue
+		}
+		switch tok.value {
+		case "(":
+			isQuery := i+1 < len(tokens) && (tokens[i+1].value == "SELECT" || tokens[i+1].value == "WITH")
+			queryLevels = append(queryLevels, isQuery)
+			continue
+		case ")":
+			if len(queryLevels) > 1 {
+				queryLevels = queryLevels[:len(queryLevels)-1]
+			}
+			continue
+		}
+		if tok.kind != tokenWord || !tableRefKeywords[tok.value] {
+			continue
+		}
+		if tok.value == "FROM" {
+			// IS [NOT] DISTINCT FROM 和函数参数中的 FROM
+			if !queryLevels[len(queryLevels)-1] || (i > 0 && tokens[i-1].value == "DISTINCT") {
+				continue
+			}
+		}
+
+		// 只有 FROM/JOIN/USING 之后的 name(...) 是表值函数，INSERT INTO t (...) 中是字段列表
+		functions := tok.value == "FROM" || tok.value == "JOIN" || tok.value == "USING"
+		j := i + 1
+		for {
+			pos := j
+			ref, next, ok := parseTableName(tokens, j, functions)
+			// 派生表 (SELECT ...) 之后仍可能有逗号分隔的表，其中的 FROM 在遍历到时处理
+			derived := false
+			if (tok.value == "FROM" || tok.value == "JOIN") && !ok {
+				k := j
+				for k < len(tokens) && tokens[k].value == "LATERAL" {
+					k++
+				}
+				if k < len(tokens) && tokens[k].value == "(" {
+					next, ok, derived = skipParens(tokens, k), true, true
+				}
+			}
+			if !ok {
+				break
+			}
+
+			// 读取别名并跳过列别名列表，逗号之后是下一张表
+			j = next
+			if j+1 < len(tokens) && tokens[j].value == "AS" {
+				ref.alias = tokens[j+1].raw
+				j += 2
+			} else if j < len(tokens) && (tokens[j].kind == tokenQuotedIdent ||
+				(tokens[j].kind == tokenWord && !tableRefStopWords[tokens[j].value])) {
+				ref.alias = tokens[j].raw
+				j++
+			}
+			if !derived {
+				add(ref, pos)
+			}
+			if j < len(tokens) && tokens[j].value == "(" {
+				j = skipParens(tokens, j)
+			}
+			if j >= len(tokens) || tokens[j].value != "," {
+				break
+			}
+			j++
+		}
+	}
+	return refs
+}
+
+// parseTableName 从 start 开始读取 [schema.]名称，返回引用和其后的位置。
+// 跳过 IF [NOT] EXISTS、ONLY、LATERAL 等修饰词，支持 SQLite 的 [名称] 引用
+func parseTableName(tokens []sqlToken, start int, functions bool) (tableRef, int, bool) {
+	i := start
+	for i < len(tokens) && tokens[i].kind == tokenWord {
+		switch tokens[i].value {
+		case "IF", "NOT", "EXISTS", "ONLY", "LATERAL", "IGNORE", "LOW_PRIORITY", "QUICK":
+			i++
+			continue
+		}
+		break
+	}
+
+	var parts []string
+	for i < len(tokens) {
+		tok := tokens[i]
+		switch {
+		case tok.kind == tokenQuotedIdent || (tok.kind == tokenWord && (len(parts) > 0 || !tableRefStopWords[tok.value])):
+			parts = append(parts, tok.raw)
+			i++
+		case tok.value == "[" && i+2 < len(tokens) && tokens[i+2].value == "]":
+			parts = append(parts, tokens[i+1].raw)
+			i += 3
+		default:
+			return tableRef{}, i, false
+		}
+		if i < len(tokens) && tokens[i].value == "." {
+			i++
+			continue
+		}
+		break
+	}
+	if len(parts) == 0 {
+		return tableRef{}, i, false
+	}
+
+	// 三段名称（库.schema.表）只取后两段
+	ref := tableRef{name: parts[len(parts)-1]}
+	if len(parts) > 1 {
+		ref.schema = parts[len(parts)-2]
+	}
+	if functions && i < len(tokens) && tokens[i].value == "(" {
+		ref.function = true
+		i = skipParens(tokens, i)
+	}
+	return ref, i, true
+}
+
+// skipParens 跳过从 start 开始的一对括号，返回右括号之后的位置
+func skipParens(tokens []sqlToken, start int) int {
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		switch tokens[i].value {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(tokens)
+}
+
+// cteScope WITH 定义的名称（小写）及其可见范围 [start, end)
+type cteScope struct {
+	name       string
+	start, end int
+}
+
+// cteScopes WITH 子句定义的名称及其可见范围：普通定义只在之后的定义和主查询中可见，
+// 不包括自身；WITH RECURSIVE 的定义在整个 WITH 子句中可见。括号中的 WITH 只作用于所在的括号
+func cteScopes(tokens []sqlToken) []cteScope {
+	var scopes []cteScope
+	for i, tok := range tokens {
+		if tok.kind != tokenWord || tok.value != "WITH" {
+			continue
+		}
+		end := enclosingEnd(tokens, i)
+		j := i + 1
+		recursive := j < len(tokens) && tokens[j].value == "RECURSIVE"
+		if recursive {
+			j++
+		}
+		for j < len(tokens) && (tokens[j].kind == tokenWord || tokens[j].kind == tokenQuotedIdent) {
+			name := strings.ToLower(tokens[j].raw)
+			j++
+			if j < len(tokens) && tokens[j].value == "(" {
+				j = skipParens(tokens, j)
+			}
+			if j >= len(tokens) || tokens[j].value != "AS" {
+				break
+			}
+			j++
+			for j < len(tokens) && (tokens[j].value == "NOT" || tokens[j].value == "MATERIALIZED") {
+				j++
+			}
+			if j < len(tokens) && tokens[j].value == "(" {
+				j = skipParens(tokens, j)
+			}
+
+			scope := cteScope{name: name, start: j, end: end}
+			if recursive {
+				scope.start = i
+			}
+			scopes = append(scopes, scope)
+			if j >= len(tokens) || tokens[j].value != "," {
+				break
+			}
+			j++
+		}
+	}
+	return scopes
+}
+
+// inCTEScope 位于 pos 的名称是否引用 WITH 定义的名称
+func inCTEScope(scopes []cteScope, name string, pos int) bool {
+	name = strings.ToLower(name)
+	for _, scope := range scopes {
+		if scope.name == name && pos >= scope.start && pos < scope.end {
+			return true
+		}
+	}
+	return false
+}
+
+// cteNames WITH 子句定义的所有名称（小写）
+func cteNames(tokens []sqlToken) map[string]bool {
+	names := make(map[string]bool)
+	for _, scope := range cteScopes(tokens) {
+		names[scope.name] = true
+	}
+	return names
+}
+
+// enclosingEnd 包含 start 的括号的右括号位置，不在括号中时返回语句结尾
+func enclosingEnd(tokens []sqlToken, start int) int {
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		switch tokens[i].value {
+		case "(":
+			depth++
+		case ")":
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return len(tokens)
+}
+
+// filterSchemaColumns 去掉不可访问的表和字段
+func (dm *DatabaseManager) filterSchemaColumns(columns []SchemaColumn) []SchemaColumn {
+	if dm.access == nil {
+		return columns
+	}
+	visible := make([]SchemaColumn, 0, len(columns))
+	for _, col := range columns {
+		if dm.tableVisible(col.Table) && dm.access.ColumnAllowed(col.Table, col.Column) {
+			visible = append(visible, col)
+		}
+	}
+	return visible
+}
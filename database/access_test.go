@@ -0,0 +1,140 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"hello-mcp-server/config"
+)
+
+func TestCheckAccessTables(t *testing.T) {
+	dm := newSQLiteManager(t, config.DatabaseConfig{
+		Access: config.AccessConfig{Tables: config.AccessList{Deny: []string{"secrets"}}},
+	},
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE secrets (k TEXT)",
+	)
+
+	tests := []struct {
+		name    string
+		sql     string
+		allowed bool
+	}{
+		{"allowed table", "SELECT * FROM users", true},
+		{"denied table", "SELECT * FROM secrets", false},
+		{"denied in subquery", "SELECT * FROM users WHERE name IN (SELECT k FROM secrets)", false},
+		{"denied after derived table", "SELECT * FROM (SELECT 1) x, secrets", false},
+
+		// MySQL 可执行注释中的内容会被执行
+		{"executable comment", "SELECT 1 /*!, (SELECT k FROM secrets) */", false},
+		{"versioned executable comment", "SELECT 1 /*!50000 UNION SELECT k FROM secrets */", false},
+		{"plain comment", "SELECT 1 /* FROM secrets */", true},
+		// MySQL 的 "..." 是字符串，其中的 \" 不结束字符串
+		{"backslash in double quotes", `SELECT "\" ", k FROM secrets -- "`, false},
+
+		// CTE 名称的可见范围
+		{"cte", "WITH s AS (SELECT 1) SELECT * FROM s", true},
+		{"cte shadows table", "WITH secrets AS (SELECT 1) SELECT * FROM secrets", true},
+		{"cte body reads shadowed table", "WITH secrets AS (SELECT * FROM secrets) SELECT * FROM secrets", false},
+		{"recursive cte", "WITH RECURSIVE secrets(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM secrets WHERE n < 3) SELECT * FROM secrets", true},
+		{"later cte", "WITH a AS (SELECT 1), b AS (SELECT * FROM a) SELECT * FROM b", true},
+		{"forward reference", "WITH a AS (SELECT * FROM secrets), secrets AS (SELECT 1) SELECT * FROM a", false},
+		{"nested cte scope", "SELECT * FROM (WITH secrets AS (SELECT 1) SELECT * FROM secrets) x, secrets", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dm.CheckAccess(tt.sql)
+			if (err == nil) != tt.allowed {
+				t.Errorf("CheckAccess(%q) = %v, want allowed=%v", tt.sql, err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestCheckAccessColumns(t *testing.T) {
+	dm := newSQLiteManager(t, config.DatabaseConfig{
+		Access: config.AccessConfig{Columns: config.AccessList{Deny: []string{"users.password"}}},
+	},
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, password TEXT)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER)",
+		"INSERT INTO users (name, password) VALUES ('alice', 'secret')",
+	)
+
+	tests := []struct {
+		name    string
+		sql     string
+		allowed bool
+	}{
+		{"star hides column", "SELECT * FROM users", true},
+		{"qualified star", "SELECT u.* FROM users u", true},
+		{"qualified column", "SELECT u.id, u.name FROM users u", true},
+		{"join", "SELECT o.id, u.name FROM orders o JOIN users u ON u.id = o.user_id", true},
+		{"denied column", "SELECT password FROM users", false},
+		{"denied column in filter", "SELECT id FROM users WHERE password LIKE 's%'", false},
+
+		// 整行引用
+		{"whole row", "SELECT u FROM users u", false},
+		{"whole row by table name", "SELECT users FROM users", false},
+		{"to_json", "SELECT to_json(u) FROM users u", false},
+		{"row_to_json", "SELECT row_to_json(u) FROM users AS u", false},
+		{"whole row in filter", "SELECT id FROM users u WHERE to_json(u)::text LIKE '%secret%'", false},
+		{"star in expression", "SELECT json_agg(u.*) FROM users u", false},
+		{"derived table", "SELECT row_to_json(t) FROM (SELECT * FROM users) t", false},
+		{"cte", "WITH t AS (SELECT * FROM users) SELECT to_json(t) FROM t", false},
+		{"table without denied columns", "SELECT o.* FROM orders o", true},
+
+		{"backslash in double quotes", `SELECT "\" ", password FROM users -- "`, false},
+		{"table name in other case", "SELECT * FROM USERS", true},
+		{"unknown table", "SELECT * FROM missing", false},
+		{"star in union branch", "SELECT id, user_id, id FROM orders UNION SELECT * FROM users", false},
+		{"star in scalar subquery", "SELECT (SELECT * FROM (SELECT password FROM users) s) AS x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := dm.ExecuteQuery(tt.sql, Page{})
+			denied := strings.Contains(result.Error, "access denied")
+			if denied == tt.allowed {
+				t.Errorf("ExecuteQuery(%q) error = %q, want allowed=%v", tt.sql, result.Error, tt.allowed)
+			}
+			if tt.allowed && result.Error == "" {
+				for _, col := range result.Columns {
+					if strings.EqualFold(col, "password") {
+						t.Errorf("ExecuteQuery(%q) returned hidden column", tt.sql)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCheckAccessColumnsInStatements(t *testing.T) {
+	dm := newSQLiteManager(t, config.DatabaseConfig{
+		Access: config.AccessConfig{Columns: config.AccessList{Deny: []string{"users.password"}}},
+	},
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, password TEXT)",
+	)
+
+	tests := []struct {
+		name    string
+		sql     string
+		allowed bool
+	}{
+		{"create table", "CREATE TABLE notes (id INTEGER, body TEXT)", true},
+		{"insert listed columns", "INSERT INTO notes (id, body) SELECT id, name FROM users", true},
+		{"insert star", "INSERT INTO notes SELECT id, * FROM users", false},
+		{"create table as star", "CREATE TABLE copy AS SELECT * FROM users", false},
+		{"unknown table", "UPDATE missing SET name = 'x'", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := dm.ExecuteStatement(tt.sql, false)
+			denied := strings.Contains(result.Error, "access denied")
+			if denied == tt.allowed {
+				t.Errorf("ExecuteStatement(%q) error = %q, want allowed=%v", tt.sql, result.Error, tt.allowed)
+			}
+		})
+	}
+}
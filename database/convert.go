@@ -0,0 +1,203 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ValueKind 结果值的类别，决定驱动返回值转换为哪种JSON类型
+type ValueKind string
+
+const (
+	KindString  ValueKind = "string"
+	KindInteger ValueKind = "integer"
+	// KindDecimal 定点数，转换为字符串以保留精度
+	KindDecimal ValueKind = "decimal"
+	KindFloat   ValueKind = "float"
+	KindBoolean ValueKind = "boolean"
+	KindTime    ValueKind = "time"
+	KindDate    ValueKind = "date"
+	// KindJSON JSON列，解码为对象或数组
+	KindJSON ValueKind = "json"
+	// KindBinary 二进制列，编码为base64
+	KindBinary ValueKind = "binary"
+	// KindUnknown 无法从列类型判断（如 SQLite 表达式列），按值推断
+	KindUnknown ValueKind = "unknown"
+)
+
+// ColumnType 结果列的类型信息
+type ColumnType struct {
+	Name string `json:"name"`
+	// 数据库报告的类型名，如 VARCHAR、NUMERIC、JSONB
+	DatabaseType string    `json:"database_type"`
+	Kind         ValueKind `json:"kind"`
+	Nullable     *bool     `json:"nullable,omitempty"`
+	Length       *int64    `json:"length,omitempty"`
+	Precision    *int64    `json:"precision,omitempty"`
+	Scale        *int64    `json:"scale,omitempty"`
+}
+
+// 数据库类型名到值类别的映射，未列出的类型按名称中的关键字判断
+var kindsByType = map[string]ValueKind{
+	"INT": KindInteger, "INTEGER": KindInteger, "TINYINT": KindInteger, "SMALLINT": KindInteger,
+	"MEDIUMINT": KindInteger, "BIGINT": KindInteger, "INT2": KindInteger, "INT4": KindInteger,
+	"INT8": KindInteger, "YEAR": KindInteger,
+	"UNSIGNED INT": KindInteger, "UNSIGNED TINYINT": KindInteger, "UNSIGNED SMALLINT": KindInteger,
+	"UNSIGNED MEDIUMINT": KindInteger, "UNSIGNED BIGINT": KindInteger,
+
+	"DECIMAL": KindDecimal, "NUMERIC": KindDecimal, "MONEY": KindDecimal,
+
+	"FLOAT": KindFloat, "DOUBLE": KindFloat, "REAL": KindFloat, "FLOAT4": KindFloat, "FLOAT8": KindFloat,
+
+	"BOOL": KindBoolean, "BOOLEAN": KindBoolean,
+
+	"DATE":     KindDate,
+	"DATETIME": KindTime, "TIMESTAMP": KindTime, "TIMESTAMPTZ": KindTime,
+
+	"JSON": KindJSON, "JSONB": KindJSON,
+
+	"BLOB": KindBinary, "TINYBLOB": KindBinary, "MEDIUMBLOB": KindBinary, "LONGBLOB": KindBinary,
+	"BINARY": KindBinary, "VARBINARY": KindBinary, "BYTEA": KindBinary, "BIT": KindBinary,
+	"GEOMETRY": KindBinary,
+}
+
+// kindOf 根据数据库类型名判断值类别
+func kindOf(databaseType string) ValueKind {
+	name := strings.ToUpper(strings.TrimSpace(databaseType))
+	if name == "" {
+		return KindUnknown
+	}
+	if kind, ok := kindsByType[name]; ok {
+		return kind
+	}
+
+	// 带长度或修饰的类型名，如 SQLite 的 VARCHAR(20)、UNSIGNED BIG INT
+	switch {
+	case strings.Contains(name, "INT"):
+		return KindInteger
+	case strings.Contains(name, "CHAR"), strings.Contains(name, "TEXT"), strings.Contains(name, "CLOB"):
+		return KindString
+	case strings.Contains(name, "BLOB"), strings.Contains(name, "BINARY"):
+		return KindBinary
+	case strings.Contains(name, "DEC"), strings.Contains(name, "NUMERIC"):
+		return KindDecimal
+	case strings.Contains(name, "DOUB"), strings.Contains(name, "FLOA"), strings.Contains(name, "REAL"):
+		return KindFloat
+	case strings.Contains(name, "TIMESTAMP"), strings.Contains(name, "DATETIME"):
+		return KindTime
+	default:
+		return KindString
+	}
+}
+
+// newColumnTypes 读取结果列的类型信息，驱动不支持时类别为 unknown
+func newColumnTypes(columns []string, types []*sql.ColumnType) []ColumnType {
+	result := make([]ColumnType, len(columns))
+	for i, name := range columns {
+		result[i] = ColumnType{Name: name, Kind: KindUnknown}
+		if i >= len(types) || types[i] == nil {
+			continue
+		}
+
+		ct := types[i]
+		result[i].DatabaseType = ct.DatabaseTypeName()
+		result[i].Kind = kindOf(ct.DatabaseTypeName())
+		if nullable, ok := ct.Nullable(); ok {
+			result[i].Nullable = &nullable
+		}
+		// 不限长度的类型（如 TEXT）驱动报告为 math.MaxInt64
+		if length, ok := ct.Length(); ok && length != math.MaxInt64 {
+			result[i].Length = &length
+		}
+		if precision, scale, ok := ct.DecimalSize(); ok {
+			result[i].Precision = &precision
+			result[i].Scale = &scale
+		}
+	}
+	return result
+}
+
+// convertValue 将驱动返回的值转换为可直接编码为JSON的值：
+// 文本为字符串，整数为int64，定点数为字符串，时间为RFC3339，JSON列解码，二进制为base64
+func convertValue(kind ValueKind, value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return convertText(kind, v)
+	case string:
+		if kind == KindBinary {
+			return base64.StdEncoding.EncodeToString([]byte(v))
+		}
+		return convertText(kind, []byte(v))
+	case time.Time:
+		if kind == KindDate {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(time.RFC3339Nano)
+	case int64:
+		if kind == KindBoolean {
+			return v != 0
+		}
+		if kind == KindDecimal {
+			return strconv.FormatInt(v, 10)
+		}
+		return v
+	case float64:
+		if kind == KindDecimal {
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return v
+	case float32:
+		return convertValue(kind, float64(v))
+	default:
+		return v
+	}
+}
+
+// convertText 按列类别解析文本形式的值，解析失败时保留原字符串
+func convertText(kind ValueKind, data []byte) interface{} {
+	text := string(data)
+
+	switch kind {
+	case KindBinary:
+		return base64.StdEncoding.EncodeToString(data)
+	case KindInteger:
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return n
+		}
+		// 超出int64范围的无符号整数
+		return text
+	case KindFloat:
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f
+		}
+		return text
+	case KindBoolean:
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b
+		}
+		return text
+	case KindJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var decoded interface{}
+		if err := decoder.Decode(&decoded); err == nil {
+			return decoded
+		}
+		return text
+	default:
+		// 无法作为文本显示的内容按二进制处理
+		if !utf8.Valid(data) {
+			return base64.StdEncoding.EncodeToString(data)
+		}
+		return text
+	}
+}
@@ -0,0 +1,227 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"hello-mcp-server/config"
+)
+
+// ColumnInfo 字段信息
+type ColumnInfo struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Nullable bool    `json:"nullable"`
+	Key      string  `json:"key,omitempty"`
+	Default  *string `json:"default,omitempty"`
+	Extra    string  `json:"extra,omitempty"`
+	Comment  string  `json:"comment,omitempty"`
+}
+
+// IndexInfo 索引信息
+type IndexInfo struct {
+	Name string `json:"name"`
+	// 按索引中的顺序排列，表达式索引为表达式文本
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	Primary bool     `json:"primary,omitempty"`
+	// 索引类型，如 BTREE、HASH、gin
+	Type string `json:"type,omitempty"`
+}
+
+// ForeignKey 外键
+type ForeignKey struct {
+	// SQLite 的外键没有名称
+	Name              string   `json:"name,omitempty"`
+	Table             string   `json:"table"`
+	Columns           []string `json:"columns"`
+	ReferencedTable   string   `json:"referenced_table"`
+	ReferencedColumns []string `json:"referenced_columns"`
+	OnUpdate          string   `json:"on_update,omitempty"`
+	OnDelete          string   `json:"on_delete,omitempty"`
+}
+
+// CheckConstraint 检查约束
+type CheckConstraint struct {
+	Name       string `json:"name,omitempty"`
+	Expression string `json:"expression"`
+}
+
+// TableStats 表的行数和大小估算，来自数据库统计信息，数据库不提供的项为空
+type TableStats struct {
+	EstimatedRows *int64 `json:"estimated_rows,omitempty"`
+	DataBytes     *int64 `json:"data_bytes,omitempty"`
+	IndexBytes    *int64 `json:"index_bytes,omitempty"`
+}
+
+// SchemaColumn 库中的一个字段及其所在表，用于结构搜索
+type SchemaColumn struct {
+	Table        string `json:"table"`
+	TableComment string `json:"table_comment,omitempty"`
+	Column       string `json:"column"`
+	Type         string `json:"type"`
+	Comment      string `json:"comment,omitempty"`
+}
+
+// TableSchema 表结构
+type TableSchema struct {
+	Name    string       `json:"name"`
+	Comment string       `json:"comment,omitempty"`
+	Columns []ColumnInfo `json:"columns"`
+	Indexes []IndexInfo  `json:"indexes,omitempty"`
+	// 本表引用其他表的外键
+	ForeignKeys []ForeignKey `json:"foreign_keys,omitempty"`
+	// 其他表引用本表的外键
+	ReferencedBy []ForeignKey      `json:"referenced_by,omitempty"`
+	Checks       []CheckConstraint `json:"checks,omitempty"`
+	Stats        *TableStats       `json:"stats,omitempty"`
+}
+
+// Queryer *sql.DB 与 *sql.Tx 共有的查询方法
+type Queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Dialect 屏蔽不同数据库之间的SQL差异
+type Dialect interface {
+	// Name 方言名称
+	Name() string
+	// Open 按配置打开连接池（不发起连接），包括驱动特有的 TLS 设置
+	Open(cfg *config.DatabaseConfig) (*sql.DB, error)
+	// CurrentSchema 当前库（MySQL）或 schema（PostgreSQL）的名称，SQLite 为 main
+	CurrentSchema(q Queryer) (string, error)
+	// ListTables 列出当前库（或 schema）中的表和视图
+	ListTables(q Queryer) ([]string, error)
+	// DescribeTable 获取表的字段信息
+	DescribeTable(q Queryer, table string) ([]ColumnInfo, error)
+	// DescribeIndexes 获取表的索引，主键在前
+	DescribeIndexes(q Queryer, table string) ([]IndexInfo, error)
+	// ForeignKeys 获取表的外键，table 为空时返回当前库（或 schema）中的所有外键
+	ForeignKeys(q Queryer, table string) ([]ForeignKey, error)
+	// DescribeChecks 获取表的检查约束
+	DescribeChecks(q Queryer, table string) ([]CheckConstraint, error)
+	// DescribeTableStats 获取表注释和统计信息
+	DescribeTableStats(q Queryer, table string) (comment string, stats *TableStats, err error)
+	// DistinctEstimates 从统计信息估算字段的不同值个数，没有统计信息的字段不在结果中
+	DistinctEstimates(q Queryer, table string) (map[string]int64, error)
+	// SchemaColumns 一次获取当前库（或 schema）中所有表的字段，按表名和字段顺序排列
+	SchemaColumns(q Queryer) ([]SchemaColumn, error)
+	// SchemaVersion 表结构的指纹，表、字段或约束变化后改变，用于判断缓存是否失效
+	SchemaVersion(q Queryer) (string, error)
+	// QuoteIdentifier 引用标识符，带点号的名称按各部分分别引用
+	QuoteIdentifier(name string) string
+	// Placeholder 第 n 个（从1开始）绑定参数的占位符
+	Placeholder(n int) string
+	// ExplainPlan 获取并归一化执行计划，analyze 时真正执行语句
+	ExplainPlan(q Queryer, query string, analyze bool, args ...interface{}) (*QueryPlan, error)
+	// TextLength 表达式转换为文本后的字符数
+	TextLength(expr string) string
+	// RandomOrder 随机排序的表达式，用于随机抽样
+	RandomOrder() string
+	// LimitClause 生成 LIMIT/OFFSET 子句，offset 为0时省略
+	LimitClause(limit, offset int) string
+	// SupportsReadOnlyTx 驱动是否能真正开启只读事务
+	SupportsReadOnlyTx() bool
+	// SupportsTransactionalDDL DDL 能否随事务回滚
+	SupportsTransactionalDDL() bool
+}
+
+// NewDialect 根据驱动名称创建方言
+func NewDialect(driver string) (Dialect, error) {
+	switch driver {
+	case "mysql":
+		return &MySQLDialect{}, nil
+	case "postgres":
+		return &PostgresDialect{}, nil
+	case "sqlite":
+		return &SQLiteDialect{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %q", driver)
+	}
+}
+
+// quoteName 引用单个标识符，名称中的点号不作为分隔符（表名和查询结果的列名都可能包含点号）
+func quoteName(d Dialect, name string) string {
+	quote := d.QuoteIdentifier("x")[:1]
+	return quote + strings.ReplaceAll(name, quote, quote+quote) + quote
+}
+
+// quoteQualified 分别引用 schema 和表名，schema 为空时只引用表名
+func quoteQualified(d Dialect, schema, table string) string {
+	if schema == "" {
+		return quoteName(d, table)
+	}
+	return quoteName(d, schema) + "." + quoteName(d, table)
+}
+
+// limitClause 通用的 LIMIT/OFFSET 子句
+func limitClause(limit, offset int) string {
+	if offset > 0 {
+		return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+	}
+	return fmt.Sprintf(" LIMIT %d", limit)
+}
+
+// scanStrings 读取单列字符串结果
+func scanStrings(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+// nullInt64 可空整数转换为指针
+func nullInt64(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int64
+}
+
+// scanSchemaColumns 读取 表名、表注释、字段名、类型、字段注释 五列结果
+func scanSchemaColumns(rows *sql.Rows, err error) ([]SchemaColumn, error) {
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %v", err)
+	}
+	defer rows.Close()
+
+	var columns []SchemaColumn
+	for rows.Next() {
+		var col SchemaColumn
+		if err := rows.Scan(&col.Table, &col.TableComment, &col.Column, &col.Type, &col.Comment); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %v", err)
+		}
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
+// scanDistinctEstimates 读取 字段名、不同值个数 两列结果
+func scanDistinctEstimates(rows *sql.Rows, err error) (map[string]int64, error) {
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statistics: %v", err)
+	}
+	defer rows.Close()
+
+	estimates := make(map[string]int64)
+	for rows.Next() {
+		var column string
+		var distinct int64
+		if err := rows.Scan(&column, &distinct); err != nil {
+			return nil, fmt.Errorf("failed to scan statistics: %v", err)
+		}
+		estimates[column] = distinct
+	}
+	return estimates, rows.Err()
+}
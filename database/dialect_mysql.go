@@ -0,0 +1,525 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"hello-mcp-server/config"
+)
+
+// ER_UNKNOWN_TABLE，旧版本 information_schema 中没有的表
+const mysqlErrUnknownTable = 1109
+
+// MySQLDialect MySQL方言，基于 information_schema
+type MySQLDialect struct{}
+
+func (d *MySQLDialect) Name() string {
+	return "mysql"
+}
+
+// Open 打开连接池，TLS 证书通过 Connector 设置，无需全局注册
+func (d *MySQLDialect) Open(cfg *config.DatabaseConfig) (*sql.DB, error) {
+	mysqlConfig, err := mysql.ParseDSN(cfg.GetDSN())
+	if err != nil {
+		return nil, fmt.Errorf("invalid mysql connection parameters: %v", err)
+	}
+
+	// disable 时保留 params 中的 tls 参数
+	if mode := cfg.TLS.GetMode(); mode != config.TLSDisable {
+		serverName := cfg.TLS.ServerName
+		if serverName == "" {
+			serverName = cfg.Host
+		}
+		mysqlConfig.TLS, err = newTLSConfig(cfg.TLS, serverName)
+		if err != nil {
+			return nil, err
+		}
+		mysqlConfig.AllowFallbackToPlaintext = mode == config.TLSPrefer
+	} else if err := cfg.TLS.Validate(); err != nil {
+		return nil, err
+	}
+
+	connector, err := mysql.NewConnector(mysqlConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mysql connection parameters: %v", err)
+	}
+	return sql.OpenDB(connector), nil
+}
+
+func (d *MySQLDialect) CurrentSchema(q Queryer) (string, error) {
+	var name sql.NullString
+	if err := q.QueryRow("SELECT DATABASE()").Scan(&name); err != nil {
+		return "", fmt.Errorf("failed to query current database: %v", err)
+	}
+	return name.String, nil
+}
+
+func (d *MySQLDialect) ListTables(q Queryer) ([]string, error) {
+	rows, err := q.Query(`SELECT table_name FROM information_schema.tables
+		WHERE table_schema = DATABASE()
+		ORDER BY table_name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tables: %v", err)
+	}
+
+	tables, err := scanStrings(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan table name: %v", err)
+	}
+	return tables, nil
+}
+
+func (d *MySQLDialect) DescribeTable(q Queryer, table string) ([]ColumnInfo, error) {
+	rows, err := q.Query(`SELECT column_name, column_type, is_nullable, column_key, column_default, extra, column_comment
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = ?
+		ORDER BY ordinal_position`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %v", err)
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		var col ColumnInfo
+		var nullable string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&col.Name, &col.Type, &nullable, &col.Key, &defaultValue, &col.Extra, &col.Comment); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %v", err)
+		}
+		col.Nullable = nullable == "YES"
+		if defaultValue.Valid {
+			col.Default = &defaultValue.String
+		}
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
+func (d *MySQLDialect) DescribeIndexes(q Queryer, table string) ([]IndexInfo, error) {
+	// 函数索引（8.0.13+）的 column_name 为 NULL
+	rows, err := q.Query(`SELECT index_name, column_name, non_unique, index_type
+		FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = ?
+		ORDER BY index_name = 'PRIMARY' DESC, index_name, seq_in_index`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %v", err)
+	}
+	defer rows.Close()
+
+	var indexes []IndexInfo
+	for rows.Next() {
+		var name, indexType string
+		var column sql.NullString
+		var nonUnique int
+		if err := rows.Scan(&name, &column, &nonUnique, &indexType); err != nil {
+			return nil, fmt.Errorf("failed to scan index: %v", err)
+		}
+		if !column.Valid {
+			column.String = "(expression)"
+		}
+
+		if n := len(indexes); n > 0 && indexes[n-1].Name == name {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, column.String)
+			continue
+		}
+		indexes = append(indexes, IndexInfo{
+			Name:    name,
+			Columns: []string{column.String},
+			Unique:  nonUnique == 0,
+			Primary: name == "PRIMARY",
+			Type:    indexType,
+		})
+	}
+	return indexes, rows.Err()
+}
+
+func (d *MySQLDialect) ForeignKeys(q Queryer, table string) ([]ForeignKey, error) {
+	// 引用其他库的表时带上库名
+	rows, err := q.Query(`SELECT k.constraint_name, k.table_name, k.column_name,
+			IF(k.referenced_table_schema = DATABASE(), k.referenced_table_name,
+				CONCAT(k.referenced_table_schema, '.', k.referenced_table_name)),
+			k.referenced_column_name, r.update_rule, r.delete_rule
+		FROM information_schema.key_column_usage k
+		JOIN information_schema.referential_constraints r
+			ON r.constraint_schema = k.constraint_schema AND r.constraint_name = k.constraint_name
+			AND r.table_name = k.table_name
+		WHERE k.table_schema = DATABASE() AND k.referenced_table_name IS NOT NULL
+			AND (? = '' OR k.table_name = ?)
+		ORDER BY k.table_name, k.constraint_name, k.ordinal_position`, table, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query foreign keys: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []ForeignKey
+	for rows.Next() {
+		var name, tableName, column, refTable, refColumn, onUpdate, onDelete string
+		if err := rows.Scan(&name, &tableName, &column, &refTable, &refColumn, &onUpdate, &onDelete); err != nil {
+			return nil, fmt.Errorf("failed to scan foreign key: %v", err)
+		}
+
+		if n := len(keys); n > 0 && keys[n-1].Name == name && keys[n-1].Table == tableName {
+			keys[n-1].Columns = append(keys[n-1].Columns, column)
+			keys[n-1].ReferencedColumns = append(keys[n-1].ReferencedColumns, refColumn)
+			continue
+		}
+		keys = append(keys, ForeignKey{
+			Name:              name,
+			Table:             tableName,
+			Columns:           []string{column},
+			ReferencedTable:   refTable,
+			ReferencedColumns: []string{refColumn},
+			OnUpdate:          onUpdate,
+			OnDelete:          onDelete,
+		})
+	}
+	return keys, rows.Err()
+}
+
+// DescribeChecks 检查约束需要 MySQL 8.0.16+，更早的版本（没有 check_constraints 表）返回空
+func (d *MySQLDialect) DescribeChecks(q Queryer, table string) ([]CheckConstraint, error) {
+	rows, err := q.Query(`SELECT c.constraint_name, c.check_clause
+		FROM information_schema.table_constraints t
+		JOIN information_schema.check_constraints c
+			ON c.constraint_schema = t.constraint_schema AND c.constraint_name = t.constraint_name
+		WHERE t.table_schema = DATABASE() AND t.table_name = ? AND t.constraint_type = 'CHECK'
+		ORDER BY c.constraint_name`, table)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrUnknownTable {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query check constraints: %v", err)
+	}
+	defer rows.Close()
+
+	var checks []CheckConstraint
+	for rows.Next() {
+		var check CheckConstraint
+		if err := rows.Scan(&check.Name, &check.Expression); err != nil {
+			return nil, fmt.Errorf("failed to scan check constraint: %v", err)
+		}
+		checks = append(checks, check)
+	}
+	return checks, rows.Err()
+}
+
+// DescribeTableStats 行数为 InnoDB 的估算值，视图没有统计信息
+func (d *MySQLDialect) DescribeTableStats(q Queryer, table string) (string, *TableStats, error) {
+	var comment sql.NullString
+	var rowCount, dataBytes, indexBytes sql.NullInt64
+	err := q.QueryRow(`SELECT table_comment, table_rows, data_length, index_length
+		FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_name = ?`, table).Scan(&comment, &rowCount, &dataBytes, &indexBytes)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to query table status: %v", err)
+	}
+
+	// 视图的注释固定为 VIEW
+	if comment.String == "VIEW" && !rowCount.Valid {
+		comment.String = ""
+	}
+	stats := &TableStats{
+		EstimatedRows: nullInt64(rowCount),
+		DataBytes:     nullInt64(dataBytes),
+		IndexBytes:    nullInt64(indexBytes),
+	}
+	return comment.String, stats, nil
+}
+
+// DistinctEstimates 使用索引第一列的基数，同一字段出现在多个索引中时取最大值
+func (d *MySQLDialect) DistinctEstimates(q Queryer, table string) (map[string]int64, error) {
+	return scanDistinctEstimates(q.Query(`SELECT column_name, MAX(cardinality)
+		FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = ? AND seq_in_index = 1
+			AND column_name IS NOT NULL AND cardinality IS NOT NULL
+		GROUP BY column_name`, table))
+}
+
+func (d *MySQLDialect) SchemaColumns(q Queryer) ([]SchemaColumn, error) {
+	return scanSchemaColumns(q.Query(`SELECT c.table_name, COALESCE(t.table_comment, ''),
+			c.column_name, c.column_type, c.column_comment
+		FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = DATABASE()
+		ORDER BY c.table_name, c.ordinal_position`))
+}
+
+// SchemaVersion 基于字段和外键定义的校验和
+func (d *MySQLDialect) SchemaVersion(q Queryer) (string, error) {
+	var version string
+	err := q.QueryRow(`SELECT CONCAT_WS(':',
+			(SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE()),
+			(SELECT COALESCE(SUM(CRC32(CONCAT_WS(',', table_name, column_name, column_type, is_nullable, column_key))), 0)
+				FROM information_schema.columns WHERE table_schema = DATABASE()),
+			(SELECT COALESCE(SUM(CRC32(CONCAT_WS(',', constraint_name, table_name, referenced_table_name))), 0)
+				FROM information_schema.referential_constraints WHERE constraint_schema = DATABASE()))`).Scan(&version)
+	if err != nil {
+		return "", fmt.Errorf("failed to query schema version: %v", err)
+	}
+	return version, nil
+}
+
+func (d *MySQLDialect) QuoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = "`" + strings.ReplaceAll(part, "`", "``") + "`"
+	}
+	return strings.Join(parts, ".")
+}
+
+func (d *MySQLDialect) Placeholder(n int) string {
+	return "?"
+}
+
+// ExplainPlan 使用 EXPLAIN FORMAT=JSON 获取计划；ANALYZE（MySQL 8.0.18+）只提供树形文本
+func (d *MySQLDialect) ExplainPlan(q Queryer, query string, analyze bool, args ...interface{}) (*QueryPlan, error) {
+	if analyze {
+		raw, err := explainText(q, "EXPLAIN ANALYZE "+query, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		plan := &QueryPlan{
+			Dialect:  d.Name(),
+			Analyzed: true,
+			Nodes:    parseMySQLTree(raw),
+			Raw:      raw,
+		}
+		if len(plan.Nodes) > 0 {
+			plan.EstimatedRows = plan.Nodes[0].EstimatedRows
+			plan.TotalCost = plan.Nodes[0].Cost
+		}
+		return plan, nil
+	}
+
+	raw, err := explainText(q, "EXPLAIN FORMAT=JSON "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var output map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &output); err != nil {
+		return nil, fmt.Errorf("unexpected EXPLAIN output: %v", err)
+	}
+
+	plan := &QueryPlan{
+		Dialect: d.Name(),
+		Nodes:   mysqlPlanNodes(output),
+		Raw:     raw,
+	}
+	if block, ok := output["query_block"].(map[string]interface{}); ok {
+		if costInfo, ok := block["cost_info"].(map[string]interface{}); ok {
+			plan.TotalCost = jsonNumber(costInfo["query_cost"])
+		}
+	}
+
+	// 嵌套循环连接的结果行数取各表 rows_produced_per_join 的最大值
+	plan.Walk(func(node *PlanNode, depth int) {
+		if node.produced == nil {
+			return
+		}
+		if plan.EstimatedRows == nil || *node.produced > *plan.EstimatedRows {
+			plan.EstimatedRows = node.produced
+		}
+	})
+	return plan, nil
+}
+
+// MySQL JSON 计划中带排序/分组等附加操作的键
+var mysqlOperations = map[string]string{
+	"ordering_operation": "Sort",
+	"grouping_operation": "Group",
+	"duplicates_removal": "Distinct",
+	"windowing":          "Window",
+	"union_result":       "Union",
+	"buffer_result":      "Buffer",
+}
+
+// mysqlPlanNodes 递归查找 FORMAT=JSON 输出中的表访问和附加操作
+func mysqlPlanNodes(value interface{}) []*PlanNode {
+	var nodes []*PlanNode
+
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			nodes = append(nodes, mysqlPlanNodes(item)...)
+		}
+
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			child := v[key]
+			switch {
+			case key == "table":
+				if table, ok := child.(map[string]interface{}); ok {
+					nodes = append(nodes, mysqlTableNode(table))
+				}
+			case mysqlOperations[key] != "":
+				operation, ok := child.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				node := &PlanNode{
+					Operation: mysqlOperations[key],
+					Children:  mysqlPlanNodes(operation),
+				}
+				if operation["using_filesort"] == true || operation["using_temporary_table"] == true {
+					node.TempStructure = true
+					node.Detail = "using filesort or temporary table"
+				}
+				nodes = append(nodes, node)
+			default:
+				nodes = append(nodes, mysqlPlanNodes(child)...)
+			}
+		}
+	}
+	return nodes
+}
+
+// mysqlTableNode 转换单个表的访问信息
+func mysqlTableNode(table map[string]interface{}) *PlanNode {
+	accessType := jsonString(table["access_type"])
+	node := &PlanNode{
+		Operation:     "Table access (" + accessType + ")",
+		Table:         jsonString(table["table_name"]),
+		Index:         jsonString(table["key"]),
+		Condition:     jsonString(table["attached_condition"]),
+		EstimatedRows: jsonNumber(table["rows_examined_per_scan"]),
+		produced:      jsonNumber(table["rows_produced_per_join"]),
+	}
+	if costInfo, ok := table["cost_info"].(map[string]interface{}); ok {
+		node.Cost = jsonNumber(costInfo["prefix_cost"])
+	}
+	if keys, ok := table["possible_keys"].([]interface{}); ok {
+		for _, key := range keys {
+			node.PossibleIndexes = append(node.PossibleIndexes, jsonString(key))
+		}
+	}
+
+	// ALL 为全表扫描，index 为全索引扫描
+	node.FullScan = accessType == "ALL" || accessType == "index"
+	node.MissingIndex = accessType == "ALL" && (node.Condition != "" || len(node.PossibleIndexes) > 0)
+
+	if table["using_temporary_table"] == true || table["using_filesort"] == true {
+		node.TempStructure = true
+	}
+
+	// 物化子查询等嵌套计划
+	rest := make(map[string]interface{})
+	for key, value := range table {
+		if key == "materialized_from_subquery" || key == "attached_subqueries" {
+			rest[key] = value
+		}
+	}
+	node.Children = mysqlPlanNodes(rest)
+	return node
+}
+
+var (
+	mysqlTreeEstimate = regexp.MustCompile(`\(cost=([0-9.e+]+)\s+rows=([0-9.e+]+)\)`)
+	mysqlTreeActual   = regexp.MustCompile(`\(actual time=[0-9.]+\.\.[0-9.]+ rows=([0-9.e+]+) loops=([0-9]+)\)`)
+	mysqlTreeTable    = regexp.MustCompile(` on (\S+)`)
+	mysqlTreeIndex    = regexp.MustCompile(` using (\S+)`)
+)
+
+// parseMySQLTree 解析 EXPLAIN ANALYZE 的树形文本，每层缩进4个空格
+func parseMySQLTree(text string) []*PlanNode {
+	var roots []*PlanNode
+	var stack []*PlanNode
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if !strings.HasPrefix(trimmed, "-> ") {
+			continue
+		}
+		depth := (len(line) - len(trimmed)) / 4
+		description := strings.TrimPrefix(trimmed, "-> ")
+
+		operation := description
+		if i := strings.Index(operation, "  ("); i >= 0 {
+			operation = operation[:i]
+		}
+		node := &PlanNode{
+			Operation: operation,
+			Detail:    description,
+		}
+
+		if m := mysqlTreeEstimate.FindStringSubmatch(description); m != nil {
+			node.Cost = jsonNumber(m[1])
+			node.EstimatedRows = jsonNumber(m[2])
+		}
+		if m := mysqlTreeActual.FindStringSubmatch(description); m != nil {
+			node.Loops = jsonNumber(m[2])
+			node.ActualRows = multiply(jsonNumber(m[1]), node.Loops)
+		}
+		if m := mysqlTreeTable.FindStringSubmatch(operation); m != nil {
+			node.Table = m[1]
+		}
+		if m := mysqlTreeIndex.FindStringSubmatch(operation); m != nil {
+			node.Index = m[1]
+		}
+
+		switch {
+		case strings.HasPrefix(operation, "Table scan"):
+			node.FullScan = true
+		case strings.HasPrefix(operation, "Index scan"):
+			node.FullScan = true
+		case strings.HasPrefix(operation, "Sort"), strings.HasPrefix(operation, "Temporary table"),
+			strings.HasPrefix(operation, "Materialize"):
+			node.TempStructure = true
+		}
+
+		if depth > len(stack) {
+			depth = len(stack)
+		}
+		stack = stack[:depth]
+		if depth == 0 {
+			roots = append(roots, node)
+		} else {
+			parent := stack[depth-1]
+			parent.Children = append(parent.Children, node)
+			// 过滤节点下的全表扫描即按条件过滤却未使用索引
+			if node.FullScan && strings.HasPrefix(parent.Operation, "Filter") {
+				node.MissingIndex = true
+				node.Condition = strings.TrimPrefix(parent.Operation, "Filter: ")
+			}
+		}
+		stack = append(stack, node)
+	}
+	return roots
+}
+
+func (d *MySQLDialect) TextLength(expr string) string {
+	return "CHAR_LENGTH(CAST(" + expr + " AS CHAR))"
+}
+
+func (d *MySQLDialect) RandomOrder() string {
+	return "RAND()"
+}
+
+func (d *MySQLDialect) LimitClause(limit, offset int) string {
+	return limitClause(limit, offset)
+}
+
+func (d *MySQLDialect) SupportsReadOnlyTx() bool {
+	return true
+}
+
+// MySQL 的 DDL 会隐式提交事务
+func (d *MySQLDialect) SupportsTransactionalDDL() bool {
+	return false
+}
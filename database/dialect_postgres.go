@@ -0,0 +1,373 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"hello-mcp-server/config"
+)
+
+// PostgresDialect PostgreSQL方言，基于 pg_catalog，只查看 current_schema()
+type PostgresDialect struct{}
+
+func (d *PostgresDialect) Name() string {
+	return "postgres"
+}
+
+// Open 打开连接池，TLS 设置通过 sslmode、sslrootcert、sslcert、sslkey 参数交给驱动
+func (d *PostgresDialect) Open(cfg *config.DatabaseConfig) (*sql.DB, error) {
+	if err := cfg.TLS.Validate(); err != nil {
+		return nil, err
+	}
+	// 驱动不支持回退到明文连接，也只按 host 校验服务器名称
+	if cfg.TLS.GetMode() == config.TLSPrefer {
+		return nil, fmt.Errorf("tls mode prefer is not supported by postgres, use require or disable")
+	}
+	if cfg.TLS.ServerName != "" {
+		return nil, fmt.Errorf("tls server_name is not supported by postgres, the certificate is verified against host")
+	}
+
+	db, err := sql.Open("postgres", cfg.GetDSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %v", err)
+	}
+	return db, nil
+}
+
+func (d *PostgresDialect) CurrentSchema(q Queryer) (string, error) {
+	var name sql.NullString
+	if err := q.QueryRow("SELECT current_schema()").Scan(&name); err != nil {
+		return "", fmt.Errorf("failed to query current schema: %v", err)
+	}
+	return name.String, nil
+}
+
+func (d *PostgresDialect) ListTables(q Queryer) ([]string, error) {
+	rows, err := q.Query(`SELECT c.relname FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema() AND c.relkind IN ('r', 'p', 'v', 'm', 'f')
+		ORDER BY c.relname`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tables: %v", err)
+	}
+
+	tables, err := scanStrings(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan table name: %v", err)
+	}
+	return tables, nil
+}
+
+func (d *PostgresDialect) DescribeTable(q Queryer, table string) ([]ColumnInfo, error) {
+	rows, err := q.Query(`SELECT a.attname,
+			pg_catalog.format_type(a.atttypid, a.atttypmod),
+			NOT a.attnotnull,
+			COALESCE((SELECT CASE con.contype WHEN 'p' THEN 'PRI' WHEN 'u' THEN 'UNI' END
+				FROM pg_catalog.pg_constraint con
+				WHERE con.conrelid = c.oid AND con.contype IN ('p', 'u') AND a.attnum = ANY(con.conkey)
+				ORDER BY con.contype LIMIT 1), ''),
+			pg_catalog.pg_get_expr(ad.adbin, ad.adrelid),
+			CASE WHEN a.attidentity <> '' THEN 'identity' ELSE '' END,
+			COALESCE(pg_catalog.col_description(c.oid, a.attnum), '')
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_catalog.pg_attrdef ad ON ad.adrelid = a.attrelid AND ad.adnum = a.attnum
+		WHERE n.nspname = current_schema() AND c.relname = $1 AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %v", err)
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		var col ColumnInfo
+		var defaultValue sql.NullString
+		if err := rows.Scan(&col.Name, &col.Type, &col.Nullable, &col.Key, &defaultValue, &col.Extra, &col.Comment); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %v", err)
+		}
+		if defaultValue.Valid {
+			col.Default = &defaultValue.String
+		}
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
+func (d *PostgresDialect) DescribeIndexes(q Queryer, table string) ([]IndexInfo, error) {
+	// pg_get_indexdef 按位置取出每一列，表达式列返回表达式文本；INCLUDE 列不计入
+	rows, err := q.Query(`SELECT ic.relname, i.indisunique, i.indisprimary, am.amname,
+			ARRAY(SELECT pg_catalog.pg_get_indexdef(i.indexrelid, k, true)
+				FROM generate_series(1, i.indnkeyatts) AS k ORDER BY k)
+		FROM pg_catalog.pg_index i
+		JOIN pg_catalog.pg_class c ON c.oid = i.indrelid
+		JOIN pg_catalog.pg_class ic ON ic.oid = i.indexrelid
+		JOIN pg_catalog.pg_am am ON am.oid = ic.relam
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema() AND c.relname = $1
+		ORDER BY i.indisprimary DESC, ic.relname`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %v", err)
+	}
+	defer rows.Close()
+
+	var indexes []IndexInfo
+	for rows.Next() {
+		var index IndexInfo
+		if err := rows.Scan(&index.Name, &index.Unique, &index.Primary, &index.Type, pq.Array(&index.Columns)); err != nil {
+			return nil, fmt.Errorf("failed to scan index: %v", err)
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, rows.Err()
+}
+
+// postgresFKActions pg_constraint 中 confupdtype/confdeltype 的取值
+var postgresFKActions = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
+}
+
+func (d *PostgresDialect) ForeignKeys(q Queryer, table string) ([]ForeignKey, error) {
+	// 引用其他 schema 的表时带上 schema 名
+	rows, err := q.Query(`SELECT con.conname, c.relname,
+			ARRAY(SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord)
+				JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+				ORDER BY k.ord),
+			CASE WHEN rn.nspname = current_schema() THEN rc.relname ELSE rn.nspname || '.' || rc.relname END,
+			ARRAY(SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY AS k(attnum, ord)
+				JOIN pg_catalog.pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+				ORDER BY k.ord),
+			con.confupdtype, con.confdeltype
+		FROM pg_catalog.pg_constraint con
+		JOIN pg_catalog.pg_class c ON c.oid = con.conrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_class rc ON rc.oid = con.confrelid
+		JOIN pg_catalog.pg_namespace rn ON rn.oid = rc.relnamespace
+		WHERE n.nspname = current_schema() AND con.contype = 'f' AND ($1 = '' OR c.relname = $1)
+		ORDER BY c.relname, con.conname`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query foreign keys: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []ForeignKey
+	for rows.Next() {
+		var key ForeignKey
+		var onUpdate, onDelete string
+		if err := rows.Scan(&key.Name, &key.Table, pq.Array(&key.Columns), &key.ReferencedTable,
+			pq.Array(&key.ReferencedColumns), &onUpdate, &onDelete); err != nil {
+			return nil, fmt.Errorf("failed to scan foreign key: %v", err)
+		}
+		key.OnUpdate = postgresFKActions[onUpdate]
+		key.OnDelete = postgresFKActions[onDelete]
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (d *PostgresDialect) DescribeChecks(q Queryer, table string) ([]CheckConstraint, error) {
+	rows, err := q.Query(`SELECT con.conname, pg_catalog.pg_get_constraintdef(con.oid, true)
+		FROM pg_catalog.pg_constraint con
+		JOIN pg_catalog.pg_class c ON c.oid = con.conrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema() AND c.relname = $1 AND con.contype = 'c'
+		ORDER BY con.conname`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query check constraints: %v", err)
+	}
+	defer rows.Close()
+
+	var checks []CheckConstraint
+	for rows.Next() {
+		var check CheckConstraint
+		if err := rows.Scan(&check.Name, &check.Expression); err != nil {
+			return nil, fmt.Errorf("failed to scan check constraint: %v", err)
+		}
+		// 定义形如 CHECK ((price > 0))，只保留表达式
+		check.Expression = strings.TrimPrefix(check.Expression, "CHECK ")
+		checks = append(checks, check)
+	}
+	return checks, rows.Err()
+}
+
+// DescribeTableStats 行数来自 pg_class.reltuples，从未 ANALYZE 的表（-1）没有估算值
+func (d *PostgresDialect) DescribeTableStats(q Queryer, table string) (string, *TableStats, error) {
+	var comment sql.NullString
+	var rowCount, dataBytes, indexBytes sql.NullInt64
+	err := q.QueryRow(`SELECT pg_catalog.obj_description(c.oid, 'pg_class'),
+			CASE WHEN c.reltuples >= 0 THEN c.reltuples::bigint END,
+			CASE WHEN c.relkind IN ('r', 'm', 'p') THEN pg_catalog.pg_table_size(c.oid) END,
+			CASE WHEN c.relkind IN ('r', 'm', 'p') THEN pg_catalog.pg_indexes_size(c.oid) END
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema() AND c.relname = $1`, table).Scan(&comment, &rowCount, &dataBytes, &indexBytes)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to query table status: %v", err)
+	}
+
+	stats := &TableStats{
+		EstimatedRows: nullInt64(rowCount),
+		DataBytes:     nullInt64(dataBytes),
+		IndexBytes:    nullInt64(indexBytes),
+	}
+	return comment.String, stats, nil
+}
+
+// DistinctEstimates 使用 pg_stats.n_distinct，负值表示占行数的比例，按 reltuples 换算
+func (d *PostgresDialect) DistinctEstimates(q Queryer, table string) (map[string]int64, error) {
+	return scanDistinctEstimates(q.Query(`SELECT s.attname,
+			CASE WHEN s.n_distinct >= 0 THEN s.n_distinct ELSE -s.n_distinct * c.reltuples END::bigint
+		FROM pg_catalog.pg_stats s
+		JOIN pg_catalog.pg_namespace n ON n.nspname = s.schemaname
+		JOIN pg_catalog.pg_class c ON c.relnamespace = n.oid AND c.relname = s.tablename
+		WHERE s.schemaname = current_schema() AND s.tablename = $1
+			AND (s.n_distinct >= 0 OR c.reltuples >= 0)`, table))
+}
+
+func (d *PostgresDialect) SchemaColumns(q Queryer) ([]SchemaColumn, error) {
+	return scanSchemaColumns(q.Query(`SELECT c.relname, COALESCE(pg_catalog.obj_description(c.oid, 'pg_class'), ''),
+			a.attname, pg_catalog.format_type(a.atttypid, a.atttypmod),
+			COALESCE(pg_catalog.col_description(c.oid, a.attnum), '')
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema() AND c.relkind IN ('r', 'p', 'v', 'm', 'f')
+			AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY c.relname, a.attnum`))
+}
+
+// SchemaVersion 基于 current_schema() 中字段和约束定义的摘要
+func (d *PostgresDialect) SchemaVersion(q Queryer) (string, error) {
+	var version string
+	err := q.QueryRow(`SELECT md5(COALESCE(string_agg(c.relname || '.' || a.attname || ':' || a.atttypid || ':' || a.atttypmod || ':' || a.attnotnull,
+				',' ORDER BY c.relname, a.attnum), ''))
+			|| md5(COALESCE((SELECT string_agg(con.conname || ':' || con.contype || ':' || con.conrelid, ',' ORDER BY con.oid)
+				FROM pg_catalog.pg_constraint con
+				JOIN pg_catalog.pg_namespace cn ON cn.oid = con.connamespace
+				WHERE cn.nspname = current_schema()), ''))
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema() AND c.relkind IN ('r', 'p', 'v', 'm', 'f')
+			AND a.attnum > 0 AND NOT a.attisdropped`).Scan(&version)
+	if err != nil {
+		return "", fmt.Errorf("failed to query schema version: %v", err)
+	}
+	return version, nil
+}
+
+func (d *PostgresDialect) QuoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+func (d *PostgresDialect) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+// ExplainPlan 使用 EXPLAIN (FORMAT JSON) 获取计划树
+func (d *PostgresDialect) ExplainPlan(q Queryer, query string, analyze bool, args ...interface{}) (*QueryPlan, error) {
+	options := "FORMAT JSON"
+	if analyze {
+		options = "ANALYZE, FORMAT JSON"
+	}
+
+	raw, err := explainText(q, "EXPLAIN ("+options+") "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var output []map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &output); err != nil || len(output) == 0 {
+		return nil, fmt.Errorf("unexpected EXPLAIN output: %v", err)
+	}
+
+	plan := &QueryPlan{
+		Dialect:  d.Name(),
+		Analyzed: analyze,
+		Raw:      raw,
+	}
+	if root, ok := output[0]["Plan"].(map[string]interface{}); ok {
+		node := postgresPlanNode(root)
+		plan.Nodes = []*PlanNode{node}
+		plan.EstimatedRows = node.EstimatedRows
+		plan.TotalCost = node.Cost
+	}
+	plan.ExecutionTimeMs = jsonNumber(output[0]["Execution Time"])
+	return plan, nil
+}
+
+// postgresPlanNode 转换 EXPLAIN JSON 中的 Plan 节点
+func postgresPlanNode(plan map[string]interface{}) *PlanNode {
+	node := &PlanNode{
+		Operation:     jsonString(plan["Node Type"]),
+		Table:         jsonString(plan["Relation Name"]),
+		Index:         jsonString(plan["Index Name"]),
+		EstimatedRows: jsonNumber(plan["Plan Rows"]),
+		Cost:          jsonNumber(plan["Total Cost"]),
+		Loops:         jsonNumber(plan["Actual Loops"]),
+	}
+	node.ActualRows = multiply(jsonNumber(plan["Actual Rows"]), node.Loops)
+
+	for _, key := range []string{"Index Cond", "Hash Cond", "Merge Cond", "Join Filter", "Filter"} {
+		if condition := jsonString(plan[key]); condition != "" {
+			node.Condition = condition
+			break
+		}
+	}
+
+	switch node.Operation {
+	case "Seq Scan":
+		node.FullScan = true
+		// 带过滤条件的顺序扫描通常意味着缺少合适的索引
+		node.MissingIndex = node.Condition != ""
+	case "Sort":
+		node.TempStructure = true
+		if method := jsonString(plan["Sort Method"]); method != "" {
+			node.Detail = method
+		}
+	case "Materialize":
+		node.TempStructure = true
+	}
+
+	if children, ok := plan["Plans"].([]interface{}); ok {
+		for _, child := range children {
+			if childPlan, ok := child.(map[string]interface{}); ok {
+				node.Children = append(node.Children, postgresPlanNode(childPlan))
+			}
+		}
+	}
+	return node
+}
+
+func (d *PostgresDialect) TextLength(expr string) string {
+	return "length(CAST(" + expr + " AS text))"
+}
+
+func (d *PostgresDialect) RandomOrder() string {
+	return "random()"
+}
+
+func (d *PostgresDialect) LimitClause(limit, offset int) string {
+	return limitClause(limit, offset)
+}
+
+func (d *PostgresDialect) SupportsReadOnlyTx() bool {
+	return true
+}
+
+func (d *PostgresDialect) SupportsTransactionalDDL() bool {
+	return true
+}
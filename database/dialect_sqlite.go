@@ -0,0 +1,534 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	_ "modernc.org/sqlite"
+
+	"hello-mcp-server/config"
+)
+
+// SQLiteDialect SQLite方言，基于 sqlite_master 和 PRAGMA
+type SQLiteDialect struct{}
+
+func (d *SQLiteDialect) Name() string {
+	return "sqlite"
+}
+
+// Open 打开数据库文件，TLS 和 socket 配置不适用
+func (d *SQLiteDialect) Open(cfg *config.DatabaseConfig) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", cfg.GetDSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %v", err)
+	}
+	return db, nil
+}
+
+// CurrentSchema 主库的名称固定为 main
+func (d *SQLiteDialect) CurrentSchema(q Queryer) (string, error) {
+	return "main", nil
+}
+
+func (d *SQLiteDialect) ListTables(q Queryer) ([]string, error) {
+	rows, err := q.Query(`SELECT name FROM sqlite_master
+		WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'
+		ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tables: %v", err)
+	}
+
+	tables, err := scanStrings(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan table name: %v", err)
+	}
+	return tables, nil
+}
+
+func (d *SQLiteDialect) DescribeTable(q Queryer, table string) ([]ColumnInfo, error) {
+	// 单列唯一索引（含 UNIQUE 约束）的列标记为 UNI
+	rows, err := q.Query(`SELECT t.name, t.type, t."notnull", t.dflt_value, t.pk,
+			EXISTS (SELECT 1 FROM pragma_index_list(?) il
+				WHERE il."unique" = 1 AND il.origin <> 'pk'
+					AND (SELECT COUNT(*) FROM pragma_index_info(il.name)) = 1
+					AND (SELECT ii.name FROM pragma_index_info(il.name) ii) = t.name)
+		FROM pragma_table_info(?) t
+		ORDER BY t.cid`, table, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %v", err)
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		var col ColumnInfo
+		var notNull, pk int
+		var unique bool
+		var defaultValue sql.NullString
+		if err := rows.Scan(&col.Name, &col.Type, &notNull, &defaultValue, &pk, &unique); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %v", err)
+		}
+		col.Nullable = notNull == 0 && pk == 0
+		switch {
+		case pk > 0:
+			col.Key = "PRI"
+		case unique:
+			col.Key = "UNI"
+		}
+		if defaultValue.Valid {
+			col.Default = &defaultValue.String
+		}
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
+// DescribeIndexes 基于 pragma_index_list 和 pragma_index_info；
+// INTEGER PRIMARY KEY 是 rowid 的别名，没有对应的索引，按 table_info 生成名为 PRIMARY 的主键
+func (d *SQLiteDialect) DescribeIndexes(q Queryer, table string) ([]IndexInfo, error) {
+	rows, err := q.Query(`SELECT il.name, il."unique", il.origin, ii.name
+		FROM pragma_index_list(?) il
+		JOIN pragma_index_info(il.name) ii
+		ORDER BY il.origin = 'pk' DESC, il.name, ii.seqno`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %v", err)
+	}
+	defer rows.Close()
+
+	var indexes []IndexInfo
+	for rows.Next() {
+		var name, origin string
+		var unique int
+		var column sql.NullString
+		if err := rows.Scan(&name, &unique, &origin, &column); err != nil {
+			return nil, fmt.Errorf("failed to scan index: %v", err)
+		}
+		if !column.Valid {
+			column.String = "(expression)"
+		}
+
+		if n := len(indexes); n > 0 && indexes[n-1].Name == name {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, column.String)
+			continue
+		}
+		indexes = append(indexes, IndexInfo{
+			Name:    name,
+			Columns: []string{column.String},
+			Unique:  unique == 1,
+			Primary: origin == "pk",
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(indexes) > 0 && indexes[0].Primary {
+		return indexes, nil
+	}
+	primary, err := d.primaryKey(q, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(primary) > 0 {
+		rowid := IndexInfo{Name: "PRIMARY", Columns: primary, Unique: true, Primary: true, Type: "rowid"}
+		indexes = append([]IndexInfo{rowid}, indexes...)
+	}
+	return indexes, nil
+}
+
+// primaryKey 按主键中的顺序返回主键列
+func (d *SQLiteDialect) primaryKey(q Queryer, table string) ([]string, error) {
+	rows, err := q.Query(`SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query primary key: %v", err)
+	}
+	columns, err := scanStrings(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan primary key: %v", err)
+	}
+	return columns, nil
+}
+
+// ForeignKeys 基于 pragma_foreign_key_list，SQLite 的外键没有名称；
+// 省略被引用列时引用的是被引用表的主键
+func (d *SQLiteDialect) ForeignKeys(q Queryer, table string) ([]ForeignKey, error) {
+	rows, err := q.Query(`SELECT m.name, f.id, f."table", f."from", f."to", f.on_update, f.on_delete
+		FROM sqlite_master m
+		JOIN pragma_foreign_key_list(m.name) f
+		WHERE m.type = 'table' AND (? = '' OR m.name = ?)
+		ORDER BY m.name, f.id, f.seq`, table, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query foreign keys: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []ForeignKey
+	var implicit []int
+	lastID := -1
+	for rows.Next() {
+		var tableName, refTable, column, onUpdate, onDelete string
+		var id int
+		var refColumn sql.NullString
+		if err := rows.Scan(&tableName, &id, &refTable, &column, &refColumn, &onUpdate, &onDelete); err != nil {
+			return nil, fmt.Errorf("failed to scan foreign key: %v", err)
+		}
+
+		if n := len(keys); n > 0 && keys[n-1].Table == tableName && id == lastID {
+			keys[n-1].Columns = append(keys[n-1].Columns, column)
+			if refColumn.Valid {
+				keys[n-1].ReferencedColumns = append(keys[n-1].ReferencedColumns, refColumn.String)
+			}
+			continue
+		}
+		key := ForeignKey{
+			Table:           tableName,
+			Columns:         []string{column},
+			ReferencedTable: refTable,
+			OnUpdate:        onUpdate,
+			OnDelete:        onDelete,
+		}
+		if refColumn.Valid {
+			key.ReferencedColumns = []string{refColumn.String}
+		} else {
+			implicit = append(implicit, len(keys))
+		}
+		keys = append(keys, key)
+		lastID = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// SQLite 只有一个连接，读完结果后再查询被引用表的主键
+	for _, i := range implicit {
+		primary, err := d.primaryKey(q, keys[i].ReferencedTable)
+		if err != nil {
+			return nil, err
+		}
+		keys[i].ReferencedColumns = primary
+	}
+	return keys, nil
+}
+
+// DescribeChecks SQLite 不提供约束的元数据，从建表语句中解析 CHECK 子句
+func (d *SQLiteDialect) DescribeChecks(q Queryer, table string) ([]CheckConstraint, error) {
+	var createSQL sql.NullString
+	err := q.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&createSQL)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table definition: %v", err)
+	}
+	return parseCheckConstraints(createSQL.String), nil
+}
+
+// DescribeTableStats SQLite 没有表注释，也不提供单表大小（驱动未编译 dbstat）；
+// 行数来自 ANALYZE 生成的 sqlite_stat1，未执行过 ANALYZE 时为空
+func (d *SQLiteDialect) DescribeTableStats(q Queryer, table string) (string, *TableStats, error) {
+	var hasStat int
+	if err := q.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'sqlite_stat1'`).Scan(&hasStat); err != nil {
+		return "", nil, fmt.Errorf("failed to query table status: %v", err)
+	}
+	if hasStat == 0 {
+		return "", nil, nil
+	}
+
+	// stat 的第一个数字为表的行数，表级统计（idx 为 NULL）优先
+	var stat string
+	err := q.QueryRow(`SELECT stat FROM sqlite_stat1 WHERE tbl = ? ORDER BY idx IS NULL DESC LIMIT 1`, table).Scan(&stat)
+	if err != nil && err != sql.ErrNoRows {
+		return "", nil, fmt.Errorf("failed to query table status: %v", err)
+	}
+	fields := strings.Fields(stat)
+	if len(fields) == 0 {
+		return "", nil, nil
+	}
+	rowCount, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return "", nil, nil
+	}
+	return "", &TableStats{EstimatedRows: &rowCount}, nil
+}
+
+// DistinctEstimates 使用 sqlite_stat1 中索引第一列的统计：stat 的前两个数字为行数和
+// 每个值平均对应的行数，两者相除即不同值个数。未执行过 ANALYZE 时为空
+func (d *SQLiteDialect) DistinctEstimates(q Queryer, table string) (map[string]int64, error) {
+	var hasStat int
+	if err := q.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'sqlite_stat1'`).Scan(&hasStat); err != nil {
+		return nil, fmt.Errorf("failed to query statistics: %v", err)
+	}
+	if hasStat == 0 {
+		return nil, nil
+	}
+
+	rows, err := q.Query(`SELECT i.name, s.stat
+		FROM sqlite_stat1 s
+		JOIN pragma_index_info(s.idx) i
+		WHERE s.tbl = ? AND i.seqno = 0 AND i.name IS NOT NULL`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statistics: %v", err)
+	}
+	defer rows.Close()
+
+	estimates := make(map[string]int64)
+	for rows.Next() {
+		var column, stat string
+		if err := rows.Scan(&column, &stat); err != nil {
+			return nil, fmt.Errorf("failed to scan statistics: %v", err)
+		}
+		fields := strings.Fields(stat)
+		if len(fields) < 2 {
+			continue
+		}
+		rowCount, err1 := strconv.ParseInt(fields[0], 10, 64)
+		perValue, err2 := strconv.ParseInt(fields[1], 10, 64)
+		if err1 != nil || err2 != nil || perValue <= 0 {
+			continue
+		}
+		if distinct := (rowCount + perValue - 1) / perValue; distinct > estimates[column] {
+			estimates[column] = distinct
+		}
+	}
+	return estimates, rows.Err()
+}
+
+// SchemaColumns SQLite 没有注释，注释列为空
+func (d *SQLiteDialect) SchemaColumns(q Queryer) ([]SchemaColumn, error) {
+	return scanSchemaColumns(q.Query(`SELECT m.name, '', c.name, c.type, ''
+		FROM sqlite_master m
+		JOIN pragma_table_info(m.name) c
+		WHERE m.type IN ('table', 'view') AND m.name NOT LIKE 'sqlite_%'
+		ORDER BY m.name, c.cid`))
+}
+
+// SchemaVersion 使用 PRAGMA schema_version，每次修改表结构时递增
+func (d *SQLiteDialect) SchemaVersion(q Queryer) (string, error) {
+	var version int64
+	if err := q.QueryRow(`PRAGMA schema_version`).Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to query schema version: %v", err)
+	}
+	return strconv.FormatInt(version, 10), nil
+}
+
+// parseCheckConstraints 从建表语句中取出 CHECK (...) 子句及其前面 CONSTRAINT 指定的名称
+func parseCheckConstraints(createSQL string) []CheckConstraint {
+	var checks []CheckConstraint
+	var name string
+	expectName := false
+
+	i := 0
+	for i < len(createSQL) {
+		c := createSQL[i]
+		switch {
+		case c == '-' && i+1 < len(createSQL) && createSQL[i+1] == '-':
+			for i < len(createSQL) && createSQL[i] != '\n' {
+				i++
+			}
+
+		case c == '/' && i+1 < len(createSQL) && createSQL[i+1] == '*':
+			end := strings.Index(createSQL[i+2:], "*/")
+			if end < 0 {
+				i = len(createSQL)
+			} else {
+				i += end + 4
+			}
+
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			end := skipQuotedSQLite(createSQL, i)
+			if expectName {
+				name = strings.Trim(createSQL[i:end], "'\"`[]")
+				expectName = false
+			}
+			i = end
+
+		case isWordStart(c):
+			end := i
+			for end < len(createSQL) && isWordPart(createSQL[end]) {
+				end++
+			}
+			word := strings.ToUpper(createSQL[i:end])
+			i = end
+
+			switch {
+			case expectName:
+				name = createSQL[i-len(word) : i]
+				expectName = false
+			case word == "CONSTRAINT":
+				expectName = true
+			case word == "CHECK":
+				start := i
+				for start < len(createSQL) && strings.IndexByte(" \t\r\n", createSQL[start]) >= 0 {
+					start++
+				}
+				if start < len(createSQL) && createSQL[start] == '(' {
+					end := matchParen(createSQL, start)
+					checks = append(checks, CheckConstraint{Name: name, Expression: createSQL[start:end]})
+					i = end
+				}
+				name = ""
+			default:
+				// CONSTRAINT 名称只属于紧随其后的约束
+				name = ""
+			}
+
+		default:
+			i++
+		}
+	}
+	return checks
+}
+
+// skipQuotedSQLite 返回引号内容结束后的位置，SQLite 还支持 [name] 形式的标识符
+func skipQuotedSQLite(sql string, start int) int {
+	if sql[start] == '[' {
+		if end := strings.IndexByte(sql[start:], ']'); end >= 0 {
+			return start + end + 1
+		}
+		return len(sql)
+	}
+	return scanQuoted(sql, start, sql[start], false)
+}
+
+// matchParen 返回与 start 处左括号匹配的右括号之后的位置，跳过引号中的括号
+func matchParen(sql string, start int) int {
+	depth := 0
+	i := start
+	for i < len(sql) {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			i = skipQuotedSQLite(sql, i)
+			continue
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+		i++
+	}
+	return len(sql)
+}
+
+func (d *SQLiteDialect) QuoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+func (d *SQLiteDialect) Placeholder(n int) string {
+	return "?"
+}
+
+// ExplainPlan 使用 EXPLAIN QUERY PLAN 获取计划树，SQLite 不提供行数估算，也不支持 ANALYZE
+func (d *SQLiteDialect) ExplainPlan(q Queryer, query string, analyze bool, args ...interface{}) (*QueryPlan, error) {
+	if analyze {
+		return nil, fmt.Errorf("EXPLAIN ANALYZE is not supported by sqlite")
+	}
+
+	rows, err := q.Query("EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain query: %v", err)
+	}
+	defer rows.Close()
+
+	plan := &QueryPlan{Dialect: d.Name()}
+	nodes := make(map[int64]*PlanNode)
+	var raw []string
+
+	for rows.Next() {
+		var id, parent, notUsed int64
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			return nil, fmt.Errorf("failed to scan plan: %v", err)
+		}
+		raw = append(raw, fmt.Sprintf("%d|%d|%s", id, parent, detail))
+
+		node := sqlitePlanNode(detail)
+		nodes[id] = node
+		if parentNode, ok := nodes[parent]; ok {
+			parentNode.Children = append(parentNode.Children, node)
+		} else {
+			plan.Nodes = append(plan.Nodes, node)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read plan: %v", err)
+	}
+
+	plan.Raw = strings.Join(raw, "\n")
+	return plan, nil
+}
+
+// sqlitePlanNode 解析 EXPLAIN QUERY PLAN 的一行描述，
+// 如 "SCAN users"、"SEARCH users USING INDEX idx_email (email=?)"
+func sqlitePlanNode(detail string) *PlanNode {
+	node := &PlanNode{
+		Operation: detail,
+		Detail:    detail,
+	}
+
+	words := strings.Fields(detail)
+	if len(words) < 2 {
+		return node
+	}
+
+	switch words[0] {
+	case "SCAN", "SEARCH":
+		node.Operation = words[0]
+		table := words[1]
+		// 旧版本的输出为 "SCAN TABLE users"
+		if table == "TABLE" && len(words) > 2 {
+			table = words[2]
+		}
+		if table == "CONSTANT" || table == "SUBQUERY" {
+			return node
+		}
+		node.Table = table
+
+		if i := strings.Index(detail, " INDEX "); i >= 0 {
+			rest := strings.Fields(detail[i+len(" INDEX "):])
+			if len(rest) > 0 {
+				node.Index = rest[0]
+			}
+		} else if strings.Contains(detail, "PRIMARY KEY") {
+			node.Index = "PRIMARY KEY"
+		}
+		if i := strings.Index(detail, "("); i >= 0 {
+			node.Condition = strings.TrimSuffix(detail[i+1:], ")")
+		}
+		node.FullScan = words[0] == "SCAN"
+	case "USE":
+		// USE TEMP B-TREE FOR ORDER BY / GROUP BY / DISTINCT
+		node.TempStructure = true
+	}
+	return node
+}
+
+func (d *SQLiteDialect) TextLength(expr string) string {
+	return "length(CAST(" + expr + " AS TEXT))"
+}
+
+func (d *SQLiteDialect) RandomOrder() string {
+	return "random()"
+}
+
+func (d *SQLiteDialect) LimitClause(limit, offset int) string {
+	return limitClause(limit, offset)
+}
+
+// SQLite 驱动会忽略只读选项，只读模式改由连接参数 query_only 保证
+func (d *SQLiteDialect) SupportsReadOnlyTx() bool {
+	return false
+}
+
+func (d *SQLiteDialect) SupportsTransactionalDDL() bool {
+	return true
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+)
+
+// shapeDriver 不连接数据库的驱动：每条查询返回一行，列数等于 SELECT 顶层的选择项数，
+// 每个值都是 "1"。用于检查内省查询的 SELECT 与 rows.Scan 的目标个数一致
+type shapeDriver struct{}
+
+func (shapeDriver) Open(name string) (driver.Conn, error) {
+	return shapeConn{}, nil
+}
+
+type shapeConn struct{}
+
+func (shapeConn) Prepare(query string) (driver.Stmt, error) {
+	return shapeStmt{query: query}, nil
+}
+
+func (shapeConn) Close() error { return nil }
+
+func (shapeConn) Begin() (driver.Tx, error) { return shapeTx{}, nil }
+
+func (shapeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return newShapeRows(query), nil
+}
+
+type shapeTx struct{}
+
+func (shapeTx) Commit() error   { return nil }
+func (shapeTx) Rollback() error { return nil }
+
+type shapeStmt struct{ query string }
+
+func (s shapeStmt) Close() error  { return nil }
+func (s shapeStmt) NumInput() int { return -1 }
+
+func (s shapeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (s shapeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return newShapeRows(s.query), nil
+}
+
+type shapeRows struct {
+	columns []string
+	done    bool
+}
+
+func newShapeRows(query string) *shapeRows {
+	columns := make([]string, selectItemCount(query))
+	for i := range columns {
+		columns[i] = "c"
+	}
+	return &shapeRows{columns: columns}
+}
+
+func (r *shapeRows) Columns() []string { return r.columns }
+func (r *shapeRows) Close() error      { return nil }
+
+func (r *shapeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	for i := range dest {
+		dest[i] = "1"
+	}
+	return nil
+}
+
+// selectItemCount 统计第一个 SELECT 顶层的选择项数
+func selectItemCount(query string) int {
+	tokens := splitTokens(query, standardLex)[0].tokens
+	count, depth, started := 0, 0, false
+	for _, tok := range tokens {
+		switch {
+		case tok.kind == tokenPunct && tok.value == "(":
+			depth++
+		case tok.kind == tokenPunct && tok.value == ")":
+			depth--
+		case depth > 0:
+		case !started && tok.kind == tokenWord && tok.value == "SELECT":
+			started, count = true, 1
+		case started && tok.kind == tokenWord && tok.value == "FROM":
+			return count
+		case started && tok.kind == tokenPunct && tok.value == ",":
+			count++
+		}
+	}
+	return count
+}
+
+func init() {
+	sql.Register("shape", shapeDriver{})
+}
+
+func TestSelectItemCount(t *testing.T) {
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"SELECT a FROM t", 1},
+		{"SELECT a, COALESCE(b, ''), (SELECT x, y FROM u) FROM t", 3},
+		{"SELECT CASE WHEN a THEN 'x, y' END, b\n\t\tFROM t WHERE c = $1", 2},
+	}
+	for _, tt := range tests {
+		if got := selectItemCount(tt.query); got != tt.want {
+			t.Errorf("selectItemCount(%q) = %d, want %d", tt.query, got, tt.want)
+		}
+	}
+}
+
+// TestIntrospectionQueryShape 内省查询选择的列数与扫描的目标个数一致。
+// 值的类型转换错误（如数组列）与查询的形状无关，不在检查范围内
+func TestIntrospectionQueryShape(t *testing.T) {
+	db, err := sql.Open("shape", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, d := range []Dialect{&MySQLDialect{}, &PostgresDialect{}} {
+		checks := map[string]func() error{
+			"ListTables": func() error {
+				_, err := d.ListTables(db)
+				return err
+			},
+			"DescribeTable": func() error {
+				_, err := d.DescribeTable(db, "t")
+				return err
+			},
+			"DescribeIndexes": func() error {
+				_, err := d.DescribeIndexes(db, "t")
+				return err
+			},
+			"ForeignKeys": func() error {
+				_, err := d.ForeignKeys(db, "t")
+				return err
+			},
+			"DescribeChecks": func() error {
+				_, err := d.DescribeChecks(db, "t")
+				return err
+			},
+			"DescribeTableStats": func() error {
+				_, _, err := d.DescribeTableStats(db, "t")
+				return err
+			},
+			"DistinctEstimates": func() error {
+				_, err := d.DistinctEstimates(db, "t")
+				return err
+			},
+			"SchemaColumns": func() error {
+				_, err := d.SchemaColumns(db)
+				return err
+			},
+		}
+		for name, check := range checks {
+			if err := check(); err != nil && strings.Contains(err.Error(), "destination arguments") {
+				t.Errorf("%s %s: %v", d.Name(), name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+// PlanNode 归一化后的执行计划节点
+type PlanNode struct {
+	// 操作，如 Seq Scan、Index Lookup、SCAN
+	Operation string `json:"operation"`
+	Table     string `json:"table,omitempty"`
+	// 实际使用的索引
+	Index string `json:"index,omitempty"`
+	// 可用但未使用的索引（MySQL possible_keys）
+	PossibleIndexes []string `json:"possible_indexes,omitempty"`
+	// 过滤或连接条件
+	Condition string `json:"condition,omitempty"`
+
+	EstimatedRows *float64 `json:"estimated_rows,omitempty"`
+	Cost          *float64 `json:"cost,omitempty"`
+	// 仅 ANALYZE：实际行数（已乘以循环次数）和循环次数
+	ActualRows *float64 `json:"actual_rows,omitempty"`
+	Loops      *float64 `json:"loops,omitempty"`
+
+	// 全表扫描（或全索引扫描）
+	FullScan bool `json:"full_scan,omitempty"`
+	// 按条件过滤却没有使用索引（可能缺少索引，或有可用索引但未被选择）
+	MissingIndex bool `json:"missing_index,omitempty"`
+	// 使用临时表或额外排序
+	TempStructure bool `json:"temp_structure,omitempty"`
+
+	// 数据库给出的原始描述
+	Detail   string      `json:"detail,omitempty"`
+	Children []*PlanNode `json:"children,omitempty"`
+
+	// MySQL 嵌套循环连接到此表为止产生的行数
+	produced *float64
+}
+
+// QueryPlan 归一化后的执行计划
+type QueryPlan struct {
+	Dialect  string      `json:"dialect"`
+	Analyzed bool        `json:"analyzed"`
+	Nodes    []*PlanNode `json:"nodes"`
+	// 预计返回（或连接产生）的行数
+	EstimatedRows *float64 `json:"estimated_rows,omitempty"`
+	TotalCost     *float64 `json:"total_cost,omitempty"`
+	// 仅 ANALYZE：执行耗时（毫秒）
+	ExecutionTimeMs *float64 `json:"execution_time_ms,omitempty"`
+	// 数据库返回的原始计划（JSON 或文本）
+	Raw string `json:"raw,omitempty"`
+}
+
+// Walk 深度优先遍历所有节点
+func (p *QueryPlan) Walk(fn func(node *PlanNode, depth int)) {
+	var walk func(nodes []*PlanNode, depth int)
+	walk = func(nodes []*PlanNode, depth int) {
+		for _, node := range nodes {
+			fn(node, depth)
+			walk(node.Children, depth+1)
+		}
+	}
+	walk(p.Nodes, 0)
+}
+
+// FullScans 所有全表扫描节点
+func (p *QueryPlan) FullScans() []*PlanNode {
+	var nodes []*PlanNode
+	p.Walk(func(node *PlanNode, depth int) {
+		if node.FullScan {
+			nodes = append(nodes, node)
+		}
+	})
+	return nodes
+}
+
+// MaxScanRows 全表扫描节点中最大的预计行数，没有估算时为0
+func (p *QueryPlan) MaxScanRows() float64 {
+	largest := 0.0
+	for _, node := range p.FullScans() {
+		if node.EstimatedRows != nil && *node.EstimatedRows > largest {
+			largest = *node.EstimatedRows
+		}
+	}
+	return largest
+}
+
+// FormatPlanNumber 格式化计划中的估算值，整数不显示小数
+func FormatPlanNumber(value float64) string {
+	if value == float64(int64(value)) {
+		return fmt.Sprintf("%d", int64(value))
+	}
+	return fmt.Sprintf("%.2f", value)
+}
+
+// ExplainQuery 获取单条语句的执行计划。
+// analyze 会真正执行语句，因此只允许只读语句
+func (dm *DatabaseManager) ExplainQuery(query string, analyze bool, args ...interface{}) (*QueryPlan, error) {
+	if dm.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	stmt, err := SingleStatement(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// 不带 ANALYZE 时不会执行语句，只在只读模式下拒绝修改语句
+	if dm.config.ReadOnly {
+		if err := CheckReadOnly(stmt.Text); err != nil {
+			return nil, err
+		}
+	}
+
+	if analyze && stmt.Class != StatementRead {
+		return nil, fmt.Errorf("ANALYZE executes the statement and is only allowed for read-only statements, got %s", stmt.Keyword)
+	}
+
+	if err := dm.checkConnectionFree(); err != nil {
+		return nil, err
+	}
+
+	// 只读模式下同样在只读事务中执行
+	var q Queryer = dm.db
+	if dm.config.ReadOnly && dm.dialect.SupportsReadOnlyTx() {
+		tx, err := dm.db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin read-only transaction: %v", err)
+		}
+		defer tx.Rollback()
+		q = tx
+	}
+
+	// 执行计划中会出现表名和过滤条件
+	if _, err := dm.checkAccess(q, stmt.Text); err != nil {
+		return nil, err
+	}
+
+	return dm.dialect.ExplainPlan(q, stmt.Text, analyze, args...)
+}
+
+// explainText 读取单行单列的 EXPLAIN 输出
+func explainText(q Queryer, query string, args ...interface{}) (string, error) {
+	var text string
+	if err := q.QueryRow(query, args...).Scan(&text); err != nil {
+		return "", fmt.Errorf("failed to explain query: %v", err)
+	}
+	return text, nil
+}
+
+// jsonNumber 读取JSON中的数字，兼容 MySQL 以字符串表示的数字
+func jsonNumber(value interface{}) *float64 {
+	switch v := value.(type) {
+	case float64:
+		return &v
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return &f
+		}
+	}
+	return nil
+}
+
+// jsonString 读取JSON中的字符串
+func jsonString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return ""
+}
+
+// multiply 两个可选数值相乘，任一为空时返回第一个
+func multiply(a, b *float64) *float64 {
+	if a == nil || b == nil {
+		return a
+	}
+	product := *a * *b
+	return &product
+}
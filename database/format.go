@@ -0,0 +1,326 @@
+package database
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Formatter 将查询结果格式化为文本
+type Formatter interface {
+	// Name 格式名称，即工具参数 format 的取值
+	Name() string
+	// Format 格式化结果中的列和行
+	Format(result *QueryResult) (string, error)
+}
+
+var formatters = map[string]Formatter{}
+
+func init() {
+	RegisterFormatter(&MarkdownFormatter{})
+	RegisterFormatter(&CSVFormatter{})
+	RegisterFormatter(&JSONFormatter{})
+	RegisterFormatter(&JSONLFormatter{})
+	RegisterFormatter(&VerticalFormatter{})
+}
+
+// RegisterFormatter 注册格式化器，同名时覆盖
+func RegisterFormatter(f Formatter) {
+	formatters[f.Name()] = f
+}
+
+// GetFormatter 按名称获取格式化器
+func GetFormatter(name string) (Formatter, error) {
+	f, ok := formatters[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported format: %q (available: %s)", name, strings.Join(FormatNames(), ", "))
+	}
+	return f, nil
+}
+
+// FormatNames 所有已注册的格式名称
+func FormatNames() []string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FormatValue 将结果值格式化为文本，NULL 显示为 NULL，对象和数组显示为JSON
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// TablesResult 将表名列表转换为查询结果，便于使用相同的格式化器
+func TablesResult(tables []string) *QueryResult {
+	result := &QueryResult{
+		Columns: []string{"table"},
+		Count:   len(tables),
+	}
+	for _, table := range tables {
+		result.Rows = append(result.Rows, []interface{}{table})
+	}
+	return result
+}
+
+// SchemaResult 将表结构转换为查询结果，每个字段一行
+func SchemaResult(schema *TableSchema) *QueryResult {
+	result := &QueryResult{
+		Columns: []string{"name", "type", "nullable", "key", "default", "extra"},
+		Count:   len(schema.Columns),
+	}
+	for _, col := range schema.Columns {
+		var defaultValue interface{}
+		if col.Default != nil {
+			defaultValue = *col.Default
+		}
+		result.Rows = append(result.Rows, []interface{}{
+			col.Name, col.Type, col.Nullable, col.Key, defaultValue, col.Extra,
+		})
+	}
+	return result
+}
+
+// RelationshipsResult 将外键列表转换为查询结果，每个外键一行，多列外键的列以逗号分隔
+func RelationshipsResult(keys []ForeignKey) *QueryResult {
+	result := &QueryResult{
+		Columns: []string{"name", "table", "columns", "referenced_table", "referenced_columns", "on_update", "on_delete"},
+		Count:   len(keys),
+	}
+	for _, key := range keys {
+		result.Rows = append(result.Rows, []interface{}{
+			key.Name, key.Table, strings.Join(key.Columns, ","), key.ReferencedTable,
+			strings.Join(key.ReferencedColumns, ","), key.OnUpdate, key.OnDelete,
+		})
+	}
+	return result
+}
+
+// MarkdownFormatter Markdown 表格
+type MarkdownFormatter struct{}
+
+func (f *MarkdownFormatter) Name() string {
+	return "markdown"
+}
+
+func (f *MarkdownFormatter) Format(result *QueryResult) (string, error) {
+	var buf strings.Builder
+
+	cells := make([]string, len(result.Columns))
+	for i, col := range result.Columns {
+		cells[i] = markdownCell(col)
+	}
+	buf.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+
+	for i := range cells {
+		cells[i] = "---"
+	}
+	buf.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+
+	for _, row := range result.Rows {
+		for i, value := range row {
+			cells[i] = markdownCell(FormatValue(value))
+		}
+		buf.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	return buf.String(), nil
+}
+
+// markdownCell 转义竖线和换行，避免破坏表格
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	text = strings.ReplaceAll(text, "\r\n", "<br>")
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
+
+// CSVFormatter RFC 4180 CSV，首行为列名，NULL 为空字段
+type CSVFormatter struct{}
+
+func (f *CSVFormatter) Name() string {
+	return "csv"
+}
+
+func (f *CSVFormatter) Format(result *QueryResult) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(result.Columns); err != nil {
+		return "", err
+	}
+
+	record := make([]string, len(result.Columns))
+	for _, row := range result.Rows {
+		for i, value := range row {
+			if value == nil {
+				record[i] = ""
+				continue
+			}
+			record[i] = FormatValue(value)
+		}
+		if err := writer.Write(record); err != nil {
+			return "", err
+		}
+	}
+
+	writer.Flush()
+	return buf.String(), writer.Error()
+}
+
+// JSONFormatter JSON 数组，每行为一个以列名为键的对象
+type JSONFormatter struct{}
+
+func (f *JSONFormatter) Name() string {
+	return "json"
+}
+
+func (f *JSONFormatter) Format(result *QueryResult) (string, error) {
+	var buf bytes.Buffer
+	keys := recordKeys(result.Columns)
+
+	buf.WriteString("[")
+	for i, row := range result.Rows {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  ")
+		if err := writeRecord(&buf, keys, row); err != nil {
+			return "", err
+		}
+	}
+	if len(result.Rows) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]")
+	return buf.String(), nil
+}
+
+// JSONLFormatter 每行一个JSON对象
+type JSONLFormatter struct{}
+
+func (f *JSONLFormatter) Name() string {
+	return "jsonl"
+}
+
+func (f *JSONLFormatter) Format(result *QueryResult) (string, error) {
+	var buf bytes.Buffer
+	keys := recordKeys(result.Columns)
+
+	for _, row := range result.Rows {
+		if err := writeRecord(&buf, keys, row); err != nil {
+			return "", err
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String(), nil
+}
+
+// recordKeys 生成对象的键，重复的列名加上序号后缀。
+// 后缀跳过已被使用的键（包括其他列本来的名称），如 a, a, a_2 生成 a, a_3, a_2
+func recordKeys(columns []string) []string {
+	used := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		used[col] = true
+	}
+
+	keys := make([]string, len(columns))
+	first := make(map[string]bool, len(columns))
+	next := make(map[string]int, len(columns))
+	for i, col := range columns {
+		if !first[col] {
+			first[col] = true
+			keys[i] = col
+			continue
+		}
+
+		n := next[col]
+		if n == 0 {
+			n = 2
+		}
+		for used[fmt.Sprintf("%s_%d", col, n)] {
+			n++
+		}
+		keys[i] = fmt.Sprintf("%s_%d", col, n)
+		used[keys[i]] = true
+		next[col] = n + 1
+	}
+	return keys
+}
+
+// writeRecord 按列顺序写出一行对象
+func writeRecord(buf *bytes.Buffer, keys []string, row []interface{}) error {
+	buf.WriteString("{")
+	for i, value := range row {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		if err := writeJSON(buf, keys[i]); err != nil {
+			return err
+		}
+		buf.WriteString(":")
+		if err := writeJSON(buf, value); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("}")
+	return nil
+}
+
+// writeJSON 编码单个值，不转义 HTML 字符
+func writeJSON(buf *bytes.Buffer, value interface{}) error {
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	buf.Write(bytes.TrimRight(encoded.Bytes(), "\n"))
+	return nil
+}
+
+// VerticalFormatter 每个字段一行，类似 MySQL 客户端的 \G 输出，适合宽表
+type VerticalFormatter struct{}
+
+func (f *VerticalFormatter) Name() string {
+	return "vertical"
+}
+
+func (f *VerticalFormatter) Format(result *QueryResult) (string, error) {
+	var buf strings.Builder
+
+	width := 0
+	for _, col := range result.Columns {
+		if n := utf8.RuneCountInString(col); n > width {
+			width = n
+		}
+	}
+
+	for i, row := range result.Rows {
+		buf.WriteString(fmt.Sprintf("%s %d. row %s\n", strings.Repeat("*", 27), result.Offset+i+1, strings.Repeat("*", 27)))
+		for j, value := range row {
+			col := result.Columns[j]
+			padding := strings.Repeat(" ", width-utf8.RuneCountInString(col))
+			buf.WriteString(fmt.Sprintf("%s%s: %s\n", padding, col, FormatValue(value)))
+		}
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecordKeys(t *testing.T) {
+	tests := []struct {
+		columns []string
+		want    []string
+	}{
+		{[]string{"id", "name"}, []string{"id", "name"}},
+		{[]string{"a", "a", "a"}, []string{"a", "a_2", "a_3"}},
+		{[]string{"a", "a", "a_2"}, []string{"a", "a_3", "a_2"}},
+		{[]string{"a_2", "a", "a"}, []string{"a_2", "a", "a_3"}},
+		{[]string{"a", "a", "a_3", "a"}, []string{"a", "a_2", "a_3", "a_4"}},
+		{[]string{"a", "a", "a", "a_2", "a_2"}, []string{"a", "a_3", "a_4", "a_2", "a_2_2"}},
+	}
+
+	for _, tt := range tests {
+		got := recordKeys(tt.columns)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("recordKeys(%q) = %q, want %q", tt.columns, got, tt.want)
+		}
+
+		seen := make(map[string]bool)
+		for _, key := range got {
+			if seen[key] {
+				t.Errorf("recordKeys(%q) has duplicate key %q", tt.columns, key)
+			}
+			seen[key] = true
+		}
+	}
+}
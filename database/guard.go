@@ -0,0 +1,91 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 代价检查只对这些查询生成执行计划，SHOW、PRAGMA 等语句无需检查
+var guardedKeywords = map[string]bool{
+	"SELECT": true,
+	"WITH":   true,
+	"VALUES": true,
+	"TABLE":  true,
+}
+
+// CostViolation 查询被代价检查拒绝的原因及其执行计划
+type CostViolation struct {
+	Reasons []string   `json:"reasons"`
+	Plan    *QueryPlan `json:"plan"`
+}
+
+func (v *CostViolation) Error() string {
+	return "query rejected by cost guard: " + strings.Join(v.Reasons, "; ")
+}
+
+// checkCost 执行前获取执行计划，超出 cost_guard 阈值时返回 *CostViolation。
+// 无法获取执行计划时同样拒绝执行
+func (dm *DatabaseManager) checkCost(q Queryer, query string, args ...interface{}) error {
+	guard := dm.config.Query.CostGuard
+	if !guard.Enabled {
+		return nil
+	}
+
+	stmt, err := SingleStatement(query)
+	if err != nil {
+		return fmt.Errorf("cost guard: %v", err)
+	}
+	if !guardedKeywords[stmt.Keyword] {
+		return nil
+	}
+
+	plan, err := dm.dialect.ExplainPlan(q, stmt.Text, false, args...)
+	if err != nil {
+		return fmt.Errorf("cost guard could not explain query: %v", err)
+	}
+
+	var reasons []string
+	if guard.MaxEstimatedRows > 0 && plan.EstimatedRows != nil && *plan.EstimatedRows > guard.MaxEstimatedRows {
+		reasons = append(reasons, fmt.Sprintf("estimated %s rows exceeds max_estimated_rows %s",
+			FormatPlanNumber(*plan.EstimatedRows), FormatPlanNumber(guard.MaxEstimatedRows)))
+	}
+	if guard.MaxCost > 0 && plan.TotalCost != nil && *plan.TotalCost > guard.MaxCost {
+		reasons = append(reasons, fmt.Sprintf("estimated cost %s exceeds max_cost %s",
+			FormatPlanNumber(*plan.TotalCost), FormatPlanNumber(guard.MaxCost)))
+	}
+	// 同一张表可能被扫描多次（如自连接），相同的原因只报告一次
+	seen := make(map[string]bool)
+	for _, node := range plan.FullScans() {
+		var reason string
+		switch {
+		case guard.RejectFullScans:
+			reason = "full scan " + describeScan(node) + " is not allowed"
+		case guard.MaxScanRows > 0 && node.EstimatedRows != nil && *node.EstimatedRows > guard.MaxScanRows:
+			reason = fmt.Sprintf("full scan %s exceeds max_scan_rows %s",
+				describeScan(node), FormatPlanNumber(guard.MaxScanRows))
+		}
+		if reason != "" && !seen[reason] {
+			seen[reason] = true
+			reasons = append(reasons, reason)
+		}
+	}
+
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	plan.Raw = ""
+	return &CostViolation{Reasons: reasons, Plan: plan}
+}
+
+// describeScan 描述全表扫描节点，如 "on users (~120000 rows)"
+func describeScan(node *PlanNode) string {
+	text := "on " + node.Table
+	if node.Table == "" {
+		text = "(" + node.Operation + ")"
+	}
+	if node.EstimatedRows != nil {
+		text += fmt.Sprintf(" (~%s rows)", FormatPlanNumber(*node.EstimatedRows))
+	}
+	return text
+}
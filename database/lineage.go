@@ -0,0 +1,740 @@
+package database
+
+import "strings"
+
+// columnUse 标识符与结果列的关系
+type columnUse int
+
+const (
+	// useNone 不进入结果：WHERE、ORDER BY、FROM 等子句中，COUNT/LENGTH 等只输出数量的函数参数中，以及列别名本身
+	useNone columnUse = iota
+	// useColumn 选择列表中单独的一列（可带表名前缀），结果列名就是该名称
+	useColumn
+	// useAliased 选择列表中单独的一列，但结果列名是别名
+	useAliased
+	// useExpression 结果来自包含它的表达式、函数、标量子查询或 UNION 的后续分支，无法按列名追溯来源
+	useExpression
+)
+
+// tokenUse 单个词法单元的用法，useAliased 时 alias 为结果列名
+type tokenUse struct {
+	use   columnUse
+	alias string
+}
+
+// lineage 语句中每个词法单元与结果列的关系
+type lineage struct {
+	uses []tokenUse
+	// 派生表、CTE 或表别名带有列别名列表（如 AS s(a, b)），结果列名与来源字段无关
+	renamed bool
+	// 派生表的别名（小写），与表别名一样可以作为整行引用
+	derived map[string]bool
+	// 表名位置：直接位于语句开头（如 UPDATE t）、FROM、JOIN、INTO、WITH 中的表名、别名和 CTE 名称
+	tableNames []bool
+}
+
+// lineageLevel 一层括号的分析状态
+type lineageLevel struct {
+	// 最外层或子查询，否则是表达式中的括号
+	query bool
+	// query 层当前所在的子句
+	clause string
+	// 表达式层：位于结果表达式中；query 层：整个子查询位于结果表达式中（标量子查询）
+	output bool
+	// query 层：在 UNION/INTERSECT/EXCEPT 之后，结果列名来自第一个分支
+	setOp bool
+	// 位于只输出数量或真假的函数中，不会输出字段的值
+	hidden bool
+}
+
+// 影响 query 层所在子句的关键字
+var lineageClauses = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "GROUP": true, "HAVING": true, "ORDER": true,
+	"LIMIT": true, "OFFSET": true, "FETCH": true, "RETURNING": true, "SET": true, "VALUES": true,
+	"ON": true, "USING": true, "WINDOW": true, "INTO": true, "JOIN": true, "WITH": true,
+	"FOR": true, "QUALIFY": true,
+}
+
+var setOperators = map[string]bool{"UNION": true, "INTERSECT": true, "EXCEPT": true}
+
+// 参数的值不会出现在结果中的函数
+var valueHidingFunctions = map[string]bool{
+	"COUNT": true, "LENGTH": true, "CHAR_LENGTH": true, "CHARACTER_LENGTH": true,
+	"OCTET_LENGTH": true, "EXISTS": true,
+}
+
+// 表达式中常见的关键字，不是字段名
+var expressionKeywords = map[string]bool{
+	"SELECT": true, "DISTINCT": true, "ALL": true, "AS": true, "CASE": true, "WHEN": true,
+	"THEN": true, "ELSE": true, "END": true, "AND": true, "OR": true, "NOT": true, "NULL": true,
+	"TRUE": true, "FALSE": true, "IS": true, "IN": true, "LIKE": true, "ILIKE": true,
+	"BETWEEN": true, "ESCAPE": true, "INTERVAL": true, "COLLATE": true, "DIV": true, "MOD": true,
+	"BINARY": true, "UNKNOWN": true, "CURRENT_DATE": true, "CURRENT_TIME": true, "CURRENT_TIMESTAMP": true,
+}
+
+// analyzeLineage 分析语句中每个标识符是否进入结果，以及结果列名能否追溯到它。
+// 只做静态分析，无法确定时按 useExpression 处理，由调用方拒绝
+func analyzeLineage(tokens []sqlToken) *lineage {
+	l := &lineage{
+		uses:       make([]tokenUse, len(tokens)),
+		derived:    make(map[string]bool),
+		tableNames: make([]bool, len(tokens)),
+	}
+	levels := []lineageLevel{{query: true}}
+
+	for i, tok := range tokens {
+		level := &levels[len(levels)-1]
+
+		switch tok.value {
+		case "(":
+			child := lineageLevel{hidden: level.hidden || hidesValues(tokens, i), output: level.inOutput()}
+			if i+1 < len(tokens) && (tokens[i+1].value == "SELECT" || tokens[i+1].value == "WITH" || tokens[i+1].value == "VALUES") {
+				child.query = true
+				// (SELECT ...) UNION (SELECT ...) 的后续分支
+				child.setOp = level.query && level.setOp && level.clause == ""
+			} else if level.query && isColumnAliasList(tokens, i, level.clause) {
+				l.renamed = true
+			}
+			levels = append(levels, child)
+			continue
+		case ")":
+			if len(levels) > 1 {
+				closed := levels[len(levels)-1]
+				levels = levels[:len(levels)-1]
+				parent := &levels[len(levels)-1]
+				if closed.query && parent.query && (parent.clause == "FROM" || parent.clause == "JOIN") {
+					l.addDerived(tokens, i+1)
+				}
+			}
+			continue
+		case "*":
+			// 标量子查询或 UNION 后续分支中单独的 *，输出的字段无法按列名追溯
+			if tok.kind != tokenPunct {
+				break
+			}
+			if level.query && (level.output || level.setOp) && level.inOutput() && i > 0 {
+				switch tokens[i-1].value {
+				case "SELECT", "DISTINCT", "ALL", ",":
+					l.uses[i] = tokenUse{use: useExpression}
+				}
+			}
+			continue
+		}
+
+		if tok.kind != tokenWord && tok.kind != tokenQuotedIdent {
+			continue
+		}
+
+		if level.query && tok.kind == tokenWord {
+			prev := ""
+			if i > 0 {
+				prev = tokens[i-1].value
+			}
+			switch {
+			case setOperators[tok.value]:
+				level.setOp = true
+				level.clause = ""
+				continue
+			case (tok.value == "FROM" || tok.value == "ON") && prev == "DISTINCT":
+				// IS DISTINCT FROM、DISTINCT ON (...)
+			case tok.value == "VALUES":
+				// 单独的 VALUES 输出结果，INSERT ... VALUES 则写入表中
+				level.clause = "INSERT"
+				if i == 0 || prev == "(" || setOperators[prev] {
+					level.clause = "VALUES"
+				}
+				continue
+			case lineageClauses[tok.value]:
+				level.clause = tok.value
+				continue
+			}
+		}
+
+		if level.query {
+			switch level.clause {
+			case "", "FROM", "JOIN", "INTO", "WITH":
+				l.tableNames[i] = true
+			}
+		}
+
+		switch {
+		case level.hidden:
+		case !level.query:
+			if level.output {
+				l.uses[i] = tokenUse{use: useExpression}
+			}
+		case level.clause == "SET":
+			// 赋值目标不输出值，等号右边的表达式可能通过 RETURNING 输出
+			if !isAssignmentTarget(tokens, i) {
+				l.uses[i] = tokenUse{use: useExpression}
+			}
+		case level.inOutput():
+			// AS 之后或紧跟在单独一列之后的别名
+			if i > 0 && tokens[i-1].value == "AS" {
+				continue
+			}
+			if i > 0 && isIdentifier(tokens[i-1]) && !expressionKeywords[tokens[i-1].value] {
+				if prev := selectItemUse(tokens, i-1); prev.use == useAliased && prev.alias == tok.raw {
+					continue
+				}
+			}
+			use := selectItemUse(tokens, i)
+			if (level.output || level.setOp) && use.use != useNone {
+				use = tokenUse{use: useExpression}
+			}
+			l.uses[i] = use
+		}
+	}
+	return l
+}
+
+// inOutput 当前位置的值是否进入结果
+func (level *lineageLevel) inOutput() bool {
+	if level.hidden {
+		return false
+	}
+	if !level.query {
+		return level.output
+	}
+	switch level.clause {
+	case "SELECT", "RETURNING", "VALUES", "SET":
+		return true
+	}
+	return false
+}
+
+// addDerived 记录子查询之后的派生表别名，别名后的括号是列别名列表
+func (l *lineage) addDerived(tokens []sqlToken, start int) {
+	i := start
+	if i < len(tokens) && tokens[i].value == "AS" {
+		i++
+	}
+	if i < len(tokens) && isAliasName(tokens[i]) {
+		l.derived[strings.ToLower(tokens[i].raw)] = true
+		if i+1 < len(tokens) && tokens[i+1].value == "(" {
+			l.renamed = true
+		}
+	}
+}
+
+// isAliasName 词法单元能否作为别名
+func isAliasName(tok sqlToken) bool {
+	if tok.kind == tokenQuotedIdent {
+		return true
+	}
+	return tok.kind == tokenWord && !tableRefStopWords[tok.value] && !tableRefKeywords[tok.value] &&
+		tok.value != "AS" && tok.value != "LATERAL" && tok.value != "ONLY" && tok.value != "ON"
+}
+
+// isColumnAliasList 位于 start 的括号是否为列别名列表：WITH 中 CTE 名称之后，
+// 或 FROM/JOIN 中表别名之后（如 users AS u(a, b)）
+func isColumnAliasList(tokens []sqlToken, start int, clause string) bool {
+	if start == 0 || !isAliasName(tokens[start-1]) {
+		return false
+	}
+	switch clause {
+	case "WITH":
+		return true
+	case "FROM", "JOIN":
+		if start < 2 {
+			return false
+		}
+		before := tokens[start-2]
+		return before.value == "AS" || before.value == ")" || before.value == "]" || isAliasName(before)
+	}
+	return false
+}
+
+// hidesValues 位于 start 的括号中的值是否不会输出：COUNT(...)、LENGTH(...) 等函数参数，以及 DISTINCT ON (...)
+func hidesValues(tokens []sqlToken, start int) bool {
+	if start == 0 || tokens[start-1].kind != tokenWord {
+		return false
+	}
+	if valueHidingFunctions[tokens[start-1].value] {
+		return true
+	}
+	return tokens[start-1].value == "ON" && start >= 2 && tokens[start-2].value == "DISTINCT"
+}
+
+// isAssignmentTarget SET 子句中 [表.]字段 = ... 的字段
+func isAssignmentTarget(tokens []sqlToken, i int) bool {
+	for i+2 < len(tokens) && tokens[i+1].value == "." {
+		i += 2
+	}
+	return i+1 < len(tokens) && tokens[i+1].value == "="
+}
+
+// selectItemUse 判断选择列表中位于 i 的标识符：[表.]字段（或 表.*）单独作为一项时，
+// 结果列名为字段名或其后的别名，否则是表达式的一部分
+func selectItemUse(tokens []sqlToken, i int) tokenUse {
+	start, end := i, i
+	// SQLite 的 [名称] 引用
+	if start > 0 && tokens[start-1].value == "[" && end+1 < len(tokens) && tokens[end+1].value == "]" {
+		start--
+		end++
+	}
+	for start >= 2 && tokens[start-1].value == "." && isIdentifier(tokens[start-2]) {
+		start -= 2
+	}
+	for end+2 < len(tokens) && tokens[end+1].value == "." && (isIdentifier(tokens[end+2]) || tokens[end+2].value == "*") {
+		end += 2
+	}
+
+	if start > 0 {
+		switch tokens[start-1].value {
+		case "SELECT", "DISTINCT", "ALL", ",", "RETURNING":
+		case ")":
+			// DISTINCT ON (...) 之后是第一项
+			if open := matchingParen(tokens, start-1); open < 2 || tokens[open-1].value != "ON" || tokens[open-2].value != "DISTINCT" {
+				return tokenUse{use: useExpression}
+			}
+		default:
+			return tokenUse{use: useExpression}
+		}
+	}
+
+	next := end + 1
+	if endsSelectItem(tokens, next) {
+		return tokenUse{use: useColumn}
+	}
+	if tokens[next].value == "AS" {
+		next++
+	}
+	if next < len(tokens) && isAliasName(tokens[next]) && endsSelectItem(tokens, next+1) {
+		return tokenUse{use: useAliased, alias: tokens[next].raw}
+	}
+	return tokenUse{use: useExpression}
+}
+
+// matchingParen 位于 end 的右括号对应的左括号位置，找不到时返回-1
+func matchingParen(tokens []sqlToken, end int) int {
+	depth := 0
+	for i := end; i >= 0; i-- {
+		switch tokens[i].value {
+		case ")":
+			depth++
+		case "(":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// endsSelectItem 位置 i 是否为选择列表中一项的结尾
+func endsSelectItem(tokens []sqlToken, i int) bool {
+	if i >= len(tokens) {
+		return true
+	}
+	tok := tokens[i]
+	if tok.value == "," || tok.value == ")" {
+		return true
+	}
+	return tok.kind == tokenWord && (lineageClauses[tok.value] || setOperators[tok.value])
+}
+
+// isIdentifier 词法单元是否为标识符
+func isIdentifier(tok sqlToken) bool {
+	return tok.kind == tokenWord || tok.kind == tokenQuotedIdent
+}
+
+// wholeRowReference 查找对 rows 中的名称（小写的表名、别名）的整行引用，如 PostgreSQL 的
+// SELECT u FROM users u、row_to_json(u)，以及不是单独一项的 u.*。返回引用的文本，没有时返回空
+func wholeRowReference(tokens []sqlToken, l *lineage, rows map[string]bool) string {
+	if len(rows) == 0 {
+		return ""
+	}
+	for i, tok := range tokens {
+		if !isIdentifier(tok) || l.tableNames[i] || !rows[strings.ToLower(tok.raw)] {
+			continue
+		}
+		// 字段名和别名
+		if i > 0 && (tokens[i-1].value == "." || tokens[i-1].value == "AS") {
+			continue
+		}
+
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1].value
+		}
+		switch next {
+		case "(":
+			// 同名的函数
+			continue
+		case ".":
+			use := l.uses[i].use
+			if i+2 < len(tokens) && tokens[i+2].value == "*" && use != useColumn && use != useAliased {
+				return tok.raw + ".*"
+			}
+			continue
+		}
+		return tok.raw
+	}
+	return ""
+}
+
+// sourceQuery 一层 SELECT 的结果列来源：第一个分支（结果列名来自第一个分支）的选择项和 FROM 中的来源
+type sourceQuery struct {
+	items   []selectItem
+	sources []querySource
+	// 不是 SELECT（如 VALUES、TABLE、INSERT ... RETURNING），结果列可能来自语句中的任何表
+	opaque bool
+}
+
+// selectItem 选择列表中的一项，名称都为小写
+type selectItem struct {
+	// 表名前缀
+	qualifier string
+	// 字段名，* 表示所有字段，表达式为空
+	column string
+	// 结果列名，没有别名的表达式为空
+	name string
+}
+
+// querySource FROM 中的一项，名称都为小写。table 和 query 都为空时无法追溯，
+// 如表值函数、括号中的连接和带列别名列表的来源
+type querySource struct {
+	// 引用其字段时使用的名称：别名，没有别名时为表名
+	name string
+	// 基本表的名称
+	table string
+	// 派生表或 CTE 的查询
+	query *sourceQuery
+}
+
+// columnSources 查询结果列的来源
+type columnSources struct {
+	queries []*sourceQuery
+	// 语句引用的所有基本表（小写），无法追溯时认为结果列可能来自其中任何一张
+	tables map[string]bool
+}
+
+// 选择列表之前的修饰词
+var selectModifiers = map[string]bool{
+	"DISTINCT": true, "ALL": true, "DISTINCTROW": true, "HIGH_PRIORITY": true, "STRAIGHT_JOIN": true,
+	"SQL_SMALL_RESULT": true, "SQL_BIG_RESULT": true, "SQL_BUFFER_RESULT": true, "SQL_NO_CACHE": true,
+	"SQL_CACHE": true, "SQL_CALC_FOUND_ROWS": true,
+}
+
+// 结束 FROM 子句的关键字
+var fromClauseEnd = map[string]bool{
+	"WHERE": true, "GROUP": true, "HAVING": true, "ORDER": true, "LIMIT": true, "OFFSET": true,
+	"FETCH": true, "WINDOW": true, "FOR": true, "QUALIFY": true, "INTO": true, "RETURNING": true,
+	"UNION": true, "INTERSECT": true, "EXCEPT": true,
+}
+
+// traceColumns 按两种词法规则分析查询中的每条语句，用于追溯结果列来自哪些表
+func traceColumns(query string) *columnSources {
+	c := &columnSources{tables: make(map[string]bool)}
+	for _, mode := range []lexMode{standardLex, mysqlLex} {
+		for _, stmt := range analyze(query, mode) {
+			for _, ref := range tableReferences(stmt.tokens) {
+				if !ref.function {
+					c.tables[strings.ToLower(ref.name)] = true
+				}
+			}
+			c.queries = append(c.queries, parseSourceQuery(stmt.tokens, 0, len(stmt.tokens), nil))
+		}
+	}
+	return c
+}
+
+// sources 名为 column 的结果列可能来自的基本表（小写）。
+// 只追溯以原名输出的字段，别名、表达式等由 Redactor.CheckQuery 限制
+func (c *columnSources) sources(column string) map[string]bool {
+	out := make(map[string]bool)
+	for _, q := range c.queries {
+		q.trace(strings.ToLower(column), c.tables, out)
+	}
+	return out
+}
+
+// parseSourceQuery 分析 tokens[start:end] 中的查询，ctes 为可见的 CTE
+func parseSourceQuery(tokens []sqlToken, start, end int, ctes map[string]*sourceQuery) *sourceQuery {
+	if end > len(tokens) {
+		end = len(tokens)
+	}
+	i := start
+	if i < end && tokens[i].value == "WITH" {
+		ctes, i = parseSourceCTEs(tokens, i+1, end, ctes)
+	}
+	// (SELECT ...) UNION (SELECT ...) 的结果列名来自第一个分支
+	if i < end && tokens[i].value == "(" {
+		return parseSourceQuery(tokens, i+1, skipParens(tokens, i)-1, ctes)
+	}
+
+	q := &sourceQuery{}
+	if i >= end || tokens[i].kind != tokenWord || tokens[i].value != "SELECT" {
+		q.opaque = true
+		return q
+	}
+	for i++; i < end && tokens[i].kind == tokenWord && selectModifiers[tokens[i].value]; i++ {
+		if tokens[i].value == "DISTINCT" && i+2 < end && tokens[i+1].value == "ON" && tokens[i+2].value == "(" {
+			i = skipParens(tokens, i+2) - 1
+		}
+	}
+
+	for i < end {
+		itemEnd := selectItemEnd(tokens, i, end)
+		q.items = append(q.items, parseSelectItem(tokens, i, itemEnd))
+		i = itemEnd
+		if i >= end || tokens[i].value != "," {
+			break
+		}
+		i++
+	}
+
+	// SELECT ... INTO t FROM ... 中 FROM 在 INTO 之后
+	depth := 0
+	for ; i < end; i++ {
+		tok := tokens[i]
+		switch {
+		case tok.value == "(":
+			depth++
+			continue
+		case tok.value == ")":
+			depth--
+			continue
+		}
+		if depth > 0 || tok.kind != tokenWord {
+			continue
+		}
+		if setOperators[tok.value] {
+			return q
+		}
+		if tok.value == "FROM" && tokens[i-1].value != "DISTINCT" {
+			break
+		}
+	}
+	if i >= end {
+		return q
+	}
+
+	// 逗号和 JOIN 之后是下一个来源，ON/USING 条件中的括号整体跳过
+	source, i := parseQuerySource(tokens, i+1, end, ctes)
+	q.sources = append(q.sources, source)
+	for i < end {
+		tok := tokens[i]
+		switch {
+		case tok.value == ",", tok.kind == tokenWord && (tok.value == "JOIN" || tok.value == "STRAIGHT_JOIN"):
+			source, i = parseQuerySource(tokens, i+1, end, ctes)
+			q.sources = append(q.sources, source)
+		case tok.value == "(":
+			i = skipParens(tokens, i)
+		case tok.kind == tokenWord && fromClauseEnd[tok.value]:
+			return q
+		default:
+			i++
+		}
+	}
+	return q
+}
+
+// parseSourceCTEs 读取 WITH 之后的定义，返回加上这些定义后可见的 CTE 和主查询的位置
+func parseSourceCTEs(tokens []sqlToken, i, end int, outer map[string]*sourceQuery) (map[string]*sourceQuery, int) {
+	ctes := make(map[string]*sourceQuery, len(outer))
+	for name, q := range outer {
+		ctes[name] = q
+	}
+	if i < end && tokens[i].value == "RECURSIVE" {
+		i++
+	}
+	for i < end && isIdentifier(tokens[i]) {
+		name := strings.ToLower(tokens[i].raw)
+		i++
+		renamed := i < end && tokens[i].value == "("
+		if renamed {
+			i = skipParens(tokens, i)
+		}
+		if i >= end || tokens[i].value != "AS" {
+			break
+		}
+		for i++; i < end && (tokens[i].value == "NOT" || tokens[i].value == "MATERIALIZED"); i++ {
+		}
+		if i >= end || tokens[i].value != "(" {
+			break
+		}
+		close := skipParens(tokens, i)
+		body := parseSourceQuery(tokens, i+1, close-1, ctes)
+		if renamed {
+			body = &sourceQuery{opaque: true}
+		}
+		ctes[name] = body
+		i = close
+		if i >= end || tokens[i].value != "," {
+			break
+		}
+		i++
+	}
+	return ctes, i
+}
+
+// parseQuerySource 读取从 i 开始的一个来源及其别名，返回来源和其后的位置
+func parseQuerySource(tokens []sqlToken, i, end int, ctes map[string]*sourceQuery) (querySource, int) {
+	for i < end && tokens[i].kind == tokenWord && (tokens[i].value == "LATERAL" || tokens[i].value == "ONLY") {
+		i++
+	}
+
+	var s querySource
+	if i < end && tokens[i].value == "(" {
+		// 括号中不是查询（如括号中的连接）时 parseSourceQuery 返回 opaque
+		close := skipParens(tokens, i)
+		s.query = parseSourceQuery(tokens, i+1, close-1, ctes)
+		i = close
+	} else {
+		ref, next, ok := parseTableName(tokens, i, true)
+		if !ok || next > end {
+			return s, i
+		}
+		i = next
+		s.name = strings.ToLower(ref.name)
+		if !ref.function {
+			if cte, ok := ctes[s.name]; ok && ref.schema == "" {
+				s.query = cte
+			} else {
+				s.table = s.name
+			}
+		}
+	}
+
+	if i+1 < end && tokens[i].value == "AS" {
+		s.name = strings.ToLower(tokens[i+1].raw)
+		i += 2
+	} else if i < end && isAliasName(tokens[i]) {
+		s.name = strings.ToLower(tokens[i].raw)
+		i++
+	}
+	// 列别名列表使结果列名与来源字段无关
+	if i < end && tokens[i].value == "(" {
+		s.table, s.query = "", nil
+		i = skipParens(tokens, i)
+	}
+	return s, i
+}
+
+// selectItemEnd 从 start 开始的选择项之后的位置：同一层的逗号、子句关键字或 end
+func selectItemEnd(tokens []sqlToken, start, end int) int {
+	depth := 0
+	for i := start; i < end; i++ {
+		switch tok := tokens[i]; {
+		case tok.value == "(":
+			depth++
+		case tok.value == ")":
+			depth--
+		case depth > 0:
+		case tok.value == ",", tok.kind == tokenWord && (lineageClauses[tok.value] || setOperators[tok.value]):
+			return i
+		}
+	}
+	return end
+}
+
+// parseSelectItem 分析 tokens[start:end] 中的选择项：[表.]字段 [[AS] 别名]、[表.]*，其他为表达式
+func parseSelectItem(tokens []sqlToken, start, end int) selectItem {
+	var parts []string
+	i := start
+	for i < end {
+		tok := tokens[i]
+		switch {
+		case isIdentifier(tok):
+			parts = append(parts, strings.ToLower(tok.raw))
+			i++
+		case tok.value == "[" && i+2 < end && tokens[i+2].value == "]":
+			parts = append(parts, strings.ToLower(tokens[i+1].raw))
+			i += 3
+		case tok.kind == tokenPunct && tok.value == "*":
+			parts = append(parts, "*")
+			i++
+		default:
+			parts = nil
+		}
+		if parts == nil || parts[len(parts)-1] == "*" || i >= end || tokens[i].value != "." {
+			break
+		}
+		i++
+	}
+
+	if len(parts) > 0 {
+		item := selectItem{column: parts[len(parts)-1], name: parts[len(parts)-1]}
+		if len(parts) > 1 {
+			item.qualifier = parts[len(parts)-2]
+		}
+		if i == end {
+			return item
+		}
+		if item.column != "*" {
+			if tokens[i].value == "AS" {
+				i++
+			}
+			if i == end-1 && isAliasName(tokens[i]) {
+				item.name = strings.ToLower(tokens[i].raw)
+				return item
+			}
+		}
+	}
+
+	// 表达式的结果列名为 AS 之后的别名，没有时由数据库生成
+	if end-start >= 2 && tokens[end-2].value == "AS" {
+		return selectItem{name: strings.ToLower(tokens[end-1].raw)}
+	}
+	return selectItem{}
+}
+
+// trace 把结果列 column（小写）可能来自的基本表加入 out，无法确定时加入 all 中的所有表
+func (q *sourceQuery) trace(column string, all, out map[string]bool) {
+	if q.opaque {
+		addTables(out, all)
+		return
+	}
+	named := false
+	for _, item := range q.items {
+		switch {
+		case item.column == "*":
+			named = true
+			q.traceSources(item.qualifier, column, all, out)
+		case item.name == column:
+			named = true
+			if item.column == "" {
+				addTables(out, all)
+			} else {
+				q.traceSources(item.qualifier, item.column, all, out)
+			}
+		}
+	}
+	// 没有别名的表达式，结果列名由数据库生成
+	if !named {
+		addTables(out, all)
+	}
+}
+
+// traceSources 在名为 qualifier（为空时为所有来源）的来源中追溯字段 column
+func (q *sourceQuery) traceSources(qualifier, column string, all, out map[string]bool) {
+	found := false
+	for _, s := range q.sources {
+		if qualifier != "" && s.name != qualifier && s.table != qualifier {
+			continue
+		}
+		found = true
+		switch {
+		case s.query != nil:
+			s.query.trace(column, all, out)
+		case s.table != "":
+			out[s.table] = true
+		default:
+			addTables(out, all)
+		}
+	}
+	// 外层查询的字段或无法识别的前缀
+	if !found {
+		addTables(out, all)
+	}
+}
+
+// addTables 把 tables 中的名称加入 out
+func addTables(out, tables map[string]bool) {
+	for name := range tables {
+		out[name] = true
+	}
+}
@@ -1,88 +1,469 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"hello-mcp-server/config"
-
-	_ "github.com/go-sql-driver/mysql"
 )
 
 // DatabaseManager 数据库管理器
 type DatabaseManager struct {
-	config *config.DatabaseConfig
-	db     *sql.DB
+	config  *config.DatabaseConfig
+	dialect Dialect
+	db      *sql.DB
+	// 串行化 Connect 和 Close，避免并发的调用互相替换连接池
+	connMu sync.Mutex
+
+	// 跨工具调用的事务句柄
+	txMu         sync.Mutex
+	transactions map[string]*transaction
+	finished     map[string]finishedTransaction
+
+	// 缓存的表结构快照
+	schemaMu    sync.Mutex
+	schemaCache *SchemaSnapshot
+
+	// 查询结果脱敏，没有规则时为nil
+	redactor    *Redactor
+	redactorErr error
+
+	// 表和字段访问控制，没有配置时为nil
+	access    *AccessPolicy
+	accessErr error
+	// 连接后的默认库（或 schema），未限定 schema 的表名属于它
+	currentSchema string
 }
 
 // QueryResult 查询结果结构
 type QueryResult struct {
-	Columns []string        `json:"columns"`
-	Rows    [][]interface{} `json:"rows"`
-	Count   int             `json:"count"`
-	Error   string          `json:"error,omitempty"`
+	Columns     []string        `json:"columns"`
+	ColumnTypes []ColumnType    `json:"column_types,omitempty"`
+	Rows        [][]interface{} `json:"rows"`
+	Count       int             `json:"count"`
+	Error       string          `json:"error,omitempty"`
+
+	// 本页第一行在完整结果中的位置
+	Offset int `json:"offset"`
+	// 结果未读完时为 true，NextOffset 为下一页的 offset
+	Truncated  bool `json:"truncated,omitempty"`
+	NextOffset int  `json:"next_offset,omitempty"`
+	// 截断原因：limit（达到每页行数）或 max_bytes（达到字节上限）
+	TruncatedBy string `json:"truncated_by,omitempty"`
+
+	// 被代价检查拒绝时的原因和执行计划
+	CostViolation *CostViolation `json:"cost_violation,omitempty"`
+
+	// 按脱敏规则处理过的列
+	Redaction *RedactionReport `json:"redaction,omitempty"`
+}
+
+// 截断原因
+const (
+	TruncatedByLimit    = "limit"
+	TruncatedByMaxBytes = "max_bytes"
+)
+
+// Page 结果分页：跳过 Offset 行后最多返回 Limit 行，Limit 为0时使用默认值
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+// scanLimits 读取结果时实际生效的限制
+type scanLimits struct {
+	offset   int
+	limit    int
+	maxBytes int
+	// 查询超时，0 表示不限制
+	timeout time.Duration
+}
+
+// 客户端超时比服务端语句超时多等待的时间，优先返回数据库给出的超时错误
+const statementTimeoutGrace = time.Second
+
+// ExecResult 修改语句执行结果
+type ExecResult struct {
+	RowsAffected int64          `json:"rows_affected"`
+	LastInsertID *int64         `json:"last_insert_id,omitempty"`
+	DryRun       bool           `json:"dry_run,omitempty"`
+	Class        StatementClass `json:"class"`
+	Error        string         `json:"error,omitempty"`
 }
 
 // NewDatabaseManager 创建数据库管理器
 func NewDatabaseManager(cfg *config.DatabaseConfig) *DatabaseManager {
+	// 不支持的驱动、无效的脱敏规则和访问控制在 Connect 时报错
+	dialect, _ := NewDialect(cfg.Driver)
+	redactor, redactorErr := NewRedactor(cfg.Redaction)
+	access, accessErr := NewAccessPolicy(cfg.Access)
+
 	return &DatabaseManager{
-		config: cfg,
+		config:      cfg,
+		dialect:     dialect,
+		redactor:    redactor,
+		redactorErr: redactorErr,
+		access:      access,
+		accessErr:   accessErr,
 	}
 }
 
-// Connect 连接数据库
+// Dialect 获取当前驱动对应的SQL方言，驱动不受支持时为nil
+func (dm *DatabaseManager) Dialect() Dialect {
+	return dm.dialect
+}
+
+// Connect 连接数据库。已有可用的连接时直接返回，重新连接前关闭旧的连接池
 func (dm *DatabaseManager) Connect() error {
+	dm.connMu.Lock()
+	defer dm.connMu.Unlock()
+
+	if dm.IsConnected() {
+		return nil
+	}
+
 	if !dm.config.IsValid() {
 		return fmt.Errorf("invalid database configuration")
 	}
 
-	dsn := dm.config.GetDSN()
-	log.Printf("Connecting to database: %s@%s:%d/%s",
-		dm.config.User, dm.config.Host, dm.config.Port, dm.config.Name)
+	if dm.dialect == nil {
+		return fmt.Errorf("unsupported database driver: %q", dm.config.Driver)
+	}
+
+	// 脱敏规则无效时拒绝连接，避免返回未脱敏的数据
+	if dm.redactorErr != nil {
+		return fmt.Errorf("invalid redaction configuration: %v", dm.redactorErr)
+	}
+	if dm.accessErr != nil {
+		return fmt.Errorf("invalid access configuration: %v", dm.accessErr)
+	}
+
+	// 重新连接后旧连接上的事务不再可用
+	dm.RollbackAll("database reconnected")
+	dm.InvalidateSchemaCache()
+	if dm.db != nil {
+		dm.db.Close()
+		dm.db = nil
+	}
+
+	log.Printf("Connecting to database: %s", dm.config.GetLocation())
 
 	var err error
-	dm.db, err = sql.Open(dm.config.Driver, dsn)
+	dm.db, err = dm.dialect.Open(dm.config)
 	if err != nil {
-		return fmt.Errorf("failed to open database connection: %v", err)
+		return err
 	}
 
 	// 设置连接池参数
-	dm.db.SetMaxOpenConns(25)
-	dm.db.SetMaxIdleConns(5)
-	dm.db.SetConnMaxLifetime(5 * time.Minute)
+	pool := dm.PoolSettings()
+	dm.db.SetMaxOpenConns(pool.MaxOpenConns)
+	dm.db.SetMaxIdleConns(pool.MaxIdleConns)
+	dm.db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	dm.db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
 
 	// 测试连接
-	if err := dm.db.Ping(); err != nil {
+	ctx := context.Background()
+	if timeout := dm.config.Timeout.Connect; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := dm.db.PingContext(ctx); err != nil {
 		return fmt.Errorf("failed to ping database: %v", err)
 	}
 
+	// 访问控制按 schema 判断未限定的表名
+	if dm.access != nil {
+		if dm.currentSchema, err = dm.dialect.CurrentSchema(dm.db); err != nil {
+			return fmt.Errorf("failed to get current schema: %v", err)
+		}
+	}
+
 	log.Printf("Successfully connected to database: %s", dm.config.Name)
 	return nil
 }
 
-// Close 关闭数据库连接
+// PoolSettings 实际生效的连接池参数，时长为0表示不限制
+func (dm *DatabaseManager) PoolSettings() config.PoolConfig {
+	// SQLite 同一时刻只允许一个写入者，内存数据库的每个连接还是独立的库，连接不能被关闭重建
+	if dm.config.IsFileBased() {
+		return config.PoolConfig{MaxOpenConns: 1, MaxIdleConns: 1}
+	}
+
+	pool := dm.config.Pool
+	return config.PoolConfig{
+		MaxOpenConns:    pool.GetMaxOpenConns(),
+		MaxIdleConns:    pool.GetMaxIdleConns(),
+		ConnMaxLifetime: pool.GetConnMaxLifetime(),
+		ConnMaxIdleTime: pool.ConnMaxIdleTime,
+	}
+}
+
+// Stats 连接池统计，未连接时返回nil
+func (dm *DatabaseManager) Stats() *sql.DBStats {
+	if dm.db == nil {
+		return nil
+	}
+	stats := dm.db.Stats()
+	return &stats
+}
+
+// Close 回滚未结束的事务并关闭数据库连接
 func (dm *DatabaseManager) Close() error {
+	dm.connMu.Lock()
+	defer dm.connMu.Unlock()
+
+	dm.RollbackAll("session ended")
+
 	if dm.db != nil {
 		return dm.db.Close()
 	}
 	return nil
 }
 
-// ExecuteQuery 执行查询（修复方法接收器）
-func (dm *DatabaseManager) ExecuteQuery(query string) *QueryResult {
+// ExecuteQuery 执行查询并返回一页结果，args 按占位符顺序绑定
+func (dm *DatabaseManager) ExecuteQuery(query string, page Page, args ...interface{}) *QueryResult {
+	return dm.ExecuteQueryContext(context.Background(), query, page, args...)
+}
+
+// ExecuteQueryContext 同 ExecuteQuery，ctx 取消时中断正在执行的查询
+func (dm *DatabaseManager) ExecuteQueryContext(ctx context.Context, query string, page Page, args ...interface{}) *QueryResult {
 	if dm.db == nil {
 		return &QueryResult{
 			Error: "Database not connected",
 		}
 	}
 
+	if err := dm.CheckStatement(query); err != nil {
+		return &QueryResult{
+			Error: err.Error(),
+		}
+	}
+
+	if err := dm.checkConnectionFree(); err != nil {
+		return &QueryResult{
+			Error: err.Error(),
+		}
+	}
+
+	// 只读模式下在只读事务中执行，拦截分类无法识别的写操作（如有副作用的函数）
+	var q Queryer = dm.db
+	if dm.config.ReadOnly && dm.dialect.SupportsReadOnlyTx() {
+		tx, err := dm.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return &QueryResult{
+				Error: fmt.Sprintf("Failed to begin read-only transaction: %v", err),
+			}
+		}
+		defer tx.Rollback()
+		q = tx
+	}
+
+	hidden, err := dm.checkAccess(q, query)
+	if err != nil {
+		return &QueryResult{
+			Error: err.Error(),
+		}
+	}
+
+	if err := dm.CheckRedaction(query); err != nil {
+		return &QueryResult{
+			Error: err.Error(),
+		}
+	}
+
+	if err := dm.checkCost(q, query, args...); err != nil {
+		return costGuardResult(err)
+	}
+
+	result := runQuery(ctx, q, query, dm.scanLimits(page), args...)
+	if !dm.config.ReadOnly && ClassifyStatement(query).Class == StatementDDL {
+		dm.InvalidateSchemaCache()
+	}
+	hideColumns(result, hidden)
+	dm.redactor.Redact(query, result)
+	return result
+}
+
+// costGuardResult 将代价检查的错误转换为查询结果
+func costGuardResult(err error) *QueryResult {
+	result := &QueryResult{
+		Error: err.Error(),
+	}
+	if violation, ok := err.(*CostViolation); ok {
+		result.CostViolation = violation
+	}
+	return result
+}
+
+// scanLimits 按配置修正分页参数，limit 不超过 max_rows
+func (dm *DatabaseManager) scanLimits(page Page) scanLimits {
+	limits := scanLimits{
+		offset:   page.Offset,
+		limit:    page.Limit,
+		maxBytes: dm.config.Query.GetMaxBytes(),
+		timeout:  dm.config.Query.Timeout,
+	}
+
+	if limits.offset < 0 {
+		limits.offset = 0
+	}
+	if limits.limit <= 0 {
+		limits.limit = dm.config.Query.GetDefaultLimit()
+	}
+	if maxRows := dm.config.Query.GetMaxRows(); limits.limit > maxRows {
+		limits.limit = maxRows
+	}
+	return limits
+}
+
+// ExecuteStatement 执行修改语句并返回影响行数；dryRun 时在事务中执行后回滚
+func (dm *DatabaseManager) ExecuteStatement(query string, dryRun bool, args ...interface{}) *ExecResult {
+	return dm.ExecuteStatementContext(context.Background(), query, dryRun, args...)
+}
+
+// ExecuteStatementContext 同 ExecuteStatement，ctx 取消时中断正在执行的语句
+func (dm *DatabaseManager) ExecuteStatementContext(ctx context.Context, query string, dryRun bool, args ...interface{}) *ExecResult {
+	if dm.db == nil {
+		return &ExecResult{
+			Error: "Database not connected",
+		}
+	}
+
+	if !dm.config.ExecuteEnabled() {
+		return &ExecResult{
+			Error: "execute is disabled by configuration (allow_execute is false or read_only is true)",
+		}
+	}
+
+	// 事务控制语句会破坏演练的回滚
+	if stmt := FindTransactionControl(query); stmt != nil {
+		return &ExecResult{
+			Class: stmt.Class,
+			Error: fmt.Sprintf("transaction control statement %s is not allowed here", stmt.Keyword),
+		}
+	}
+
+	if err := dm.checkConnectionFree(); err != nil {
+		return &ExecResult{
+			Error: err.Error(),
+		}
+	}
+
+	if _, err := dm.checkAccess(dm.db, query); err != nil {
+		return &ExecResult{
+			Error: err.Error(),
+		}
+	}
+
+	stmt := ClassifyStatement(query)
+
+	if !dryRun {
+		result, err := dm.db.ExecContext(ctx, query, args...)
+		if stmt.Class == StatementDDL {
+			dm.InvalidateSchemaCache()
+		}
+		if err != nil {
+			return &ExecResult{
+				Class: stmt.Class,
+				Error: fmt.Sprintf("Statement execution failed: %v", err),
+			}
+		}
+		return newExecResult(result, stmt, false)
+	}
+
+	// DDL 在不支持事务性DDL的数据库上会隐式提交，无法演练
+	if stmt.Class == StatementDDL && !dm.dialect.SupportsTransactionalDDL() {
+		return &ExecResult{
+			Class: stmt.Class,
+			Error: fmt.Sprintf("dry run is not possible for %s on %s: DDL commits implicitly", stmt.Keyword, dm.dialect.Name()),
+		}
+	}
+
+	tx, err := dm.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &ExecResult{
+			Class: stmt.Class,
+			Error: fmt.Sprintf("Failed to begin transaction: %v", err),
+		}
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return &ExecResult{
+			Class: stmt.Class,
+			Error: fmt.Sprintf("Statement execution failed: %v", err),
+		}
+	}
+	return newExecResult(result, stmt, true)
+}
+
+// newExecResult 读取影响行数和自增ID。
+// 部分驱动（如SQLite）按连接记录最近一次的值，因此DDL不报告影响行数，
+// 只有 INSERT/REPLACE 报告自增ID
+func newExecResult(result sql.Result, stmt Statement, dryRun bool) *ExecResult {
+	execResult := &ExecResult{
+		DryRun: dryRun,
+		Class:  stmt.Class,
+	}
+
+	if stmt.Class != StatementDDL {
+		if affected, err := result.RowsAffected(); err == nil {
+			execResult.RowsAffected = affected
+		}
+	}
+	if stmt.Keyword == "INSERT" || stmt.Keyword == "REPLACE" {
+		if id, err := result.LastInsertId(); err == nil && id > 0 {
+			execResult.LastInsertID = &id
+		}
+	}
+	return execResult
+}
+
+// CheckStatement 按配置检查语句能否通过 database_query 执行：
+// 只读模式或未开启 allow_execute 时只允许只读语句，修改语句需开启 allow_execute
+func (dm *DatabaseManager) CheckStatement(query string) error {
+	if dm.config.ReadOnly {
+		return CheckReadOnly(query)
+	}
+	if !dm.config.ExecuteEnabled() {
+		return checkReadOnlySQL(query, "allow_execute is false")
+	}
+	return nil
+}
+
+// CheckRedaction 检查查询结果中的脱敏字段能否按列名追溯，见 Redactor.CheckQuery
+func (dm *DatabaseManager) CheckRedaction(query string) error {
+	return dm.redactor.CheckQuery(query)
+}
+
+// IsReadOnly 是否处于只读模式
+func (dm *DatabaseManager) IsReadOnly() bool {
+	return dm.config.ReadOnly
+}
+
+// runQuery 执行查询并读取一页结果。
+// 跳过的行不会保存，达到行数或字节上限后停止读取，剩余结果不会载入内存
+func runQuery(ctx context.Context, q Queryer, query string, limits scanLimits, args ...interface{}) *QueryResult {
+	// 服务端不支持语句超时（如 SQLite）时由客户端中断查询
+	queryCtx := ctx
+	if limits.timeout > 0 {
+		var cancel context.CancelFunc
+		queryCtx, cancel = context.WithTimeout(ctx, limits.timeout+statementTimeoutGrace)
+		defer cancel()
+	}
+
 	// 执行查询
-	rows, err := dm.db.Query(query)
+	rows, err := q.QueryContext(queryCtx, query, args...)
 	if err != nil {
 		return &QueryResult{
-			Error: fmt.Sprintf("Query execution failed: %v", err),
+			Error: fmt.Sprintf("Query execution failed: %v", timeoutError(ctx, queryCtx, err, limits.timeout)),
 		}
 	}
 	defer rows.Close()
@@ -95,8 +476,18 @@ func (dm *DatabaseManager) ExecuteQuery(query string) *QueryResult {
 		}
 	}
 
+	// 获取列类型，驱动不支持时按值推断
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		columnTypes = nil
+	}
+
 	// 准备结果容器
-	var resultRows [][]interface{}
+	result := &QueryResult{
+		Columns:     columns,
+		ColumnTypes: newColumnTypes(columns, columnTypes),
+		Offset:      limits.offset,
+	}
 	values := make([]interface{}, len(columns))
 	valuePtrs := make([]interface{}, len(columns))
 
@@ -105,31 +496,78 @@ func (dm *DatabaseManager) ExecuteQuery(query string) *QueryResult {
 	}
 
 	// 读取数据
+	skipped := 0
+	size := 0
 	for rows.Next() {
+		if skipped < limits.offset {
+			skipped++
+			continue
+		}
+
+		if len(result.Rows) >= limits.limit {
+			result.Truncated = true
+			result.TruncatedBy = TruncatedByLimit
+			break
+		}
+
 		if err := rows.Scan(valuePtrs...); err != nil {
 			return &QueryResult{
 				Error: fmt.Sprintf("Failed to scan row: %v", err),
 			}
 		}
 
-		// 复制值到新切片
+		// 按列类型转换值
 		row := make([]interface{}, len(columns))
+		rowSize := 0
 		for i, v := range values {
-			row[i] = v
+			row[i] = convertValue(result.ColumnTypes[i].Kind, v)
+			rowSize += valueSize(v)
 		}
-		resultRows = append(resultRows, row)
+
+		// 至少返回一行，保证分页能够前进
+		if size+rowSize > limits.maxBytes && len(result.Rows) > 0 {
+			result.Truncated = true
+			result.TruncatedBy = TruncatedByMaxBytes
+			break
+		}
+		size += rowSize
+		result.Rows = append(result.Rows, row)
 	}
 
 	if err := rows.Err(); err != nil {
 		return &QueryResult{
-			Error: fmt.Sprintf("Error during rows iteration: %v", err),
+			Error: fmt.Sprintf("Error during rows iteration: %v", timeoutError(ctx, queryCtx, err, limits.timeout)),
 		}
 	}
 
-	return &QueryResult{
-		Columns: columns,
-		Rows:    resultRows,
-		Count:   len(resultRows),
+	result.Count = len(result.Rows)
+	if result.Truncated {
+		result.NextOffset = limits.offset + result.Count
+	}
+	return result
+}
+
+// timeoutError 语句超时中断时给出明确的原因；调用方的 ctx 取消时保留原错误
+func timeoutError(ctx, queryCtx context.Context, err error, timeout time.Duration) error {
+	if ctx.Err() == nil && queryCtx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("query exceeded the statement timeout of %v", timeout)
+	}
+	return err
+}
+
+// valueSize 估算单个值占用的字节数
+func valueSize(value interface{}) int {
+	switch v := value.(type) {
+	case nil:
+		return 4
+	case []byte:
+		return len(v)
+	case string:
+		return len(v)
+	case time.Time:
+		return 25
+	default:
+		return 8
 	}
 }
 
@@ -139,38 +577,145 @@ func (dm *DatabaseManager) GetTableInfo() ([]string, error) {
 		return nil, fmt.Errorf("database not connected")
 	}
 
-	var tables []string
-	query := "SHOW TABLES"
-	rows, err := dm.db.Query(query)
+	if err := dm.checkConnectionFree(); err != nil {
+		return nil, err
+	}
+
+	tables, err := dm.dialect.ListTables(dm.db)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query tables: %v", err)
+		return nil, err
 	}
-	defer rows.Close()
+	return dm.filterTableNames(tables), nil
+}
 
-	for rows.Next() {
-		var tableName string
-		if err := rows.Scan(&tableName); err != nil {
-			return nil, fmt.Errorf("failed to scan table name: %v", err)
+// GetTableSchema 获取表结构
+func (dm *DatabaseManager) GetTableSchema(tableName string) (*TableSchema, error) {
+	if dm.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	tableName, err := dm.ResolveTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := dm.dialect.DescribeTable(dm.db, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table schema: %v", err)
+	}
+	schema := &TableSchema{
+		Name:    tableName,
+		Columns: columns,
+	}
+
+	if schema.Indexes, err = dm.dialect.DescribeIndexes(dm.db, tableName); err != nil {
+		return nil, fmt.Errorf("failed to get table schema: %v", err)
+	}
+	if schema.Checks, err = dm.dialect.DescribeChecks(dm.db, tableName); err != nil {
+		return nil, fmt.Errorf("failed to get table schema: %v", err)
+	}
+	if schema.Comment, schema.Stats, err = dm.dialect.DescribeTableStats(dm.db, tableName); err != nil {
+		return nil, fmt.Errorf("failed to get table schema: %v", err)
+	}
+
+	keys, err := dm.dialect.ForeignKeys(dm.db, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table schema: %v", err)
+	}
+	for _, key := range keys {
+		if key.Table == tableName {
+			schema.ForeignKeys = append(schema.ForeignKeys, key)
+		}
+		if key.ReferencedTable == tableName {
+			schema.ReferencedBy = append(schema.ReferencedBy, key)
 		}
-		tables = append(tables, tableName)
 	}
 
-	return tables, nil
+	dm.filterTableSchema(schema)
+	return schema, nil
 }
 
-// GetTableSchema 获取表结构
-func (dm *DatabaseManager) GetTableSchema(tableName string) (*QueryResult, error) {
+// GetRelationships 获取当前库（或 schema）中的外键关系图，
+// tableName 不为空时只返回引用该表或被该表引用的外键
+func (dm *DatabaseManager) GetRelationships(tableName string) ([]ForeignKey, error) {
 	if dm.db == nil {
 		return nil, fmt.Errorf("database not connected")
 	}
 
-	query := fmt.Sprintf("DESCRIBE %s", tableName)
-	result := dm.ExecuteQuery(query)
-	if result.Error != "" {
-		return nil, fmt.Errorf("failed to get table schema: %v", result.Error)
+	if tableName != "" {
+		resolved, err := dm.ResolveTable(tableName)
+		if err != nil {
+			return nil, err
+		}
+		tableName = resolved
+	} else if err := dm.checkConnectionFree(); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	keys, err := dm.dialect.ForeignKeys(dm.db, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get relationships: %v", err)
+	}
+	keys = dm.filterForeignKeys(keys)
+	if tableName == "" {
+		return keys, nil
+	}
+
+	var related []ForeignKey
+	for _, key := range keys {
+		if key.Table == tableName || key.ReferencedTable == tableName {
+			related = append(related, key)
+		}
+	}
+	return related, nil
+}
+
+// ResolveTable 校验表名存在于 GetTableInfo 中，返回数据库中的实际名称（大小写不敏感匹配）。
+// 拼接进SQL的表名必须先经过此校验，再用 QuoteTable 引用
+func (dm *DatabaseManager) ResolveTable(tableName string) (string, error) {
+	tables, err := dm.GetTableInfo()
+	if err != nil {
+		return "", err
+	}
+
+	match, ok := matchTableName(tables, tableName)
+	if !ok {
+		return "", fmt.Errorf("table not found: %s", tableName)
+	}
+	return match, nil
+}
+
+// matchTableName 在表名列表中查找名称，优先完全相同的名称，其次是大小写不同的名称
+func matchTableName(tables []string, tableName string) (string, bool) {
+	match := ""
+	for _, table := range tables {
+		if table == tableName {
+			return table, true
+		}
+		if match == "" && strings.EqualFold(table, tableName) {
+			match = table
+		}
+	}
+	return match, match != ""
+}
+
+// QuoteTable 校验表名并返回引用后的标识符，用于内部拼接的语句
+func (dm *DatabaseManager) QuoteTable(tableName string) (string, error) {
+	tableName, err := dm.ResolveTable(tableName)
+	if err != nil {
+		return "", err
+	}
+	return dm.quoteResolvedTable(tableName)
+}
+
+// quoteResolvedTable 按当前 schema 引用 ResolveTable 返回的表名。
+// 表名作为单个标识符引用，其中的点号不会被当作 schema 分隔符
+func (dm *DatabaseManager) quoteResolvedTable(table string) (string, error) {
+	schema, err := dm.dialect.CurrentSchema(dm.db)
+	if err != nil {
+		return "", err
+	}
+	return quoteQualified(dm.dialect, schema, table), nil
 }
 
 // IsConnected 检查是否已连接
@@ -178,5 +723,9 @@ func (dm *DatabaseManager) IsConnected() bool {
 	if dm.db == nil {
 		return false
 	}
+	// SQLite 的唯一连接被事务占用时 Ping 会一直等待
+	if dm.config.IsFileBased() && dm.hasTransactions() {
+		return true
+	}
 	return dm.db.Ping() == nil
 }
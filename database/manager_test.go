@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"hello-mcp-server/config"
+)
+
+// newSQLiteManager 连接内存 SQLite 并执行建表语句
+func newSQLiteManager(t *testing.T, cfg config.DatabaseConfig, setup ...string) *DatabaseManager {
+	t.Helper()
+
+	cfg.Enabled = true
+	cfg.Driver = "sqlite"
+	cfg.Name = ":memory:"
+	cfg.AllowExecute = true
+	dm := NewDatabaseManager(&cfg)
+	if err := dm.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { dm.Close() })
+
+	for _, stmt := range setup {
+		if _, err := dm.db.Exec(stmt); err != nil {
+			t.Fatalf("setup %q: %v", stmt, err)
+		}
+	}
+	return dm
+}
+
+func TestExecuteQueryContextCancelled(t *testing.T) {
+	dm := newSQLiteManager(t, config.DatabaseConfig{}, "CREATE TABLE t (id INTEGER)")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if result := dm.ExecuteQueryContext(ctx, "SELECT * FROM t", Page{}); !strings.Contains(result.Error, "context canceled") {
+		t.Errorf("query error = %q, want context canceled", result.Error)
+	}
+	if result := dm.ExecuteStatementContext(ctx, "INSERT INTO t VALUES (1)", false); !strings.Contains(result.Error, "context canceled") {
+		t.Errorf("statement error = %q, want context canceled", result.Error)
+	}
+	if result := dm.ExecuteQuery("SELECT COUNT(*) FROM t", Page{}); result.Error != "" || result.Rows[0][0] != int64(0) {
+		t.Errorf("cancelled statement changed the table: %+v", result)
+	}
+}
+
+func TestConnectKeepsHealthyPool(t *testing.T) {
+	dm := newSQLiteManager(t, config.DatabaseConfig{}, "CREATE TABLE t (id INTEGER)")
+	db := dm.db
+
+	if err := dm.Connect(); err != nil {
+		t.Fatalf("reconnect: %v", err)
+	}
+	if dm.db != db {
+		t.Fatal("Connect replaced a healthy connection pool")
+	}
+	if result := dm.ExecuteQuery("SELECT * FROM t", Page{}); result.Error != "" {
+		t.Fatalf("table lost after Connect: %s", result.Error)
+	}
+}
+
+func TestConnectClosesBrokenPool(t *testing.T) {
+	dm := newSQLiteManager(t, config.DatabaseConfig{})
+	old := dm.db
+	old.Close()
+
+	if err := dm.Connect(); err != nil {
+		t.Fatalf("reconnect: %v", err)
+	}
+	if dm.db == old {
+		t.Fatal("Connect kept a closed connection pool")
+	}
+	if !dm.IsConnected() {
+		t.Fatal("not connected after Connect")
+	}
+}
@@ -0,0 +1,259 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SchemaSnapshot 整个库（或 schema）的表、字段和外键，用于生成概览。
+// Tables 按表名排序，每张表只包含 Name、Columns 和 ForeignKeys
+type SchemaSnapshot struct {
+	Tables  []TableSchema `json:"tables"`
+	BuiltAt time.Time     `json:"built_at"`
+	// 来自缓存时为 true
+	Cached bool `json:"cached"`
+
+	version string
+}
+
+// OverviewOptions 概览的过滤条件和 token 预算
+type OverviewOptions struct {
+	// 表名通配符，include 为空时包含所有表，exclude 优先
+	Include []string
+	Exclude []string
+	// token 预算（按字符数估算），超出后剩余的表只列出名称
+	MaxTokens int
+}
+
+// Overview 按预算生成的概览
+type Overview struct {
+	// 每张展开的表一行
+	Text string
+	// 通过过滤条件的表数
+	Tables int
+	// 展开字段的表数
+	Detailed int
+	// 超出预算、只列出名称的表
+	Listed []string
+	// 连名称也未能列出的表数
+	Omitted int
+	// 概览的估算 token 数
+	EstimatedTokens int
+}
+
+// Truncated 是否有表因预算未展开
+func (o *Overview) Truncated() bool {
+	return o.Detailed < o.Tables
+}
+
+// SchemaSnapshot 获取表结构快照。快照会被缓存，表结构指纹变化、
+// 通过本服务执行了 DDL 或超过 cache_ttl 后重新读取；refresh 时忽略缓存
+func (dm *DatabaseManager) SchemaSnapshot(refresh bool) (*SchemaSnapshot, error) {
+	if dm.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	if err := dm.checkConnectionFree(); err != nil {
+		return nil, err
+	}
+
+	// 无法获取指纹时只按 cache_ttl 失效
+	version, err := dm.dialect.SchemaVersion(dm.db)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	dm.schemaMu.Lock()
+	defer dm.schemaMu.Unlock()
+
+	if cached := dm.schemaCache; cached != nil && !refresh && cached.version == version &&
+		time.Since(cached.BuiltAt) < dm.config.Overview.GetCacheTTL() {
+		snapshot := *cached
+		snapshot.Cached = true
+		return &snapshot, nil
+	}
+
+	// 访问控制在快照中生效，缓存中不含不可访问的表和字段
+	tables, err := dm.dialect.ListTables(dm.db)
+	if err != nil {
+		return nil, err
+	}
+	tables = dm.filterTableNames(tables)
+	keys, err := dm.dialect.ForeignKeys(dm.db, "")
+	if err != nil {
+		return nil, err
+	}
+	keys = dm.filterForeignKeys(keys)
+
+	snapshot := &SchemaSnapshot{
+		Tables:  make([]TableSchema, 0, len(tables)),
+		BuiltAt: time.Now(),
+		version: version,
+	}
+	for _, table := range tables {
+		columns, err := dm.dialect.DescribeTable(dm.db, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe table %s: %v", table, err)
+		}
+		schema := TableSchema{Name: table, Columns: dm.filterColumns(table, columns)}
+		for _, key := range keys {
+			if key.Table == table {
+				schema.ForeignKeys = append(schema.ForeignKeys, key)
+			}
+		}
+		snapshot.Tables = append(snapshot.Tables, schema)
+	}
+
+	dm.schemaCache = snapshot
+	result := *snapshot
+	return &result, nil
+}
+
+// InvalidateSchemaCache 丢弃缓存的表结构快照
+func (dm *DatabaseManager) InvalidateSchemaCache() {
+	dm.schemaMu.Lock()
+	defer dm.schemaMu.Unlock()
+	dm.schemaCache = nil
+}
+
+// ValidateTablePatterns 检查表名通配符的语法
+func ValidateTablePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid table pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// MatchTablePattern 表名是否匹配任一通配符（大小写不敏感）
+func MatchTablePattern(patterns []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterTables 按 include/exclude 通配符过滤表
+func FilterTables(tables []TableSchema, include, exclude []string) []TableSchema {
+	var filtered []TableSchema
+	for _, table := range tables {
+		if len(include) > 0 && !MatchTablePattern(include, table.Name) {
+			continue
+		}
+		if MatchTablePattern(exclude, table.Name) {
+			continue
+		}
+		filtered = append(filtered, table)
+	}
+	return filtered
+}
+
+// BuildOverview 生成紧凑的表结构概览，每张表一行：
+//
+//	orders(id INTEGER PK, user_id INT NN →users.id, total REAL)
+//
+// PK 主键，UK 唯一，NN 非空，→ 外键引用；多列外键附在行尾。
+// 超出预算后剩余的表只列出名称，名称也放不下时只计数
+func BuildOverview(snapshot *SchemaSnapshot, opts OverviewOptions) (*Overview, error) {
+	if err := ValidateTablePatterns(opts.Include); err != nil {
+		return nil, err
+	}
+	if err := ValidateTablePatterns(opts.Exclude); err != nil {
+		return nil, err
+	}
+
+	tables := FilterTables(snapshot.Tables, opts.Include, opts.Exclude)
+	overview := &Overview{Tables: len(tables)}
+
+	var lines []string
+	used := 0
+	for _, table := range tables {
+		line := overviewLine(table)
+		cost := EstimateTokens(line) + 1
+		if opts.MaxTokens > 0 && used+cost > opts.MaxTokens {
+			break
+		}
+		lines = append(lines, line)
+		used += cost
+		overview.Detailed++
+	}
+
+	for i, table := range tables[overview.Detailed:] {
+		cost := EstimateTokens(table.Name) + 1
+		if opts.MaxTokens > 0 && used+cost > opts.MaxTokens {
+			overview.Omitted = len(tables) - overview.Detailed - i
+			break
+		}
+		overview.Listed = append(overview.Listed, table.Name)
+		used += cost
+	}
+
+	overview.Text = strings.Join(lines, "\n")
+	overview.EstimatedTokens = used
+	return overview, nil
+}
+
+// overviewLine 单张表的概览行
+func overviewLine(table TableSchema) string {
+	// 单列外键标在字段上，多列外键附在行尾
+	references := make(map[string]string)
+	var composite []string
+	for _, key := range table.ForeignKeys {
+		if len(key.Columns) == 1 && len(key.ReferencedColumns) == 1 {
+			references[key.Columns[0]] = key.ReferencedTable + "." + key.ReferencedColumns[0]
+			continue
+		}
+		composite = append(composite, fmt.Sprintf("FK (%s) →%s(%s)",
+			strings.Join(key.Columns, ", "), key.ReferencedTable, strings.Join(key.ReferencedColumns, ", ")))
+	}
+
+	columns := make([]string, 0, len(table.Columns))
+	for _, col := range table.Columns {
+		// SQLite 的字段可以没有类型
+		parts := []string{col.Name}
+		if col.Type != "" {
+			parts = append(parts, col.Type)
+		}
+		switch col.Key {
+		case "PRI":
+			parts = append(parts, "PK")
+		case "UNI":
+			parts = append(parts, "UK")
+		}
+		if !col.Nullable && col.Key != "PRI" {
+			parts = append(parts, "NN")
+		}
+		if ref, ok := references[col.Name]; ok {
+			parts = append(parts, "→"+ref)
+		}
+		columns = append(columns, strings.Join(parts, " "))
+	}
+
+	line := table.Name + "(" + strings.Join(columns, ", ") + ")"
+	if len(composite) > 0 {
+		line += "; " + strings.Join(composite, "; ")
+	}
+	return line
+}
+
+// EstimateTokens 粗略估算文本的 token 数：ASCII 约4个字符一个 token，其他字符各算一个
+func EstimateTokens(text string) int {
+	ascii := 0
+	other := 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
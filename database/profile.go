@@ -0,0 +1,427 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 字段画像的默认值
+const (
+	// DefaultProfileSampleRows 默认抽样的行数
+	DefaultProfileSampleRows = 10000
+	// DefaultProfileTopN 默认列出的高频值个数
+	DefaultProfileTopN = 5
+	// MaxProfileTopN 高频值个数的上限
+	MaxProfileTopN = 50
+)
+
+// 不同值个数的来源
+const (
+	// DistinctExact 对全部数据精确计数
+	DistinctExact = "exact"
+	// DistinctSample 对抽样的行精确计数
+	DistinctSample = "sample"
+	// DistinctStatistics 来自数据库统计信息的估算值
+	DistinctStatistics = "statistics"
+)
+
+// ProfileOptions 字段画像的数据来源和抽样设置
+type ProfileOptions struct {
+	// 表名和查询二选一
+	Table string
+	Query string
+	Args  []interface{}
+	// 只分析这些字段，为空时分析全部
+	Columns []string
+	// 抽样行数，0 时使用默认值；Full 时分析全部行
+	SampleRows int
+	Full       bool
+	// 随机抽样，否则取前 SampleRows 行
+	Random bool
+	// 优先使用统计信息估算不同值个数（仅对表有效）
+	ApproxDistinct bool
+	// 高频值个数，0 时使用默认值
+	TopN int
+}
+
+// ValueCount 值及其出现次数
+type ValueCount struct {
+	Value interface{} `json:"value"`
+	Count int64       `json:"count"`
+}
+
+// ColumnProfile 单个字段的画像
+type ColumnProfile struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+
+	Nulls     int64   `json:"nulls"`
+	NullRatio float64 `json:"null_ratio"`
+	// 不同值个数（不含 NULL）及其来源
+	Distinct       *int64 `json:"distinct,omitempty"`
+	DistinctSource string `json:"distinct_source,omitempty"`
+
+	Min interface{} `json:"min,omitempty"`
+	Max interface{} `json:"max,omitempty"`
+	// 转换为文本后的平均字符数
+	AvgLength *float64 `json:"avg_length,omitempty"`
+
+	// 出现次数最多的非 NULL 值
+	TopValues []ValueCount `json:"top_values,omitempty"`
+	// 最小/最大值和高频值的脱敏方式，未脱敏时为空
+	Redaction string `json:"redaction,omitempty"`
+
+	// 部分统计无法计算时的原因（如类型不支持比较），多个原因以分号分隔
+	Error string `json:"error,omitempty"`
+}
+
+// TableProfile 表或查询结果的字段画像
+type TableProfile struct {
+	// 表名，分析查询时为空
+	Table string `json:"table,omitempty"`
+	// 参与分析的行数
+	Rows int64 `json:"rows"`
+	// 达到抽样行数、可能未覆盖全部数据时为 true
+	Sampled    bool `json:"sampled"`
+	SampleRows int  `json:"sample_rows,omitempty"`
+	Random     bool `json:"random,omitempty"`
+
+	Columns []ColumnProfile `json:"columns"`
+}
+
+// errProfileDropped 字段被脱敏规则从统计查询的结果中删除
+var errProfileDropped = fmt.Errorf("column is hidden by a redaction rule")
+
+// profileColumn 待分析的字段
+type profileColumn struct {
+	name   string
+	typ    string
+	quoted string
+}
+
+// ProfileTable 计算表或查询结果中每个字段的空值比例、不同值个数、最小/最大值、
+// 平均长度和高频值。每项统计都是一条单独的查询，通过 ExecuteQuery 执行，
+// 受只读模式、代价检查和超时限制；某个字段的统计失败不影响其他字段
+func (dm *DatabaseManager) ProfileTable(opts ProfileOptions) (*TableProfile, error) {
+	if dm.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+	if (opts.Table == "") == (opts.Query == "") {
+		return nil, fmt.Errorf("exactly one of table or query is required")
+	}
+
+	if opts.SampleRows <= 0 {
+		opts.SampleRows = DefaultProfileSampleRows
+	}
+	if opts.TopN <= 0 {
+		opts.TopN = DefaultProfileTopN
+	}
+	if opts.TopN > MaxProfileTopN {
+		opts.TopN = MaxProfileTopN
+	}
+
+	profile := &TableProfile{}
+	var rowsSQL string
+	var columns []profileColumn
+	var estimates map[string]int64
+
+	if opts.Table != "" {
+		table, err := dm.ResolveTable(opts.Table)
+		if err != nil {
+			return nil, err
+		}
+		profile.Table = table
+		quoted, err := dm.quoteResolvedTable(table)
+		if err != nil {
+			return nil, err
+		}
+		rowsSQL = "SELECT * FROM " + quoted
+
+		infos, err := dm.dialect.DescribeTable(dm.db, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe table %s: %v", table, err)
+		}
+		for _, info := range infos {
+			// 被脱敏规则删除和访问控制隐藏的列不可见
+			if dm.redactor.Drops(table, info.Name) || !dm.access.ColumnAllowed(table, info.Name) {
+				continue
+			}
+			columns = append(columns, profileColumn{name: info.Name, typ: info.Type})
+		}
+
+		if opts.ApproxDistinct {
+			if estimates, err = dm.dialect.DistinctEstimates(dm.db, table); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		stmt, err := SingleStatement(opts.Query)
+		if err != nil {
+			return nil, err
+		}
+		if stmt.Class != StatementRead || !guardedKeywords[stmt.Keyword] {
+			return nil, fmt.Errorf("profile query must be a single SELECT statement, got %s", stmt.Keyword)
+		}
+		rowsSQL = "SELECT * FROM (" + stmt.Text + ") AS profiled_query"
+
+		// 不读取数据，只获取结果的列
+		probe := dm.ExecuteQuery(rowsSQL+dm.dialect.LimitClause(0, 0), Page{Limit: 1}, opts.Args...)
+		if probe.Error != "" {
+			return nil, fmt.Errorf("%s", probe.Error)
+		}
+		for i, name := range probe.Columns {
+			columns = append(columns, profileColumn{name: name, typ: probe.ColumnTypes[i].DatabaseType})
+		}
+	}
+
+	columns, err := selectProfileColumns(columns, opts.Columns)
+	if err != nil {
+		return nil, err
+	}
+	for i := range columns {
+		columns[i].quoted = quoteName(dm.dialect, columns[i].name)
+	}
+
+	if !opts.Full {
+		if opts.Random {
+			rowsSQL += " ORDER BY " + dm.dialect.RandomOrder()
+		}
+		rowsSQL += dm.dialect.LimitClause(opts.SampleRows, 0)
+	}
+	source := "(" + rowsSQL + ") AS profiled"
+
+	count := dm.ExecuteQuery("SELECT COUNT(*) AS row_count FROM "+source, Page{Limit: 1}, opts.Args...)
+	if count.Error != "" {
+		return nil, fmt.Errorf("%s", count.Error)
+	}
+	if len(count.Rows) > 0 {
+		profile.Rows, _ = profileInt(count.Rows[0][0])
+	}
+	if !opts.Full {
+		profile.Sampled = profile.Rows >= int64(opts.SampleRows)
+		profile.SampleRows = opts.SampleRows
+		profile.Random = opts.Random
+	}
+
+	distinctSource := DistinctExact
+	if profile.Sampled {
+		distinctSource = DistinctSample
+	}
+
+	for _, col := range columns {
+		column := ColumnProfile{Name: col.name, Type: col.typ}
+		estimate, hasEstimate := estimates[col.name]
+		if hasEstimate {
+			column.Distinct = &estimate
+			column.DistinctSource = DistinctStatistics
+		}
+
+		// 部分统计失败时保留其他统计
+		var errs []string
+		if err := dm.profileAggregates(&column, col, source, !hasEstimate, distinctSource, profile.Rows, opts.Args); err != nil {
+			errs = append(errs, err.Error())
+		}
+		if err := dm.profileRange(&column, col, source, opts.Args); err != nil {
+			errs = append(errs, err.Error())
+		}
+		if err := dm.profileTopValues(&column, col, source, opts.TopN, opts.Args); err != nil {
+			errs = append(errs, err.Error())
+		}
+		column.Error = strings.Join(errs, "; ")
+		profile.Columns = append(profile.Columns, column)
+	}
+	return profile, nil
+}
+
+// profileAggregates 计算空值数、不同值个数和平均长度。
+// 类型不支持去重时（如 PostgreSQL 的 json）不计算不同值个数
+func (dm *DatabaseManager) profileAggregates(column *ColumnProfile, col profileColumn, source string, distinct bool, distinctSource string, rows int64, args []interface{}) error {
+	// 聚合结果使用固定的别名，避免按列名匹配的脱敏规则作用于统计值
+	selects := []string{
+		"COUNT(" + col.quoted + ") AS non_null_count",
+		"AVG(" + dm.dialect.TextLength(col.quoted) + ") AS avg_length",
+	}
+	if distinct {
+		selects = append(selects, "COUNT(DISTINCT "+col.quoted+") AS distinct_count")
+	}
+
+	result := dm.ExecuteQuery("SELECT "+strings.Join(selects, ", ")+" FROM "+source, Page{Limit: 1}, args...)
+	var distinctErr string
+	if result.Error != "" && distinct {
+		distinctErr = result.Error
+		result = dm.ExecuteQuery("SELECT "+strings.Join(selects[:2], ", ")+" FROM "+source, Page{Limit: 1}, args...)
+	}
+	if result.Error != "" {
+		return fmt.Errorf("%s", result.Error)
+	}
+	if len(result.Rows) == 0 {
+		return nil
+	}
+	row := result.Rows[0]
+
+	nonNull, _ := profileInt(row[0])
+	column.Nulls = rows - nonNull
+	if rows > 0 {
+		column.NullRatio = float64(column.Nulls) / float64(rows)
+	}
+	if avg, ok := profileFloat(row[1]); ok {
+		column.AvgLength = &avg
+	}
+
+	if distinctErr != "" {
+		return fmt.Errorf("distinct count unavailable: %s", distinctErr)
+	}
+	if distinct {
+		if n, ok := profileInt(row[2]); ok {
+			column.Distinct = &n
+			column.DistinctSource = distinctSource
+		}
+	}
+	return nil
+}
+
+// profileRange 按排序取最小值和最大值，而不是 MIN/MAX 聚合，
+// 使结果保留字段类型（SQLite 的聚合结果没有声明类型），与其他查询结果的格式一致
+func (dm *DatabaseManager) profileRange(column *ColumnProfile, col profileColumn, source string, args []interface{}) error {
+	for _, order := range []string{"ASC", "DESC"} {
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE %s IS NOT NULL ORDER BY %s %s",
+			col.quoted, source, col.quoted, col.quoted, order) + dm.dialect.LimitClause(1, 0)
+		result := dm.ExecuteQuery(query, Page{Limit: 1}, args...)
+		if result.Error != "" {
+			return fmt.Errorf("min/max unavailable: %s", result.Error)
+		}
+		if len(result.Columns) < 1 {
+			return errProfileDropped
+		}
+		if len(result.Rows) == 0 {
+			return nil
+		}
+		if order == "ASC" {
+			column.Min = result.Rows[0][0]
+		} else {
+			column.Max = result.Rows[0][0]
+		}
+		setProfileRedaction(column, result)
+	}
+	return nil
+}
+
+// profileTopValues 列出出现次数最多的非 NULL 值，次数相同时按值排序
+func (dm *DatabaseManager) profileTopValues(column *ColumnProfile, col profileColumn, source string, topN int, args []interface{}) error {
+	query := fmt.Sprintf("SELECT %s, COUNT(*) AS value_count FROM %s WHERE %s IS NOT NULL GROUP BY %s ORDER BY COUNT(*) DESC, %s",
+		col.quoted, source, col.quoted, col.quoted, col.quoted) + dm.dialect.LimitClause(topN, 0)
+	result := dm.ExecuteQuery(query, Page{Limit: topN}, args...)
+	if result.Error != "" {
+		return fmt.Errorf("top values unavailable: %s", result.Error)
+	}
+	if len(result.Columns) < 2 {
+		return errProfileDropped
+	}
+	for _, row := range result.Rows {
+		n, _ := profileInt(row[1])
+		column.TopValues = append(column.TopValues, ValueCount{Value: row[0], Count: n})
+	}
+	setProfileRedaction(column, result)
+	return nil
+}
+
+// setProfileRedaction 记录统计查询结果中该字段的脱敏方式
+func setProfileRedaction(column *ColumnProfile, result *QueryResult) {
+	if result.Redaction == nil {
+		return
+	}
+	for _, redacted := range result.Redaction.Columns {
+		if redacted.Column == result.Columns[0] {
+			column.Redaction = redacted.Action
+		}
+	}
+}
+
+// selectProfileColumns 按名称（大小写不敏感）选出要分析的字段
+func selectProfileColumns(columns []profileColumn, names []string) ([]profileColumn, error) {
+	if len(names) == 0 {
+		return columns, nil
+	}
+
+	selected := make([]profileColumn, 0, len(names))
+	for _, name := range names {
+		found := -1
+		for i, col := range columns {
+			if col.name == name {
+				found = i
+				break
+			}
+			if found < 0 && strings.EqualFold(col.name, name) {
+				found = i
+			}
+		}
+		if found < 0 {
+			return nil, fmt.Errorf("column not found: %s", name)
+		}
+		selected = append(selected, columns[found])
+	}
+	return selected, nil
+}
+
+// profileInt 将聚合结果转换为整数，MySQL 的 DECIMAL 等以字符串返回
+func profileInt(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// profileFloat 将聚合结果转换为浮点数
+func profileFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// ProfileResult 将字段画像转换为查询结果，每个字段一行，高频值格式化为 "值 (次数)" 列表
+func ProfileResult(profile *TableProfile) *QueryResult {
+	result := &QueryResult{
+		Columns: []string{"column", "type", "nulls", "null_ratio", "distinct", "distinct_source",
+			"min", "max", "avg_length", "top_values", "redaction", "error"},
+		Count: len(profile.Columns),
+	}
+	for _, col := range profile.Columns {
+		var distinct, avgLength interface{}
+		if col.Distinct != nil {
+			distinct = *col.Distinct
+		}
+		if col.AvgLength != nil {
+			avgLength = *col.AvgLength
+		}
+		result.Rows = append(result.Rows, []interface{}{
+			col.Name, col.Type, col.Nulls, col.NullRatio, distinct, col.DistinctSource,
+			col.Min, col.Max, avgLength, FormatValueCounts(col.TopValues), col.Redaction, col.Error,
+		})
+	}
+	return result
+}
+
+// FormatValueCounts 将高频值格式化为 "值 (次数), ..." 形式
+func FormatValueCounts(values []ValueCount) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		parts = append(parts, fmt.Sprintf("%s (%d)", FormatValue(v.Value), v.Count))
+	}
+	return strings.Join(parts, ", ")
+}
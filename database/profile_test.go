@@ -0,0 +1,30 @@
+package database
+
+import (
+	"testing"
+
+	"hello-mcp-server/config"
+)
+
+func TestProfileTableNameWithDot(t *testing.T) {
+	dm := newSQLiteManager(t, config.DatabaseConfig{},
+		`CREATE TABLE "a.b" (id INTEGER PRIMARY KEY, name TEXT)`,
+		`INSERT INTO "a.b" (name) VALUES ('x'), ('y')`,
+	)
+
+	quoted, err := dm.QuoteTable("A.B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"main"."a.b"`; quoted != want {
+		t.Errorf("QuoteTable = %s, want %s", quoted, want)
+	}
+
+	profile, err := dm.ProfileTable(ProfileOptions{Table: "a.b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Table != "a.b" || profile.Rows != 2 {
+		t.Errorf("profile = %+v, want 2 rows of a.b", profile)
+	}
+}
@@ -0,0 +1,482 @@
+package database
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"hello-mcp-server/config"
+)
+
+// redactionMask mask 方式替换后的值
+const redactionMask = "****"
+
+// Detector 在文本中查找敏感信息
+type Detector interface {
+	// Name 检测器名称，即规则中 detector 的取值
+	Name() string
+	// Find 返回所有匹配的 [start, end) 位置，按位置排序且互不重叠
+	Find(text string) [][]int
+}
+
+var detectors = map[string]Detector{}
+
+func init() {
+	RegisterDetector(&EmailDetector{})
+	RegisterDetector(&CardNumberDetector{})
+}
+
+// RegisterDetector 注册检测器，同名时覆盖
+func RegisterDetector(d Detector) {
+	detectors[d.Name()] = d
+}
+
+// GetDetector 按名称获取检测器
+func GetDetector(name string) (Detector, error) {
+	d, ok := detectors[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported detector: %q (available: %s)", name, strings.Join(DetectorNames(), ", "))
+	}
+	return d, nil
+}
+
+// DetectorNames 所有已注册的检测器名称
+func DetectorNames() []string {
+	names := make([]string, 0, len(detectors))
+	for name := range detectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EmailDetector 邮箱地址
+type EmailDetector struct{}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+
+func (d *EmailDetector) Name() string {
+	return "email"
+}
+
+func (d *EmailDetector) Find(text string) [][]int {
+	return emailPattern.FindAllStringIndex(text, -1)
+}
+
+// CardNumberDetector 银行卡号：13-19位数字，可用空格或连字符分隔，并通过 Luhn 校验
+type CardNumberDetector struct{}
+
+var cardNumberPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+func (d *CardNumberDetector) Name() string {
+	return "card_number"
+}
+
+func (d *CardNumberDetector) Find(text string) [][]int {
+	var matches [][]int
+	for _, m := range cardNumberPattern.FindAllStringIndex(text, -1) {
+		if luhnValid(text[m[0]:m[1]]) {
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// luhnValid 对数字（忽略分隔符）做 Luhn 校验
+func luhnValid(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		digit := int(c - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// RedactionReport 查询结果中被脱敏的列
+type RedactionReport struct {
+	Columns []RedactedColumn `json:"columns"`
+}
+
+// RedactedColumn 单列的脱敏情况
+type RedactedColumn struct {
+	Column string `json:"column"`
+	Action string `json:"action"`
+	// 匹配的规则，如 "users.email"、"*.phone" 或 "detector:email"
+	Rule string `json:"rule"`
+	// 被替换的值的个数，drop 时为0
+	Values int `json:"values"`
+}
+
+// Redactor 按配置的规则对查询结果脱敏
+type Redactor struct {
+	rules []redactionRule
+	salt  string
+}
+
+// redactionRule 校验后的规则
+type redactionRule struct {
+	config.RedactionRule
+	action   string
+	detector Detector
+}
+
+// NewRedactor 校验规则并创建脱敏器，没有规则时返回nil
+func NewRedactor(cfg config.RedactionConfig) (*Redactor, error) {
+	if len(cfg.Rules) == 0 {
+		return nil, nil
+	}
+
+	r := &Redactor{salt: cfg.HashSalt}
+	for i, rule := range cfg.Rules {
+		compiled := redactionRule{RedactionRule: rule, action: rule.GetAction()}
+		for _, pattern := range []string{rule.Table, rule.Column} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("redaction rule %d: invalid pattern %q: %v", i+1, pattern, err)
+			}
+		}
+
+		switch compiled.action {
+		case config.RedactMask, config.RedactHash, config.RedactPartial, config.RedactDrop:
+		default:
+			return nil, fmt.Errorf("redaction rule %d: unsupported action: %q (available: mask, hash, partial, drop)", i+1, rule.Action)
+		}
+		if compiled.action == config.RedactHash && cfg.HashSalt == "" {
+			return nil, fmt.Errorf("redaction rule %d: hash requires a non-empty hash_salt", i+1)
+		}
+		if rule.RevealFirst < 0 || rule.RevealLast < 0 {
+			return nil, fmt.Errorf("redaction rule %d: reveal_first and reveal_last must not be negative", i+1)
+		}
+
+		if rule.Detector != "" {
+			detector, err := GetDetector(rule.Detector)
+			if err != nil {
+				return nil, fmt.Errorf("redaction rule %d: %v", i+1, err)
+			}
+			if compiled.action == config.RedactDrop {
+				return nil, fmt.Errorf("redaction rule %d: detector rules cannot use drop", i+1)
+			}
+			compiled.detector = detector
+		} else if rule.Table == "" && rule.Column == "" {
+			return nil, fmt.Errorf("redaction rule %d: table, column or detector is required", i+1)
+		}
+		r.rules = append(r.rules, compiled)
+	}
+	return r, nil
+}
+
+// Redact 对查询结果脱敏并在 result.Redaction 中记录。规则的 table 与结果列追溯到的来源表匹配，
+// 每列先应用第一条匹配的整值规则，没有整值规则的列再由匹配的检测器规则替换其中的敏感文本
+func (r *Redactor) Redact(query string, result *QueryResult) {
+	if r == nil || result.Error != "" || len(result.Columns) == 0 {
+		return
+	}
+
+	traced := traceColumns(query)
+	report := &RedactionReport{}
+	var dropped []int
+
+	for i, column := range result.Columns {
+		tables := traced.sources(column)
+		if rule := r.columnRule(tables, column); rule != nil {
+			redacted := RedactedColumn{Column: column, Action: rule.action, Rule: rule.name()}
+			if rule.action == config.RedactDrop {
+				dropped = append(dropped, i)
+			} else {
+				for _, row := range result.Rows {
+					if row[i] == nil {
+						continue
+					}
+					row[i] = r.apply(rule, FormatValue(row[i]))
+					redacted.Values++
+				}
+			}
+			report.Columns = append(report.Columns, redacted)
+			continue
+		}
+
+		for _, rule := range r.detectorRules(tables, column) {
+			redacted := RedactedColumn{Column: column, Action: rule.action, Rule: rule.name()}
+			for _, row := range result.Rows {
+				text, ok := row[i].(string)
+				if !ok {
+					continue
+				}
+				if replaced, n := r.replaceDetected(rule, text); n > 0 {
+					row[i] = replaced
+					redacted.Values++
+				}
+			}
+			if redacted.Values > 0 {
+				report.Columns = append(report.Columns, redacted)
+			}
+		}
+	}
+
+	if len(dropped) > 0 {
+		dropColumns(result, dropped)
+	}
+	if len(report.Columns) > 0 {
+		result.Redaction = report
+	}
+}
+
+// Drops 表中的列是否会被 drop 规则删除
+func (r *Redactor) Drops(table, column string) bool {
+	if r == nil {
+		return false
+	}
+	rule := r.columnRule(map[string]bool{strings.ToLower(table): true}, column)
+	return rule != nil && rule.action == config.RedactDrop
+}
+
+// columnRule 第一条匹配该列的整值规则，tables 为该列的来源表（小写）
+func (r *Redactor) columnRule(tables map[string]bool, column string) *redactionRule {
+	for i := range r.rules {
+		rule := &r.rules[i]
+		if rule.detector == nil && rule.matches(tables, column) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// detectorRules 匹配该列的检测器规则
+func (r *Redactor) detectorRules(tables map[string]bool, column string) []*redactionRule {
+	var rules []*redactionRule
+	for i := range r.rules {
+		rule := &r.rules[i]
+		if rule.detector != nil && rule.matches(tables, column) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// matches 规则的 table 是否匹配任一来源表，column 是否匹配列名
+func (rule *redactionRule) matches(tables map[string]bool, column string) bool {
+	return rule.matchesColumn(column) && rule.matchesTables(tables)
+}
+
+// matchesColumn column 是否匹配列名
+func (rule *redactionRule) matchesColumn(column string) bool {
+	if rule.Column == "" {
+		return true
+	}
+	matched, _ := path.Match(strings.ToLower(rule.Column), strings.ToLower(column))
+	return matched
+}
+
+// matchesTables table 是否匹配任一名称（小写）
+func (rule *redactionRule) matchesTables(names map[string]bool) bool {
+	if rule.Table == "" {
+		return true
+	}
+	pattern := strings.ToLower(rule.Table)
+	for name := range names {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckQuery 检查结果列能否按列名追溯到被整值规则脱敏的字段。Redact 按结果列名匹配规则，
+// 因此拒绝通过别名、表达式、函数、标量子查询、UNION 的后续分支或列别名列表输出这些字段，
+// 标量子查询和 UNION 后续分支中的 *，
+// 以及对相关表的整行引用（如 PostgreSQL 的 SELECT u FROM users u、row_to_json(u)）。
+// COUNT、LENGTH 等只输出数量的函数和 WHERE、ORDER BY 等子句中的引用不受限制
+func (r *Redactor) CheckQuery(query string) error {
+	if r == nil {
+		return nil
+	}
+
+	tables := traceColumns(query).tables
+	var rules []*redactionRule
+	for i := range r.rules {
+		rule := &r.rules[i]
+		if rule.detector == nil && rule.matchesTables(tables) {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	for _, mode := range []lexMode{standardLex, mysqlLex} {
+		for _, stmt := range analyze(query, mode) {
+			if err := checkRedactedSources(stmt.tokens, rules); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkRedactedSources 对单条语句执行 CheckQuery 的检查
+func checkRedactedSources(tokens []sqlToken, rules []*redactionRule) error {
+	l := analyzeLineage(tokens)
+	if l.renamed {
+		return fmt.Errorf("redaction: column alias lists are not allowed in queries on tables with redacted columns")
+	}
+
+	// 可以作为整行引用的名称：规则匹配的表及其别名、CTE 和派生表
+	rows := cteNames(tokens)
+	for name := range l.derived {
+		rows[name] = true
+	}
+	for _, ref := range tableReferences(tokens) {
+		name := map[string]bool{strings.ToLower(ref.name): true}
+		for _, rule := range rules {
+			if rule.matchesTables(name) {
+				rows[strings.ToLower(ref.name)] = true
+				if ref.alias != "" {
+					rows[strings.ToLower(ref.alias)] = true
+				}
+				break
+			}
+		}
+	}
+
+	if ref := wholeRowReference(tokens, l, rows); ref != "" {
+		return fmt.Errorf("redaction: whole-row reference %s is not allowed on tables with redacted columns", ref)
+	}
+
+	for i, tok := range tokens {
+		use := l.uses[i]
+		if tok.kind == tokenPunct && use.use == useExpression {
+			return fmt.Errorf("redaction: * cannot be used in a subquery or UNION branch in the select list on tables with redacted columns; list the columns")
+		}
+		if use.use == useNone || !isIdentifier(tok) {
+			continue
+		}
+		// 函数名和表名前缀
+		if i+1 < len(tokens) && (tokens[i+1].value == "(" || tokens[i+1].value == ".") {
+			continue
+		}
+
+		for _, rule := range rules {
+			if rule.Column == "" && tok.kind == tokenWord && expressionKeywords[tok.value] {
+				continue
+			}
+			if !rule.matchesColumn(tok.raw) {
+				continue
+			}
+			switch {
+			case use.use == useExpression:
+				return fmt.Errorf("redaction: redacted column %s cannot be used in an expression, function, subquery or UNION in the select list; select it as a plain column", tok.raw)
+			case use.use == useAliased && !rule.matchesColumn(use.alias):
+				return fmt.Errorf("redaction: redacted column %s cannot be renamed to %s", tok.raw, use.alias)
+			}
+		}
+	}
+	return nil
+}
+
+// name 规则在报告中的名称
+func (rule *redactionRule) name() string {
+	if rule.detector != nil {
+		return "detector:" + rule.detector.Name()
+	}
+	table, column := rule.Table, rule.Column
+	if table == "" {
+		table = "*"
+	}
+	if column == "" {
+		column = "*"
+	}
+	return table + "." + column
+}
+
+// apply 按规则的方式替换文本
+func (r *Redactor) apply(rule *redactionRule, text string) string {
+	switch rule.action {
+	case config.RedactHash:
+		mac := hmac.New(sha256.New, []byte(r.salt))
+		mac.Write([]byte(text))
+		return "hash:" + hex.EncodeToString(mac.Sum(nil)[:16])
+	case config.RedactPartial:
+		runes := []rune(text)
+		if len(runes) <= rule.RevealFirst+rule.RevealLast {
+			return strings.Repeat("*", len(runes))
+		}
+		hidden := len(runes) - rule.RevealFirst - rule.RevealLast
+		return string(runes[:rule.RevealFirst]) + strings.Repeat("*", hidden) + string(runes[len(runes)-rule.RevealLast:])
+	default:
+		return redactionMask
+	}
+}
+
+// replaceDetected 替换文本中检测到的部分，返回替换后的文本和替换次数
+func (r *Redactor) replaceDetected(rule *redactionRule, text string) (string, int) {
+	matches := rule.detector.Find(text)
+	if len(matches) == 0 {
+		return text, 0
+	}
+
+	var buf strings.Builder
+	last := 0
+	for _, m := range matches {
+		buf.WriteString(text[last:m[0]])
+		buf.WriteString(r.apply(rule, text[m[0]:m[1]]))
+		last = m[1]
+	}
+	buf.WriteString(text[last:])
+	return buf.String(), len(matches)
+}
+
+// dropColumns 删除结果中指定位置的列
+func dropColumns(result *QueryResult, dropped []int) {
+	drop := make(map[int]bool, len(dropped))
+	for _, i := range dropped {
+		drop[i] = true
+	}
+
+	keep := func(n int) []int {
+		var indexes []int
+		for i := 0; i < n; i++ {
+			if !drop[i] {
+				indexes = append(indexes, i)
+			}
+		}
+		return indexes
+	}(len(result.Columns))
+
+	columns := make([]string, 0, len(keep))
+	for _, i := range keep {
+		columns = append(columns, result.Columns[i])
+	}
+	result.Columns = columns
+
+	if len(result.ColumnTypes) > 0 {
+		columnTypes := make([]ColumnType, 0, len(keep))
+		for _, i := range keep {
+			columnTypes = append(columnTypes, result.ColumnTypes[i])
+		}
+		result.ColumnTypes = columnTypes
+	}
+
+	for r, row := range result.Rows {
+		values := make([]interface{}, 0, len(keep))
+		for _, i := range keep {
+			values = append(values, row[i])
+		}
+		result.Rows[r] = values
+	}
+}
@@ -0,0 +1,221 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"hello-mcp-server/config"
+)
+
+// testRedaction users.email 整值掩码，users.ssn 哈希，所有表的 phone 删除
+var testRedaction = config.RedactionConfig{
+	Rules: []config.RedactionRule{
+		{Table: "users", Column: "email"},
+		{Table: "users", Column: "ssn", Action: config.RedactHash},
+		{Column: "phone", Action: config.RedactDrop},
+	},
+	HashSalt: "test-salt",
+}
+
+func TestRedactorCheckQuery(t *testing.T) {
+	r, err := NewRedactor(testRedaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		sql     string
+		allowed bool
+	}{
+		{"plain column", "SELECT email FROM users", true},
+		{"qualified column", "SELECT u.email FROM users u", true},
+		{"quoted column", `SELECT "email" FROM users`, true},
+		{"alias matching rule", "SELECT id, email AS email FROM users", true},
+		{"star", "SELECT * FROM users", true},
+		{"qualified star", "SELECT u.* FROM users u", true},
+		{"filter and order", "SELECT name FROM users WHERE email LIKE '%@x.com' ORDER BY email", true},
+		{"count", "SELECT COUNT(email), COUNT(DISTINCT email) FROM users", true},
+		{"length", "SELECT AVG(length(CAST(email AS TEXT))) AS avg_length FROM users", true},
+		{"derived table", "SELECT s.email FROM (SELECT email FROM users) s", true},
+		{"cte", "WITH x AS (SELECT email FROM users) SELECT email FROM x", true},
+		{"other expression", "SELECT name || '!' AS shout FROM users", true},
+		{"other table", "SELECT upper(email) AS e FROM orders", true},
+		{"subquery filter", "SELECT email FROM users WHERE id IN (SELECT user_id FROM orders)", true},
+		{"exists", "SELECT EXISTS (SELECT email FROM users) AS any_user", true},
+		{"update target", "UPDATE users SET email = 'x' WHERE id = 1", true},
+		{"distinct", "SELECT DISTINCT email FROM users", true},
+		{"distinct on", "SELECT DISTINCT ON (email) email FROM users", true},
+		{"other column aliased as rule column", "SELECT name email FROM users", true},
+		{"rule table name as alias", "SELECT upper(email) AS e FROM orders users", true},
+		{"rule table name as column", "SELECT upper(email) AS e, users FROM orders", true},
+
+		{"alias", "SELECT email AS e FROM users", false},
+		{"alias without as", "SELECT email e FROM users", false},
+		{"qualified alias", "SELECT u.email AS e FROM users u", false},
+		{"concatenation", "SELECT email || '' FROM users", false},
+		{"function", "SELECT upper(email) FROM users", false},
+		{"case", "SELECT CASE WHEN 1 = 1 THEN email END AS x FROM users", false},
+		{"scalar subquery", "SELECT (SELECT email FROM users LIMIT 1) AS x", false},
+		{"union branch", "SELECT name FROM users UNION SELECT email FROM users", false},
+		{"aliased in derived table", "SELECT * FROM (SELECT email AS e FROM users) s", false},
+		{"cte column list", "WITH x(e) AS (SELECT email FROM users) SELECT e FROM x", false},
+		{"table column list", "SELECT e FROM users AS u(i, n, e)", false},
+		{"derived column list", "SELECT e FROM (SELECT email FROM users) AS s(e)", false},
+		{"whole row", "SELECT u FROM users u", false},
+		{"whole row function", "SELECT to_json(u) FROM users u", false},
+		{"whole row by table name", "SELECT row_to_json(users) FROM users", false},
+		{"whole row of derived table", "SELECT row_to_json(t) FROM (SELECT * FROM users) t", false},
+		{"whole row star", "SELECT json_agg(u.*) FROM users u", false},
+		{"executable comment", "SELECT 1 /*!, email AS e */ FROM users", false},
+		{"backtick alias", "SELECT `email` AS e FROM users", false},
+		{"bracket alias", "SELECT [email] AS e FROM users", false},
+		{"returning", "UPDATE users SET name = email RETURNING name", false},
+		{"mysql binary operator", "SELECT BINARY email FROM users", false},
+		{"hash column", "SELECT lower(ssn) FROM users", false},
+		{"rule without table", "SELECT phone AS p FROM contacts", false},
+		{"backslash in double quotes", `SELECT "\" ", email AS e FROM users -- "`, false},
+		{"star in union branch", "SELECT id, name, note FROM orders UNION SELECT * FROM users", false},
+		{"star in scalar subquery", "SELECT (SELECT * FROM (SELECT email FROM users) s) AS x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.CheckQuery(tt.sql)
+			if (err == nil) != tt.allowed {
+				t.Errorf("CheckQuery(%q) = %v, want allowed=%v", tt.sql, err, tt.allowed)
+			}
+		})
+	}
+}
+
+// TestRedactTracesColumnSources 规则按结果列追溯到的来源表匹配，而不是查询中出现的任一名称
+func TestRedactTracesColumnSources(t *testing.T) {
+	r, err := NewRedactor(testRedaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		sql    string
+		masked bool
+	}{
+		{"plain column", "SELECT email FROM users", true},
+		{"backslash in double quotes", `SELECT "\" ", email FROM users -- "`, true},
+		{"quoted table", `SELECT email FROM "Users"`, true},
+		{"qualified column", "SELECT u.email FROM orders o JOIN users u ON u.id = o.user_id", true},
+		{"unqualified column in join", "SELECT email FROM orders o JOIN users u ON u.id = o.user_id", true},
+		{"schema qualified", "SELECT public.users.email FROM public.users", true},
+		{"star", "SELECT * FROM users", true},
+		{"qualified star", "SELECT o.id, u.* FROM orders o, users u", true},
+		{"derived table", "SELECT s.email FROM (SELECT email FROM users) s", true},
+		{"derived star", "SELECT * FROM (SELECT * FROM users LIMIT 10) AS profiled", true},
+		{"cte", "WITH x AS (SELECT * FROM users) SELECT email FROM x", true},
+		{"nested cte", "WITH a AS (SELECT email FROM users), b AS (SELECT * FROM a) SELECT b.email FROM b", true},
+		{"parenthesized union", "(SELECT email FROM users) UNION (SELECT email FROM users)", true},
+		{"other column aliased as rule column", "SELECT name AS email FROM users", true},
+		{"table function", "SELECT email FROM json_each('[]'), users", true},
+
+		{"other table", "SELECT email FROM orders", false},
+		{"other table aliased as rule table", "SELECT email FROM orders AS users", false},
+		{"rule table name as column", "SELECT email, users FROM orders", false},
+		{"rule table name in string", "SELECT email FROM orders WHERE note = 'users'", false},
+		{"other table qualified in join", "SELECT o.email FROM orders o JOIN users u ON u.id = o.user_id", false},
+		{"other table star in join", "SELECT o.*, u.name FROM orders o JOIN users u ON u.id = o.user_id", false},
+		{"rule table in filter", "SELECT email FROM orders WHERE user_id IN (SELECT id FROM users)", false},
+		{"cte shadows rule table", "WITH users AS (SELECT email FROM orders) SELECT email FROM users", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &QueryResult{
+				Columns: []string{"x", "email"},
+				Rows:    [][]interface{}{{"x", "a@example.com"}},
+			}
+			r.Redact(tt.sql, result)
+			if masked := result.Rows[0][1] == redactionMask; masked != tt.masked {
+				t.Errorf("Redact(%q): email = %v, want masked=%v", tt.sql, result.Rows[0][1], tt.masked)
+			}
+		})
+	}
+}
+
+func TestRedactorHash(t *testing.T) {
+	if _, err := NewRedactor(config.RedactionConfig{
+		Rules: []config.RedactionRule{{Column: "ssn", Action: config.RedactHash}},
+	}); err == nil || !strings.Contains(err.Error(), "hash_salt") {
+		t.Fatalf("expected hash_salt error, got %v", err)
+	}
+
+	hash := func(salt, value string) string {
+		r, err := NewRedactor(config.RedactionConfig{
+			Rules:    []config.RedactionRule{{Column: "ssn", Action: config.RedactHash}},
+			HashSalt: salt,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r.apply(&r.rules[0], value)
+	}
+
+	a := hash("one", "123-45-6789")
+	if !strings.HasPrefix(a, "hash:") || len(a) != len("hash:")+32 {
+		t.Errorf("unexpected hash format: %q", a)
+	}
+	if a != hash("one", "123-45-6789") {
+		t.Error("same salt and value must give the same hash")
+	}
+	if a == hash("two", "123-45-6789") {
+		t.Error("different salts must give different hashes")
+	}
+	if a == hash("one", "123-45-6780") {
+		t.Error("different values must give different hashes")
+	}
+}
+
+func TestRedactionAppliedToQueries(t *testing.T) {
+	dm := newSQLiteManager(t, config.DatabaseConfig{Redaction: testRedaction},
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT, ssn TEXT, phone TEXT)",
+		"INSERT INTO users (email, ssn, phone) VALUES ('a@example.com', '123-45-6789', '555-0100')",
+	)
+
+	result := dm.ExecuteQuery("SELECT * FROM users", Page{})
+	if result.Error != "" {
+		t.Fatal(result.Error)
+	}
+	if got := strings.Join(result.Columns, ","); got != "id,email,ssn" {
+		t.Errorf("columns = %s, want id,email,ssn", got)
+	}
+	if row := result.Rows[0]; row[1] != redactionMask || !strings.HasPrefix(row[2].(string), "hash:") {
+		t.Errorf("values not redacted: %v", row)
+	}
+
+	for _, query := range []string{
+		"SELECT email AS e FROM users",
+		"SELECT email || '' AS e FROM users",
+	} {
+		if result := dm.ExecuteQuery(query, Page{}); !strings.Contains(result.Error, "redaction") {
+			t.Errorf("ExecuteQuery(%q) error = %q, want redaction error", query, result.Error)
+		}
+	}
+
+	// 查询模式的字段画像同样受限制，统计查询本身不受影响
+	if _, err := dm.ProfileTable(ProfileOptions{Query: "SELECT email AS e FROM users"}); err == nil || !strings.Contains(err.Error(), "redaction") {
+		t.Errorf("profile with aliased column: error = %v, want redaction error", err)
+	}
+	for _, opts := range []ProfileOptions{{Table: "users"}, {Query: "SELECT email FROM users"}} {
+		profile, err := dm.ProfileTable(opts)
+		if err != nil {
+			t.Fatalf("profile %+v: %v", opts, err)
+		}
+		for _, column := range profile.Columns {
+			if column.Error != "" {
+				t.Errorf("profile %+v: column %s: %s", opts, column.Name, column.Error)
+			}
+			if column.Name == "email" && (column.Redaction != config.RedactMask || column.Min != redactionMask) {
+				t.Errorf("profile %+v: email not redacted: %+v", opts, column)
+			}
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package database
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// 结构搜索的匹配方式
+const (
+	SearchSubstring = "substring"
+	SearchGlob      = "glob"
+	SearchRegex     = "regex"
+)
+
+// 结构搜索的范围
+const (
+	SearchTables   = "tables"
+	SearchColumns  = "columns"
+	SearchComments = "comments"
+)
+
+// DefaultSearchLimit 结构搜索默认最多返回的条数
+const DefaultSearchLimit = 100
+
+// SchemaSearch 结构搜索条件
+type SchemaSearch struct {
+	Pattern string
+	// substring（默认）、glob 或 regex
+	Mode string
+	// tables、columns、comments 的任意组合，为空时搜索全部
+	In            []string
+	CaseSensitive bool
+	// 最多返回的条数，0 表示不限制
+	Limit int
+}
+
+// SchemaMatch 一条搜索结果。表名或表注释命中时 Column 为空
+type SchemaMatch struct {
+	Table   string `json:"table"`
+	Column  string `json:"column,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Comment string `json:"comment,omitempty"`
+	// 命中的位置：table、column、table_comment、column_comment
+	MatchedOn []string `json:"matched_on"`
+}
+
+// SearchResult 结构搜索结果
+type SearchResult struct {
+	Matches []SchemaMatch `json:"matches"`
+	// 超出 Limit 被截断时为 true
+	Truncated bool `json:"truncated"`
+}
+
+// SearchSchema 在表名、字段名和注释中搜索
+func (dm *DatabaseManager) SearchSchema(search SchemaSearch) (*SearchResult, error) {
+	if dm.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	match, err := schemaMatcher(search)
+	if err != nil {
+		return nil, err
+	}
+	tables, columns, comments, err := searchScope(search.In)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dm.checkConnectionFree(); err != nil {
+		return nil, err
+	}
+	schemaColumns, err := dm.dialect.SchemaColumns(dm.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search schema: %v", err)
+	}
+
+	result := &SearchResult{}
+	add := func(m SchemaMatch) bool {
+		if search.Limit > 0 && len(result.Matches) >= search.Limit {
+			result.Truncated = true
+			return false
+		}
+		result.Matches = append(result.Matches, m)
+		return true
+	}
+
+	schemaColumns = dm.filterSchemaColumns(schemaColumns)
+	for i, col := range schemaColumns {
+		// 每张表的第一个字段之前先检查表本身
+		if i == 0 || schemaColumns[i-1].Table != col.Table {
+			var on []string
+			if tables && match(col.Table) {
+				on = append(on, "table")
+			}
+			if comments && col.TableComment != "" && match(col.TableComment) {
+				on = append(on, "table_comment")
+			}
+			if len(on) > 0 && !add(SchemaMatch{Table: col.Table, Comment: col.TableComment, MatchedOn: on}) {
+				break
+			}
+		}
+
+		var on []string
+		if columns && match(col.Column) {
+			on = append(on, "column")
+		}
+		if comments && col.Comment != "" && match(col.Comment) {
+			on = append(on, "column_comment")
+		}
+		if len(on) > 0 && !add(SchemaMatch{
+			Table: col.Table, Column: col.Column, Type: col.Type, Comment: col.Comment, MatchedOn: on,
+		}) {
+			break
+		}
+	}
+	return result, nil
+}
+
+// ValidateSchemaSearch 检查搜索条件的匹配方式、模式语法和范围
+func ValidateSchemaSearch(search SchemaSearch) error {
+	if _, err := schemaMatcher(search); err != nil {
+		return err
+	}
+	_, _, _, err := searchScope(search.In)
+	return err
+}
+
+// schemaMatcher 按匹配方式生成匹配函数，glob 需匹配完整名称，regex 只需部分匹配
+func schemaMatcher(search SchemaSearch) (func(string) bool, error) {
+	if search.Pattern == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+
+	fold := func(s string) string { return s }
+	if !search.CaseSensitive {
+		fold = strings.ToLower
+	}
+
+	switch strings.ToLower(search.Mode) {
+	case "", SearchSubstring:
+		pattern := fold(search.Pattern)
+		return func(s string) bool {
+			return strings.Contains(fold(s), pattern)
+		}, nil
+	case SearchGlob:
+		pattern := fold(search.Pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %v", search.Pattern, err)
+		}
+		return func(s string) bool {
+			matched, _ := path.Match(pattern, fold(s))
+			return matched
+		}, nil
+	case SearchRegex:
+		expr := search.Pattern
+		if !search.CaseSensitive {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex pattern %q: %v", search.Pattern, err)
+		}
+		return re.MatchString, nil
+	default:
+		return nil, fmt.Errorf("unsupported search mode: %q (available: %s, %s, %s)",
+			search.Mode, SearchSubstring, SearchGlob, SearchRegex)
+	}
+}
+
+// searchScope 解析搜索范围，为空时搜索全部
+func searchScope(in []string) (tables, columns, comments bool, err error) {
+	if len(in) == 0 {
+		return true, true, true, nil
+	}
+	for _, scope := range in {
+		switch strings.ToLower(scope) {
+		case SearchTables:
+			tables = true
+		case SearchColumns:
+			columns = true
+		case SearchComments:
+			comments = true
+		default:
+			return false, false, false, fmt.Errorf("unsupported search scope: %q (available: %s, %s, %s)",
+				scope, SearchTables, SearchColumns, SearchComments)
+		}
+	}
+	return tables, columns, comments, nil
+}
+
+// SearchSchemaResult 将结构搜索结果转换为查询结果，每条命中一行
+func SearchSchemaResult(result *SearchResult) *QueryResult {
+	queryResult := &QueryResult{
+		Columns: []string{"table", "column", "type", "comment", "matched_on"},
+		Count:   len(result.Matches),
+	}
+	for _, m := range result.Matches {
+		queryResult.Rows = append(queryResult.Rows, []interface{}{
+			m.Table, m.Column, m.Type, m.Comment, strings.Join(m.MatchedOn, ","),
+		})
+	}
+	return queryResult
+}
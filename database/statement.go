@@ -0,0 +1,523 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StatementClass 语句类别
+type StatementClass string
+
+const (
+	// StatementRead 只读查询：SELECT、SHOW、EXPLAIN、DESCRIBE 等
+	StatementRead StatementClass = "read"
+	// StatementDML 数据修改：INSERT、UPDATE、DELETE 以及加锁读
+	StatementDML StatementClass = "dml"
+	// StatementDDL 结构修改：CREATE、ALTER、DROP、TRUNCATE、GRANT 等
+	StatementDDL StatementClass = "ddl"
+	// StatementTransaction 事务控制：BEGIN、COMMIT、ROLLBACK 等
+	StatementTransaction StatementClass = "transaction"
+	// StatementOther 其他：SET、USE、CALL、LOCK 等
+	StatementOther StatementClass = "other"
+)
+
+// Statement 单条语句的分析结果
+type Statement struct {
+	Text    string         `json:"text"`
+	Keyword string         `json:"keyword"`
+	Class   StatementClass `json:"class"`
+	// 分类依据，如 "WITH ... DELETE"
+	Reason string `json:"reason,omitempty"`
+
+	tokens []sqlToken
+}
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenQuotedIdent
+	tokenNumber
+	tokenPunct
+)
+
+// sqlToken 词法单元，Word 类型的 Value 为大写
+type sqlToken struct {
+	kind  tokenKind
+	value string
+	// 原始文本（Word 保留大小写，引号标识符已去掉引号）
+	raw string
+}
+
+var dmlKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true,
+	"REPLACE": true, "UPSERT": true, "LOAD": true, "COPY": true,
+}
+
+// 可以嵌套在 WITH 或 EXPLAIN ANALYZE 中执行的修改语句
+var nestedDMLKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true,
+}
+
+var ddlKeywords = map[string]bool{
+	"CREATE": true, "ALTER": true, "DROP": true, "TRUNCATE": true, "RENAME": true,
+	"COMMENT": true, "GRANT": true, "REVOKE": true, "REINDEX": true, "CLUSTER": true,
+}
+
+var transactionKeywords = map[string]bool{
+	"BEGIN": true, "START": true, "COMMIT": true, "ROLLBACK": true, "SAVEPOINT": true,
+	"RELEASE": true, "END": true, "ABORT": true, "XA": true,
+}
+
+var readKeywords = map[string]bool{
+	"SELECT": true, "SHOW": true, "DESCRIBE": true, "DESC": true,
+	"VALUES": true, "TABLE": true, "EXPLAIN": true, "WITH": true, "PRAGMA": true,
+}
+
+// lexMode 词法规则：不同数据库对反斜杠转义、# 注释、$$ 引号和可执行注释的处理不同
+type lexMode struct {
+	backslashEscapes bool
+	hashComments     bool
+	dollarQuotes     bool
+	// MySQL 会执行 /*! ... */ 中的内容，按普通SQL分析
+	executableComments bool
+}
+
+var (
+	// 标准SQL / PostgreSQL / SQLite
+	standardLex = lexMode{dollarQuotes: true}
+	// MySQL
+	mysqlLex = lexMode{backslashEscapes: true, hashComments: true, executableComments: true}
+)
+
+// AnalyzeSQL 拆分并分类SQL中的每条语句，忽略注释和空语句
+func AnalyzeSQL(sql string) []Statement {
+	return analyze(sql, standardLex)
+}
+
+func analyze(sql string, mode lexMode) []Statement {
+	var statements []Statement
+	for _, part := range splitTokens(sql, mode) {
+		if len(part.tokens) == 0 {
+			continue
+		}
+		stmt := classify(part.tokens)
+		stmt.Text = strings.TrimSpace(part.text)
+		statements = append(statements, stmt)
+	}
+	return statements
+}
+
+// ClassifyStatement 分类单条语句，包含多条语句时返回限制最严的类别
+func ClassifyStatement(sql string) Statement {
+	statements := AnalyzeSQL(sql)
+	if len(statements) == 0 {
+		return Statement{Class: StatementOther, Reason: "empty statement"}
+	}
+
+	result := statements[0]
+	for _, stmt := range statements[1:] {
+		if classRank(stmt.Class) > classRank(result.Class) {
+			result = stmt
+		}
+	}
+	return result
+}
+
+// SingleStatement 检查SQL只包含一条语句并返回分析结果，两种词法规则下都必须只有一条，
+// 用于需要在语句前拼接 EXPLAIN 等前缀的场景
+func SingleStatement(sql string) (Statement, error) {
+	statements := analyze(sql, standardLex)
+	if len(statements) == 0 {
+		return Statement{}, fmt.Errorf("empty statement")
+	}
+
+	count := len(statements)
+	if n := len(analyze(sql, mysqlLex)); n > count {
+		count = n
+	}
+	if count != 1 {
+		return Statement{}, fmt.Errorf("expected a single statement, got %d", count)
+	}
+	return statements[0], nil
+}
+
+// FindTransactionControl 查找 BEGIN/COMMIT/ROLLBACK 等事务控制语句，同样按两种词法规则分析
+func FindTransactionControl(sql string) *Statement {
+	for _, mode := range []lexMode{standardLex, mysqlLex} {
+		for _, stmt := range analyze(sql, mode) {
+			if stmt.Class == StatementTransaction {
+				return &stmt
+			}
+		}
+	}
+	return nil
+}
+
+// CheckReadOnly 检查SQL是否只包含只读语句。
+// 分别按标准SQL和MySQL的词法规则分析，任一种解释下存在非只读语句都会拒绝，
+// 以防利用引号或注释差异隐藏语句
+func CheckReadOnly(sql string) error {
+	return checkReadOnlySQL(sql, "read-only mode")
+}
+
+// checkReadOnlySQL 同 CheckReadOnly，错误信息以 reason 开头说明拒绝的原因
+func checkReadOnlySQL(sql string, reason string) error {
+	if err := checkReadOnly(analyze(sql, standardLex), reason); err != nil {
+		return err
+	}
+	return checkReadOnly(analyze(sql, mysqlLex), reason)
+}
+
+func checkReadOnly(statements []Statement, reason string) error {
+	if len(statements) == 0 {
+		return fmt.Errorf("empty SQL statement")
+	}
+
+	for i, stmt := range statements {
+		if stmt.Class == StatementRead {
+			continue
+		}
+
+		where := ""
+		if len(statements) > 1 {
+			where = fmt.Sprintf(" (statement %d of %d)", i+1, len(statements))
+		}
+		detail := ""
+		if stmt.Reason != "" {
+			detail = ": " + stmt.Reason
+		}
+		return fmt.Errorf("%s: %s statement is classified as %s%s and is not allowed%s; only SELECT/SHOW/EXPLAIN/DESCRIBE are permitted",
+			reason, stmt.Keyword, stmt.Class, detail, where)
+	}
+	return nil
+}
+
+func classRank(class StatementClass) int {
+	switch class {
+	case StatementRead:
+		return 0
+	case StatementTransaction:
+		return 1
+	case StatementOther:
+		return 2
+	case StatementDML:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// classify 根据首个关键字和语句内容分类
+func classify(tokens []sqlToken) Statement {
+	// 跳过开头的括号，如 "(SELECT ...) UNION (SELECT ...)"
+	start := 0
+	for start < len(tokens) && tokens[start].value == "(" {
+		start++
+	}
+	if start >= len(tokens) || tokens[start].kind != tokenWord {
+		return Statement{Class: StatementOther, Reason: "unrecognized statement", tokens: tokens}
+	}
+
+	keyword := tokens[start].value
+	stmt := Statement{Keyword: keyword, tokens: tokens}
+	rest := tokens[start+1:]
+
+	switch {
+	case keyword == "EXPLAIN":
+		stmt.Class = StatementRead
+		// EXPLAIN ANALYZE（或 ANALYSE）会真正执行语句，按被解释的语句分类
+		if containsWord(rest, "ANALYZE") || containsWord(rest, "ANALYSE") {
+			for i, tok := range rest {
+				if tok.kind == tokenWord && (readKeywords[tok.value] || nestedDMLKeywords[tok.value]) && tok.value != "EXPLAIN" {
+					inner := classify(rest[i:])
+					if inner.Class != StatementRead {
+						stmt.Class = inner.Class
+						stmt.Reason = "EXPLAIN ANALYZE executes " + inner.Keyword
+					}
+					break
+				}
+			}
+		}
+
+	case keyword == "PRAGMA":
+		stmt.Class = StatementRead
+		if containsValue(rest, "=") || (containsValue(rest, "(") && !isIntrospectionPragma(rest)) {
+			stmt.Class = StatementOther
+			stmt.Reason = "PRAGMA assignment"
+		}
+
+	case readKeywords[keyword]:
+		stmt.Class = StatementRead
+		if word := nestedDML(rest); word != "" {
+			stmt.Class = StatementDML
+			stmt.Reason = keyword + " ... " + word
+		} else if isLockingRead(rest) {
+			stmt.Class = StatementDML
+			stmt.Reason = "locking read"
+		} else if hasSelectInto(keyword, rest) {
+			stmt.Class = StatementDDL
+			stmt.Reason = "SELECT ... INTO"
+		}
+
+	case dmlKeywords[keyword]:
+		stmt.Class = StatementDML
+
+	case ddlKeywords[keyword]:
+		stmt.Class = StatementDDL
+
+	case transactionKeywords[keyword]:
+		stmt.Class = StatementTransaction
+
+	case keyword == "SET" && len(rest) > 0 && rest[0].value == "TRANSACTION":
+		stmt.Class = StatementTransaction
+
+	default:
+		stmt.Class = StatementOther
+	}
+
+	return stmt
+}
+
+func containsWord(tokens []sqlToken, word string) bool {
+	for _, tok := range tokens {
+		if tok.kind == tokenWord && tok.value == word {
+			return true
+		}
+	}
+	return false
+}
+
+func containsValue(tokens []sqlToken, value string) bool {
+	for _, tok := range tokens {
+		if tok.kind == tokenPunct && tok.value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// nestedDML 查找嵌套的修改语句（如可写CTE），忽略 FOR UPDATE 和同名函数调用
+func nestedDML(tokens []sqlToken) string {
+	for i, tok := range tokens {
+		if tok.kind != tokenWord || !nestedDMLKeywords[tok.value] {
+			continue
+		}
+		if i > 0 && tokens[i-1].kind == tokenWord && (tokens[i-1].value == "FOR" || tokens[i-1].value == "ON") {
+			continue
+		}
+		if i+1 < len(tokens) && tokens[i+1].value == "(" {
+			continue
+		}
+		return tok.value
+	}
+	return ""
+}
+
+// isLockingRead 判断是否为 FOR UPDATE / FOR SHARE / LOCK IN SHARE MODE
+func isLockingRead(tokens []sqlToken) bool {
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i].kind != tokenWord {
+			continue
+		}
+		switch tokens[i].value {
+		case "FOR":
+			switch tokens[i+1].value {
+			case "UPDATE", "SHARE", "NO", "KEY":
+				return true
+			}
+		case "LOCK":
+			if tokens[i+1].value == "IN" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasSelectInto 判断是否为 SELECT ... INTO（创建表或写出文件）
+func hasSelectInto(keyword string, tokens []sqlToken) bool {
+	if keyword != "SELECT" && keyword != "WITH" {
+		return false
+	}
+	return containsWord(tokens, "INTO")
+}
+
+// isIntrospectionPragma 只读的 PRAGMA 函数，如 table_info(t)
+func isIntrospectionPragma(tokens []sqlToken) bool {
+	for _, tok := range tokens {
+		if tok.kind == tokenWord {
+			return strings.HasSuffix(tok.value, "_INFO") || strings.HasSuffix(tok.value, "_XINFO") ||
+				strings.HasSuffix(tok.value, "_LIST")
+		}
+	}
+	return false
+}
+
+// statementTokens 一条语句的原始文本和词法单元
+type statementTokens struct {
+	text   string
+	tokens []sqlToken
+}
+
+// splitTokens 按顶层分号拆分语句并生成词法单元，
+// 支持 '...'、"..."、`...`、-- 单行注释、/* */ 块注释，以及按 mode 启用的 # 注释、$tag$ 引号
+// 和 /*! */、/*M! */ 可执行注释（去掉注释标记和版本号后，其中的内容与注释外的SQL一样分析）
+func splitTokens(sql string, mode lexMode) []statementTokens {
+	var statements []statementTokens
+	var current []sqlToken
+	stmtStart := 0
+	// 是否在可执行注释中，此时 */ 只是注释的结束标记
+	inExecutable := false
+
+	i := 0
+	for i < len(sql) {
+		c := sql[i]
+
+		switch {
+		case c == ';':
+			statements = append(statements, statementTokens{text: sql[stmtStart:i], tokens: current})
+			current = nil
+			i++
+			stmtStart = i
+
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-', c == '#' && mode.hashComments:
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+
+		case inExecutable && c == '*' && i+1 < len(sql) && sql[i+1] == '/':
+			inExecutable = false
+			i += 2
+
+		case mode.executableComments && executableComment(sql, i) > 0:
+			inExecutable = true
+			i += executableComment(sql, i)
+
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 4
+			}
+
+		case c == '\'':
+			end := scanQuoted(sql, i, '\'', mode.backslashEscapes)
+			current = append(current, sqlToken{kind: tokenString, value: sql[i:end], raw: sql[i:end]})
+			i = end
+
+		case c == '"' || c == '`':
+			// MySQL 默认把 "..." 当作字符串，同样支持反斜杠转义；反引号中没有转义
+			end := scanQuoted(sql, i, c, c == '"' && mode.backslashEscapes)
+			name := sql[i+1 : end]
+			if end > i+1 && sql[end-1] == c {
+				name = sql[i+1 : end-1]
+			}
+			name = strings.ReplaceAll(name, string([]byte{c, c}), string(c))
+			current = append(current, sqlToken{kind: tokenQuotedIdent, value: name, raw: name})
+			i = end
+
+		case c == '$' && mode.dollarQuotes && dollarTag(sql, i) != "":
+			tag := dollarTag(sql, i)
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				end = len(sql)
+			} else {
+				end = i + len(tag) + end + len(tag)
+			}
+			current = append(current, sqlToken{kind: tokenString, value: sql[i:end], raw: sql[i:end]})
+			i = end
+
+		case isWordStart(c):
+			end := i
+			for end < len(sql) && isWordPart(sql[end]) {
+				end++
+			}
+			word := sql[i:end]
+			current = append(current, sqlToken{kind: tokenWord, value: strings.ToUpper(word), raw: word})
+			i = end
+
+		case c >= '0' && c <= '9':
+			end := i
+			for end < len(sql) && (isWordPart(sql[end]) || sql[end] == '.') {
+				end++
+			}
+			current = append(current, sqlToken{kind: tokenNumber, value: sql[i:end], raw: sql[i:end]})
+			i = end
+
+		default:
+			current = append(current, sqlToken{kind: tokenPunct, value: string(c), raw: string(c)})
+			i++
+		}
+	}
+
+	statements = append(statements, statementTokens{text: sql[stmtStart:], tokens: current})
+	return statements
+}
+
+// executableComment 识别 /*!、/*M! 开头的可执行注释及其后的版本号，返回标记的长度，不是时返回0
+func executableComment(sql string, start int) int {
+	var prefix string
+	switch {
+	case strings.HasPrefix(sql[start:], "/*!"):
+		prefix = "/*!"
+	case strings.HasPrefix(sql[start:], "/*M!"):
+		prefix = "/*M!"
+	default:
+		return 0
+	}
+
+	n := len(prefix)
+	for digits := 0; digits < 6 && start+n < len(sql) && sql[start+n] >= '0' && sql[start+n] <= '9'; digits++ {
+		n++
+	}
+	return n
+}
+
+// scanQuoted 返回引号内容结束后的位置，重复引号视为转义
+func scanQuoted(sql string, start int, quote byte, backslash bool) int {
+	i := start + 1
+	for i < len(sql) {
+		switch {
+		case backslash && sql[i] == '\\':
+			i += 2
+		case sql[i] == quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1
+		default:
+			i++
+		}
+	}
+	return len(sql)
+}
+
+// dollarTag 识别 PostgreSQL 的 $tag$ 或 $$ 引号
+func dollarTag(sql string, start int) string {
+	for i := start + 1; i < len(sql); i++ {
+		c := sql[i]
+		if c == '$' {
+			return sql[start : i+1]
+		}
+		if !isWordPart(c) || (i == start+1 && c >= '0' && c <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || c == '@' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isWordPart(c byte) bool {
+	return isWordStart(c) || c == '$' || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,210 @@
+package database
+
+import "testing"
+
+func TestClassifyStatement(t *testing.T) {
+	tests := []struct {
+		sql     string
+		keyword string
+		class   StatementClass
+	}{
+		{"SELECT * FROM t", "SELECT", StatementRead},
+		{"  select 1", "SELECT", StatementRead},
+		{"(SELECT 1) UNION (SELECT 2)", "SELECT", StatementRead},
+		{"SHOW TABLES", "SHOW", StatementRead},
+		{"DESCRIBE t", "DESCRIBE", StatementRead},
+		{"VALUES (1), (2)", "VALUES", StatementRead},
+		{"PRAGMA table_info(t)", "PRAGMA", StatementRead},
+		{"PRAGMA journal_mode = WAL", "PRAGMA", StatementOther},
+		{"INSERT INTO t VALUES (1)", "INSERT", StatementDML},
+		{"REPLACE INTO t VALUES (1)", "REPLACE", StatementDML},
+		{"SELECT * FROM t FOR UPDATE", "SELECT", StatementDML},
+		{"SELECT * FROM t LOCK IN SHARE MODE", "SELECT", StatementDML},
+		{"SELECT * INTO t2 FROM t", "SELECT", StatementDDL},
+		{"CREATE TABLE t (id INT)", "CREATE", StatementDDL},
+		{"TRUNCATE t", "TRUNCATE", StatementDDL},
+		{"BEGIN", "BEGIN", StatementTransaction},
+		{"SET TRANSACTION READ ONLY", "SET", StatementTransaction},
+		{"SET search_path = x", "SET", StatementOther},
+		{"", "", StatementOther},
+
+		// 可写 CTE
+		{"WITH d AS (DELETE FROM t RETURNING id) SELECT * FROM d", "WITH", StatementDML},
+		{"WITH u AS (UPDATE t SET a = 1 RETURNING *) SELECT 1", "WITH", StatementDML},
+		{"WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x", "WITH", StatementDML},
+		{"WITH x AS (SELECT 1) SELECT * FROM x", "WITH", StatementRead},
+		{"SELECT replace(name, 'a', 'b') FROM t", "SELECT", StatementRead},
+		{"INSERT INTO t SELECT 1 ON DUPLICATE KEY UPDATE a = 1", "INSERT", StatementDML},
+
+		// EXPLAIN 的各种写法
+		{"EXPLAIN SELECT * FROM t", "EXPLAIN", StatementRead},
+		{"EXPLAIN DELETE FROM t", "EXPLAIN", StatementRead},
+		{"EXPLAIN ANALYZE SELECT * FROM t", "EXPLAIN", StatementRead},
+		{"EXPLAIN ANALYZE DELETE FROM t", "EXPLAIN", StatementDML},
+		{"EXPLAIN ANALYSE DELETE FROM t", "EXPLAIN", StatementDML},
+		{"explain analyse update t set a = 1", "EXPLAIN", StatementDML},
+		{"EXPLAIN (ANALYZE, BUFFERS) INSERT INTO t VALUES (1)", "EXPLAIN", StatementDML},
+		{"EXPLAIN (ANALYZE) WITH d AS (DELETE FROM t RETURNING 1) SELECT * FROM d", "EXPLAIN", StatementDML},
+
+		// 多条语句取限制最严的类别
+		{"SELECT 1; DELETE FROM t", "DELETE", StatementDML},
+		{"SELECT 1; DROP TABLE t; BEGIN", "DROP", StatementDDL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			stmt := ClassifyStatement(tt.sql)
+			if stmt.Keyword != tt.keyword || stmt.Class != tt.class {
+				t.Errorf("ClassifyStatement(%q) = %s/%s (%s), want %s/%s",
+					tt.sql, stmt.Keyword, stmt.Class, stmt.Reason, tt.keyword, tt.class)
+			}
+		})
+	}
+}
+
+func TestCheckReadOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		allowed bool
+	}{
+		{"select", "SELECT * FROM t", true},
+		{"trailing semicolon", "SELECT 1;", true},
+		{"two selects", "SELECT 1; SELECT 2", true},
+		{"empty", "  ; ", false},
+		{"second statement writes", "SELECT 1; DELETE FROM t", false},
+
+		// 注释
+		{"line comment", "SELECT 1 -- ; DELETE FROM t", true},
+		{"block comment", "SELECT 1 /* ; DELETE FROM t */", true},
+		{"write after block comment", "/* x */ DELETE FROM t", false},
+		{"hash comment hides write in mysql", "SELECT 1 # \n; DELETE FROM t", false},
+		{"mysql executable comment into outfile", "SELECT * FROM t /*!50000 INTO OUTFILE '/tmp/x' */", false},
+		{"mysql executable comment statement", "SELECT 1; /*! DROP TABLE t */", false},
+		{"mysql executable comment semicolon", "SELECT 1 /*! ; DELETE FROM t */", false},
+		{"mariadb executable comment", "SELECT 1 /*M!100100 ; DELETE FROM t */", false},
+		{"executable comment read", "SELECT /*! STRAIGHT_JOIN */ * FROM a, b", true},
+		{"optimizer hint", "SELECT /*+ MAX_EXECUTION_TIME(1000) */ * FROM t", true},
+
+		// 引号
+		{"semicolon in string", "SELECT 'a; DELETE FROM t'", true},
+		{"doubled quote", "SELECT 'it''s; DROP TABLE t'", true},
+		{"backslash escape differs", `SELECT 'a\'; DELETE FROM t; -- '`, false},
+		{"quoted identifier", `SELECT "delete" FROM "update"`, true},
+		{"doubled double quote", `SELECT "a""b; DELETE FROM t" FROM t`, true},
+		{"backslash in double quotes into outfile", `SELECT "\" " INTO OUTFILE '/tmp/x' -- "`, false},
+		{"backslash in double quotes into dumpfile", `SELECT "\" " INTO DUMPFILE '/tmp/x' -- "`, false},
+		{"backslash in double quotes hides delete", `SELECT "\"; DELETE FROM t; -- "`, false},
+		{"backslash in double quotes hides update", `SELECT "\" ", 1; UPDATE t SET a = 1 -- "`, false},
+		{"no escape in backtick", "SELECT `\\`; DELETE FROM t -- `", false},
+		{"backtick identifier", "SELECT `drop` FROM `t;x`", true},
+		{"dollar quote is not a quote in mysql", "SELECT $$; DELETE FROM t$$", false},
+		{"dollar tag", "SELECT $a$; DROP TABLE t $a$", false},
+		{"comment marker in string", "SELECT '/*', 1; DELETE FROM t -- */", false},
+
+		// CTE 与 EXPLAIN
+		{"writable cte", "WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d", false},
+		{"read cte", "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 3) SELECT * FROM n", true},
+		{"explain", "EXPLAIN DELETE FROM t", true},
+		{"explain analyze write", "EXPLAIN ANALYZE DELETE FROM t", false},
+		{"explain analyse write", "EXPLAIN ANALYSE DELETE FROM t", false},
+		{"explain analyze read", "EXPLAIN ANALYZE SELECT 1", true},
+		{"select into", "SELECT * INTO backup FROM t", false},
+		{"locking read", "SELECT * FROM t FOR SHARE", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckReadOnly(tt.sql)
+			if (err == nil) != tt.allowed {
+				t.Errorf("CheckReadOnly(%q) = %v, want allowed=%v", tt.sql, err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestSingleStatement(t *testing.T) {
+	tests := []struct {
+		sql     string
+		wantErr bool
+	}{
+		{"SELECT 1", false},
+		{"SELECT 1;", false},
+		{"SELECT ';'", false},
+		{"SELECT 1; SELECT 2", true},
+		{"", true},
+		{"-- only a comment", true},
+		{"SELECT 1 /*! ; SELECT 2 */", true},
+		{`SELECT 'a\'; SELECT 2 -- '`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			_, err := SingleStatement(tt.sql)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SingleStatement(%q) error = %v, wantErr %v", tt.sql, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFindTransactionControl(t *testing.T) {
+	tests := []struct {
+		sql     string
+		keyword string
+	}{
+		{"SELECT 1", ""},
+		{"COMMIT", "COMMIT"},
+		{"SELECT 1; ROLLBACK", "ROLLBACK"},
+		{"SELECT 1 /*! ; COMMIT */", "COMMIT"},
+		{"SELECT 'COMMIT'", ""},
+		{"START TRANSACTION", "START"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			keyword := ""
+			if stmt := FindTransactionControl(tt.sql); stmt != nil {
+				keyword = stmt.Keyword
+			}
+			if keyword != tt.keyword {
+				t.Errorf("FindTransactionControl(%q) = %q, want %q", tt.sql, keyword, tt.keyword)
+			}
+		})
+	}
+}
+
+func TestSplitTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		mode lexMode
+		want []string
+	}{
+		{"mysql tokenizes content", "SELECT 1 /*!50000 , 2 */", mysqlLex, []string{"SELECT", "1", ",", "2"}},
+		{"standard skips content", "SELECT 1 /*!50000 , 2 */", standardLex, []string{"SELECT", "1"}},
+		{"plain comment skipped", "SELECT 1 /* , 2 */", mysqlLex, []string{"SELECT", "1"}},
+		{"six digit version", "SELECT /*!800000 3 */", mysqlLex, []string{"SELECT", "3"}},
+		{"string inside", "SELECT /*! '*/' */ 1", mysqlLex, []string{"SELECT", "'*/'", "1"}},
+		{"dollar quote in standard", "SELECT $a$ ; */ $a$", standardLex, []string{"SELECT", "$a$ ; */ $a$"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, stmt := range splitTokens(tt.sql, tt.mode) {
+				for _, tok := range stmt.tokens {
+					got = append(got, tok.value)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("tokens = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("tokens = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"hello-mcp-server/config"
+)
+
+// newTLSConfig 根据配置创建 TLS 配置，serverName 用于 verify-full 校验服务器名称
+func newTLSConfig(cfg config.TLSConfig, serverName string) (*tls.Config, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tls client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	// 未指定 CA 时使用系统证书
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tls ca_file: %v", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in tls ca_file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	switch cfg.GetMode() {
+	case config.TLSPrefer, config.TLSRequire:
+		tlsConfig.InsecureSkipVerify = true
+	case config.TLSVerifyCA:
+		// 只验证证书链，不校验服务器名称
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = verifyCertificateChain(tlsConfig.RootCAs)
+	case config.TLSVerifyFull:
+		if serverName == "" {
+			return nil, fmt.Errorf("tls mode verify-full requires server_name or host")
+		}
+		tlsConfig.ServerName = serverName
+	}
+	return tlsConfig, nil
+}
+
+// verifyCertificateChain 验证服务器证书由受信任的 CA 签发，roots 为nil时使用系统证书
+func verifyCertificateChain(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("server did not present a certificate")
+		}
+
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("failed to parse server certificate: %v", err)
+			}
+			certs[i] = cert
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+		})
+		return err
+	}
+}
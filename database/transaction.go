@@ -0,0 +1,430 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// 已结束事务的记录保留时长，用于给出更明确的错误信息
+const finishedTransactionRetention = time.Hour
+
+// TransactionInfo 事务句柄信息
+type TransactionInfo struct {
+	ID         string    `json:"id"`
+	ReadOnly   bool      `json:"read_only"`
+	StartedAt  time.Time `json:"started_at"`
+	LastUsed   time.Time `json:"last_used"`
+	Statements int       `json:"statements"`
+}
+
+// transaction 跨多次工具调用的事务
+type transaction struct {
+	info  TransactionInfo
+	tx    *sql.Tx
+	timer *time.Timer
+	// 正在执行语句时不会被空闲超时回滚
+	busy bool
+	// 执行过 DDL，提交后丢弃缓存的表结构
+	schemaChanged bool
+}
+
+// finishedTransaction 已结束事务的原因
+type finishedTransaction struct {
+	reason string
+	at     time.Time
+}
+
+// BeginTransaction 开启事务并返回句柄，只读模式下开启只读事务
+func (dm *DatabaseManager) BeginTransaction() (*TransactionInfo, error) {
+	if dm.db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	dm.txMu.Lock()
+	defer dm.txMu.Unlock()
+
+	if limit := dm.config.GetMaxTransactions(); len(dm.transactions) >= limit {
+		return nil, fmt.Errorf("too many open transactions (max %d), commit or roll back one first", limit)
+	}
+
+	id, err := newTransactionID()
+	if err != nil {
+		return nil, err
+	}
+
+	readOnly := dm.config.ReadOnly && dm.dialect.SupportsReadOnlyTx()
+	tx, err := dm.db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	now := time.Now()
+	t := &transaction{
+		info: TransactionInfo{
+			ID:        id,
+			ReadOnly:  dm.config.ReadOnly,
+			StartedAt: now,
+			LastUsed:  now,
+		},
+		tx: tx,
+	}
+	t.timer = time.AfterFunc(dm.config.GetTransactionIdleTimeout(), func() {
+		dm.expireTransaction(t)
+	})
+
+	if dm.transactions == nil {
+		dm.transactions = make(map[string]*transaction)
+	}
+	dm.transactions[id] = t
+
+	log.Printf("Transaction %s started", id)
+	info := t.info
+	return &info, nil
+}
+
+// CommitTransaction 提交事务，无论成功与否句柄都会失效
+func (dm *DatabaseManager) CommitTransaction(id string) (*TransactionInfo, error) {
+	t, err := dm.finishTransaction(id, "committed")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.tx.Commit(); err != nil {
+		return &t.info, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	if t.schemaChanged {
+		dm.InvalidateSchemaCache()
+	}
+	log.Printf("Transaction %s committed", id)
+	return &t.info, nil
+}
+
+// RollbackTransaction 回滚事务
+func (dm *DatabaseManager) RollbackTransaction(id string) (*TransactionInfo, error) {
+	t, err := dm.finishTransaction(id, "rolled back")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.tx.Rollback(); err != nil {
+		return &t.info, fmt.Errorf("failed to roll back transaction: %v", err)
+	}
+	log.Printf("Transaction %s rolled back", id)
+	return &t.info, nil
+}
+
+// findImplicitCommit 在不支持事务性DDL的数据库上查找DDL语句，它们会隐式提交整个事务。
+// 和 FindTransactionControl 一样按两种词法规则分析
+func (dm *DatabaseManager) findImplicitCommit(query string) *Statement {
+	if dm.dialect.SupportsTransactionalDDL() {
+		return nil
+	}
+	for _, mode := range []lexMode{standardLex, mysqlLex} {
+		for _, stmt := range analyze(query, mode) {
+			if stmt.Class == StatementDDL {
+				return &stmt
+			}
+		}
+	}
+	return nil
+}
+
+// ExecuteQueryTx 在指定事务中执行查询并返回一页结果
+func (dm *DatabaseManager) ExecuteQueryTx(id string, query string, page Page, args ...interface{}) *QueryResult {
+	return dm.ExecuteQueryTxContext(context.Background(), id, query, page, args...)
+}
+
+// ExecuteQueryTxContext 同 ExecuteQueryTx，ctx 取消时中断正在执行的查询
+func (dm *DatabaseManager) ExecuteQueryTxContext(ctx context.Context, id string, query string, page Page, args ...interface{}) *QueryResult {
+	if err := dm.CheckStatement(query); err != nil {
+		return &QueryResult{
+			Error: err.Error(),
+		}
+	}
+
+	// 事务只能通过 database_commit / database_rollback 结束
+	if stmt := FindTransactionControl(query); stmt != nil {
+		return &QueryResult{
+			Error: fmt.Sprintf("transaction control statement %s is not allowed inside a transaction handle", stmt.Keyword),
+		}
+	}
+
+	if stmt := dm.findImplicitCommit(query); stmt != nil {
+		return &QueryResult{
+			Error: fmt.Sprintf("%s would implicitly commit the transaction on %s", stmt.Keyword, dm.dialect.Name()),
+		}
+	}
+
+	t, err := dm.acquireTransaction(id)
+	if err != nil {
+		return &QueryResult{
+			Error: err.Error(),
+		}
+	}
+	defer dm.releaseTransaction(t)
+
+	hidden, err := dm.checkAccess(t.tx, query)
+	if err != nil {
+		return &QueryResult{
+			Error: err.Error(),
+		}
+	}
+
+	if err := dm.CheckRedaction(query); err != nil {
+		return &QueryResult{
+			Error: err.Error(),
+		}
+	}
+
+	if err := dm.checkCost(t.tx, query, args...); err != nil {
+		return costGuardResult(err)
+	}
+
+	if ClassifyStatement(query).Class == StatementDDL {
+		t.schemaChanged = true
+	}
+	result := runQuery(ctx, t.tx, query, dm.scanLimits(page), args...)
+	hideColumns(result, hidden)
+	dm.redactor.Redact(query, result)
+	return result
+}
+
+// ExecuteStatementTx 在指定事务中执行修改语句
+func (dm *DatabaseManager) ExecuteStatementTx(id string, query string, args ...interface{}) *ExecResult {
+	return dm.ExecuteStatementTxContext(context.Background(), id, query, args...)
+}
+
+// ExecuteStatementTxContext 同 ExecuteStatementTx，ctx 取消时中断正在执行的语句
+func (dm *DatabaseManager) ExecuteStatementTxContext(ctx context.Context, id string, query string, args ...interface{}) *ExecResult {
+	if !dm.config.ExecuteEnabled() {
+		return &ExecResult{
+			Error: "execute is disabled by configuration (allow_execute is false or read_only is true)",
+		}
+	}
+
+	if stmt := FindTransactionControl(query); stmt != nil {
+		return &ExecResult{
+			Class: stmt.Class,
+			Error: fmt.Sprintf("transaction control statement %s is not allowed inside a transaction handle", stmt.Keyword),
+		}
+	}
+
+	stmt := ClassifyStatement(query)
+	if ddl := dm.findImplicitCommit(query); ddl != nil {
+		return &ExecResult{
+			Class: ddl.Class,
+			Error: fmt.Sprintf("%s would implicitly commit the transaction on %s", ddl.Keyword, dm.dialect.Name()),
+		}
+	}
+
+	t, err := dm.acquireTransaction(id)
+	if err != nil {
+		return &ExecResult{
+			Class: stmt.Class,
+			Error: err.Error(),
+		}
+	}
+	defer dm.releaseTransaction(t)
+
+	if _, err := dm.checkAccess(t.tx, query); err != nil {
+		return &ExecResult{
+			Class: stmt.Class,
+			Error: err.Error(),
+		}
+	}
+
+	if stmt.Class == StatementDDL {
+		t.schemaChanged = true
+	}
+	result, err := t.tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return &ExecResult{
+			Class: stmt.Class,
+			Error: fmt.Sprintf("Statement execution failed: %v", err),
+		}
+	}
+	return newExecResult(result, stmt, false)
+}
+
+// Transactions 列出当前打开的事务
+func (dm *DatabaseManager) Transactions() []TransactionInfo {
+	dm.txMu.Lock()
+	defer dm.txMu.Unlock()
+
+	infos := make([]TransactionInfo, 0, len(dm.transactions))
+	for _, t := range dm.transactions {
+		infos = append(infos, t.info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartedAt.Before(infos[j].StartedAt)
+	})
+	return infos
+}
+
+// HasTransaction 事务句柄是否属于此连接（包括已结束的事务）
+func (dm *DatabaseManager) HasTransaction(id string) bool {
+	dm.txMu.Lock()
+	defer dm.txMu.Unlock()
+
+	if _, ok := dm.transactions[id]; ok {
+		return true
+	}
+	_, ok := dm.finished[id]
+	return ok
+}
+
+// RollbackAll 回滚所有打开的事务，用于会话结束和重新连接
+func (dm *DatabaseManager) RollbackAll(reason string) {
+	dm.txMu.Lock()
+	transactions := dm.transactions
+	dm.transactions = nil
+	for id := range transactions {
+		dm.recordFinished(id, reason)
+	}
+	dm.txMu.Unlock()
+
+	for id, t := range transactions {
+		t.timer.Stop()
+		if err := t.tx.Rollback(); err != nil {
+			log.Printf("Warning: Failed to roll back transaction %s: %v", id, err)
+			continue
+		}
+		log.Printf("Transaction %s rolled back: %s", id, reason)
+	}
+}
+
+// checkConnectionFree SQLite 只有一个连接，事务打开期间不能在事务外执行语句
+func (dm *DatabaseManager) checkConnectionFree() error {
+	if !dm.config.IsFileBased() {
+		return nil
+	}
+
+	dm.txMu.Lock()
+	defer dm.txMu.Unlock()
+
+	for id := range dm.transactions {
+		return fmt.Errorf("the only database connection is held by transaction %s: pass transaction_id or commit/roll back it first", id)
+	}
+	return nil
+}
+
+// hasTransactions 是否有打开的事务
+func (dm *DatabaseManager) hasTransactions() bool {
+	dm.txMu.Lock()
+	defer dm.txMu.Unlock()
+	return len(dm.transactions) > 0
+}
+
+// acquireTransaction 取出事务并标记为使用中
+func (dm *DatabaseManager) acquireTransaction(id string) (*transaction, error) {
+	dm.txMu.Lock()
+	defer dm.txMu.Unlock()
+
+	t, err := dm.lookupTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.busy {
+		return nil, fmt.Errorf("transaction %s is busy with another statement", id)
+	}
+
+	t.busy = true
+	return t, nil
+}
+
+// releaseTransaction 结束使用并重新开始空闲计时
+func (dm *DatabaseManager) releaseTransaction(t *transaction) {
+	dm.txMu.Lock()
+	defer dm.txMu.Unlock()
+
+	t.busy = false
+	t.info.Statements++
+	t.info.LastUsed = time.Now()
+	t.timer.Reset(dm.config.GetTransactionIdleTimeout())
+}
+
+// finishTransaction 从打开的事务中移除，由调用方提交或回滚
+func (dm *DatabaseManager) finishTransaction(id string, reason string) (*transaction, error) {
+	dm.txMu.Lock()
+	defer dm.txMu.Unlock()
+
+	t, err := dm.lookupTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.busy {
+		return nil, fmt.Errorf("transaction %s is busy with another statement", id)
+	}
+
+	t.timer.Stop()
+	delete(dm.transactions, id)
+	dm.recordFinished(id, reason)
+	return t, nil
+}
+
+// expireTransaction 空闲超时回调：事务仍空闲则回滚
+func (dm *DatabaseManager) expireTransaction(t *transaction) {
+	dm.txMu.Lock()
+	if dm.transactions[t.info.ID] != t || t.busy {
+		dm.txMu.Unlock()
+		return
+	}
+
+	idle := dm.config.GetTransactionIdleTimeout()
+	if remaining := idle - time.Since(t.info.LastUsed); remaining > 0 {
+		t.timer.Reset(remaining)
+		dm.txMu.Unlock()
+		return
+	}
+
+	delete(dm.transactions, t.info.ID)
+	dm.recordFinished(t.info.ID, fmt.Sprintf("rolled back after being idle for %v", idle))
+	dm.txMu.Unlock()
+
+	if err := t.tx.Rollback(); err != nil {
+		log.Printf("Warning: Failed to roll back idle transaction %s: %v", t.info.ID, err)
+		return
+	}
+	log.Printf("Transaction %s rolled back after being idle for %v", t.info.ID, idle)
+}
+
+// lookupTransaction 查找打开的事务，调用方需持有 txMu
+func (dm *DatabaseManager) lookupTransaction(id string) (*transaction, error) {
+	if t, ok := dm.transactions[id]; ok {
+		return t, nil
+	}
+	if finished, ok := dm.finished[id]; ok {
+		return nil, fmt.Errorf("transaction %s is no longer open: %s", id, finished.reason)
+	}
+	return nil, fmt.Errorf("transaction not found: %s", id)
+}
+
+// recordFinished 记录事务结束原因并清理过期记录，调用方需持有 txMu
+func (dm *DatabaseManager) recordFinished(id string, reason string) {
+	if dm.finished == nil {
+		dm.finished = make(map[string]finishedTransaction)
+	}
+
+	now := time.Now()
+	for finishedID, finished := range dm.finished {
+		if now.Sub(finished.at) > finishedTransactionRetention {
+			delete(dm.finished, finishedID)
+		}
+	}
+	dm.finished[id] = finishedTransaction{reason: reason, at: now}
+}
+
+// newTransactionID 生成随机事务句柄
+func newTransactionID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate transaction id: %v", err)
+	}
+	return "tx_" + hex.EncodeToString(buf), nil
+}
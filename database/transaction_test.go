@@ -0,0 +1,42 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"hello-mcp-server/config"
+)
+
+func TestTransactionRejectsImplicitCommitDDL(t *testing.T) {
+	dm := newSQLiteManager(t, config.DatabaseConfig{}, "CREATE TABLE t (id INTEGER)")
+	info, err := dm.BeginTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SQLite 支持事务性DDL，换成 MySQL 方言模拟会隐式提交的数据库
+	dm.dialect = &MySQLDialect{}
+
+	tests := []struct {
+		name    string
+		sql     string
+		wantErr bool
+	}{
+		{"query", "SELECT * FROM t", false},
+		{"ddl", "CREATE TABLE u (id INTEGER)", true},
+		{"ddl after select", "SELECT 1; DROP TABLE t", true},
+		{"ddl in executable comment", "SELECT 1 /*! ; DROP TABLE t */", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queryErr := dm.ExecuteQueryTx(info.ID, tt.sql, Page{}).Error
+			execErr := dm.ExecuteStatementTx(info.ID, tt.sql).Error
+			for _, got := range []string{queryErr, execErr} {
+				rejected := strings.Contains(got, "would implicitly commit the transaction on mysql")
+				if rejected != tt.wantErr {
+					t.Errorf("%q: error %q, want rejected=%v", tt.sql, got, tt.wantErr)
+				}
+			}
+		})
+	}
+}
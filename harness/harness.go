@@ -0,0 +1,200 @@
+package harness
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hello-mcp-server/client"
+	"hello-mcp-server/config"
+	"hello-mcp-server/server"
+	"hello-mcp-server/transport"
+	"hello-mcp-server/types"
+)
+
+// 等待通知的默认超时
+const DefaultWaitTimeout = 5 * time.Second
+
+// Harness 在进程内启动服务器并通过内存连接与之通信
+type Harness struct {
+	t testing.TB
+
+	Client *client.Client
+	Server *server.Server
+	// 初始化握手的结果
+	Init *types.InitializeResult
+
+	clientConn *transport.Conn
+	serverConn *transport.Conn
+	serverDone chan error
+
+	notifyMu      sync.Mutex
+	notifications []*types.JSONRPCMessage
+	notifyCh      chan struct{}
+
+	closeOnce sync.Once
+}
+
+// Start 启动服务器并完成 initialize 握手，cfg 为空时不设置请求超时。
+// 返回的 Harness 未绑定 testing.TB，只能直接使用 Client 和 Server
+func Start(handler server.HandlerFunc, cfg *config.ServerConfig) (*Harness, error) {
+	clientConn, serverConn := transport.NewMemoryPair()
+
+	srv := server.NewServer(handler, cfg)
+	srv.SetIO(serverConn, serverConn)
+
+	h := &Harness{
+		Server:     srv,
+		clientConn: clientConn,
+		serverConn: serverConn,
+		serverDone: make(chan error, 1),
+		notifyCh:   make(chan struct{}, 1),
+	}
+
+	go func() {
+		err := srv.Run()
+		serverConn.Close()
+		h.serverDone <- err
+	}()
+
+	h.Client = client.NewClient(client.NewStreamTransport(clientConn, clientConn))
+	h.Client.OnNotification("", h.recordNotification)
+
+	init, err := h.Client.Initialize(
+		types.ClientInfo{Name: "mcp-test-harness", Version: "1.0.0"},
+		types.ClientCapabilities{},
+	)
+	if err != nil {
+		h.Close()
+		return nil, fmt.Errorf("initialize failed: %v", err)
+	}
+	h.Init = init
+
+	return h, nil
+}
+
+// New 启动服务器，失败时终止测试，测试结束时自动关闭
+func New(t testing.TB, handler server.HandlerFunc, cfg *config.ServerConfig) *Harness {
+	t.Helper()
+
+	h, err := Start(handler, cfg)
+	if err != nil {
+		t.Fatalf("failed to start harness: %v", err)
+	}
+	h.t = t
+	t.Cleanup(h.Close)
+	return h
+}
+
+// Close 关闭客户端并等待服务器退出
+func (h *Harness) Close() {
+	h.closeOnce.Do(func() {
+		h.Client.Close()
+		// 客户端不再读取，避免服务器阻塞在写入上
+		h.clientConn.CloseRead()
+
+		select {
+		case <-h.serverDone:
+		case <-time.After(DefaultWaitTimeout):
+			if h.t != nil {
+				h.t.Errorf("server did not stop within %v", DefaultWaitTimeout)
+			}
+		}
+	})
+}
+
+// ListTools 获取工具列表，失败时终止测试
+func (h *Harness) ListTools() []types.Tool {
+	h.t.Helper()
+
+	result, err := h.Client.ListTools()
+	if err != nil {
+		h.t.Fatalf("tools/list failed: %v", err)
+	}
+	return result.Tools
+}
+
+// CallTool 调用工具，返回 JSON-RPC 错误时终止测试
+func (h *Harness) CallTool(name string, arguments map[string]interface{}) *types.CallToolResult {
+	h.t.Helper()
+
+	result, err := h.Client.CallTool(name, arguments)
+	if err != nil {
+		h.t.Fatalf("tools/call %s failed: %v", name, err)
+	}
+	return result
+}
+
+// CallToolText 调用工具并返回所有文本内容
+func (h *Harness) CallToolText(name string, arguments map[string]interface{}) string {
+	h.t.Helper()
+	return Text(h.CallTool(name, arguments))
+}
+
+// CallToolError 调用工具并期望返回 JSON-RPC 错误
+func (h *Harness) CallToolError(name string, arguments map[string]interface{}) *client.RPCError {
+	h.t.Helper()
+
+	_, err := h.Client.CallTool(name, arguments)
+	if err == nil {
+		h.t.Fatalf("tools/call %s: expected error, got success", name)
+	}
+
+	rpcErr, ok := err.(*client.RPCError)
+	if !ok {
+		h.t.Fatalf("tools/call %s: expected rpc error, got %v", name, err)
+	}
+	return rpcErr
+}
+
+// Notifications 返回目前收到的所有通知
+func (h *Harness) Notifications() []*types.JSONRPCMessage {
+	h.notifyMu.Lock()
+	defer h.notifyMu.Unlock()
+	return append([]*types.JSONRPCMessage{}, h.notifications...)
+}
+
+// WaitNotification 等待指定方法的通知，超时时终止测试
+func (h *Harness) WaitNotification(method string, timeout time.Duration) *types.JSONRPCMessage {
+	h.t.Helper()
+
+	deadline := time.After(timeout)
+	for {
+		for _, msg := range h.Notifications() {
+			if msg.Method == method {
+				return msg
+			}
+		}
+
+		select {
+		case <-h.notifyCh:
+		case <-deadline:
+			h.t.Fatalf("no %s notification within %v", method, timeout)
+			return nil
+		}
+	}
+}
+
+func (h *Harness) recordNotification(msg *types.JSONRPCMessage) {
+	h.notifyMu.Lock()
+	h.notifications = append(h.notifications, msg)
+	h.notifyMu.Unlock()
+
+	select {
+	case h.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+// Text 拼接结果中的所有文本内容
+func Text(result *types.CallToolResult) string {
+	var parts []string
+	for _, item := range result.Content {
+		if item.Type == "text" {
+			parts = append(parts, item.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,139 @@
+package harness
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"hello-mcp-server/server"
+	"hello-mcp-server/types"
+)
+
+// echoHandler 最小的服务器：echo 工具返回参数 text，fail 工具返回错误，
+// notify 工具在响应前发送一条通知
+func echoHandler(srv **server.Server) server.HandlerFunc {
+	return func(ctx context.Context, msg *types.JSONRPCMessage) *types.JSONRPCMessage {
+		response := &types.JSONRPCMessage{JSONRPC: "2.0", ID: msg.ID}
+
+		switch msg.Method {
+		case "initialize":
+			response.Result = types.InitializeResult{
+				ProtocolVersion: "2024-11-05",
+				ServerInfo:      types.ServerInfo{Name: "echo", Version: "0.1.0"},
+			}
+		case "notifications/initialized":
+			return nil
+		case "tools/list":
+			response.Result = types.ListToolsResult{Tools: []types.Tool{{Name: "echo"}}}
+		case "tools/call":
+			params, _ := msg.Params.(map[string]interface{})
+			arguments, _ := params["arguments"].(map[string]interface{})
+			text, _ := arguments["text"].(string)
+
+			switch params["name"] {
+			case "fail":
+				response.Error = &types.JSONRPCError{Code: -32602, Message: "bad arguments"}
+				return response
+			case "notify":
+				(*srv).SendNotification("notifications/message", map[string]interface{}{"text": text})
+			}
+			response.Result = types.CallToolResult{
+				Content: []types.ContentItem{{Type: "text", Text: text}, {Type: "image", Text: "ignored"}},
+			}
+		default:
+			response.Error = &types.JSONRPCError{Code: -32601, Message: "Method not found"}
+		}
+		return response
+	}
+}
+
+func newEchoHarness(t *testing.T) *Harness {
+	var srv *server.Server
+	h := New(t, echoHandler(&srv), nil)
+	srv = h.Server
+	return h
+}
+
+func TestHandshake(t *testing.T) {
+	h := newEchoHarness(t)
+
+	if h.Init == nil || h.Init.ServerInfo.Name != "echo" {
+		t.Fatalf("unexpected initialize result: %+v", h.Init)
+	}
+	if tools := h.ListTools(); len(tools) != 1 || tools[0].Name != "echo" {
+		t.Errorf("unexpected tools: %+v", tools)
+	}
+}
+
+func TestCallTool(t *testing.T) {
+	h := newEchoHarness(t)
+
+	tests := []struct {
+		text string
+	}{
+		{"hello"},
+		{""},
+		{"多行\n文本"},
+	}
+	for _, tt := range tests {
+		if got := h.CallToolText("echo", map[string]interface{}{"text": tt.text}); got != tt.text {
+			t.Errorf("CallToolText(%q) = %q", tt.text, got)
+		}
+	}
+}
+
+func TestCallToolError(t *testing.T) {
+	h := newEchoHarness(t)
+
+	err := h.CallToolError("fail", nil)
+	if err.Code != -32602 || err.Message != "bad arguments" {
+		t.Errorf("unexpected error: %+v", err)
+	}
+}
+
+func TestWaitNotification(t *testing.T) {
+	h := newEchoHarness(t)
+
+	h.CallTool("notify", map[string]interface{}{"text": "ping"})
+	msg := h.WaitNotification("notifications/message", time.Second)
+	params, _ := msg.Params.(map[string]interface{})
+	if params["text"] != "ping" {
+		t.Errorf("unexpected notification params: %v", msg.Params)
+	}
+	if n := len(h.Notifications()); n != 1 {
+		t.Errorf("expected 1 notification, got %d", n)
+	}
+}
+
+func TestStartFailsWhenInitializeFails(t *testing.T) {
+	handler := func(ctx context.Context, msg *types.JSONRPCMessage) *types.JSONRPCMessage {
+		return &types.JSONRPCMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Error:   &types.JSONRPCError{Code: -32603, Message: "not ready"},
+		}
+	}
+
+	if h, err := Start(handler, nil); err == nil {
+		h.Close()
+		t.Fatal("expected Start to fail")
+	}
+}
+
+func TestCloseStopsServer(t *testing.T) {
+	h, err := Start(echoHandler(new(*server.Server)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Close()
+
+	select {
+	case <-h.Client.Done():
+	case <-time.After(time.Second):
+		t.Fatal("client still running after Close")
+	}
+	if _, err := h.serverConn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected server input closed, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"hello-mcp-server/config"
@@ -15,6 +16,8 @@ import (
 type RedisManager struct {
 	config *config.RedisConfig
 	client *redis.Client
+	// 串行化 Connect 和 Close，避免并发的调用互相替换客户端
+	connMu sync.Mutex
 }
 
 // RedisResult Redis操作结果结构
@@ -40,12 +43,24 @@ func NewRedisManager(cfg *config.RedisConfig) *RedisManager {
 	}
 }
 
-// Connect 连接Redis
+// Connect 连接Redis。已有可用的连接时直接返回，重新连接前关闭旧的客户端
 func (rm *RedisManager) Connect() error {
+	rm.connMu.Lock()
+	defer rm.connMu.Unlock()
+
+	if rm.IsConnected() {
+		return nil
+	}
+
 	if !rm.config.IsValid() {
 		return fmt.Errorf("invalid redis configuration")
 	}
 
+	if rm.client != nil {
+		rm.client.Close()
+		rm.client = nil
+	}
+
 	log.Printf("Connecting to Redis: %s", rm.config.GetAddr())
 
 	// 创建Redis客户端
@@ -77,6 +92,9 @@ func (rm *RedisManager) Connect() error {
 
 // Close 关闭Redis连接
 func (rm *RedisManager) Close() error {
+	rm.connMu.Lock()
+	defer rm.connMu.Unlock()
+
 	if rm.client != nil {
 		return rm.client.Close()
 	}
@@ -0,0 +1,417 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"hello-mcp-server/config"
+	"hello-mcp-server/types"
+)
+
+// HandlerFunc 处理单条请求或通知，通知应返回nil。
+// ctx 在请求超时后取消，耗时的处理函数应据此尽早返回
+type HandlerFunc func(ctx context.Context, msg *types.JSONRPCMessage) *types.JSONRPCMessage
+
+// request 读循环交给处理协程的一条消息或一个批量请求
+type request struct {
+	messages []*types.JSONRPCMessage
+	batch    bool
+	// 批量中无法解析的元素对应的错误响应
+	errors []*types.JSONRPCMessage
+}
+
+// Server JSON-RPC 消息循环，负责读写、ping 和请求超时
+type Server struct {
+	handler HandlerFunc
+	config  *config.ServerConfig
+
+	reader io.Reader
+	writer io.Writer
+	sendMu sync.Mutex
+
+	// 服务器发往客户端的请求
+	pendingMu sync.Mutex
+	pending   map[int]chan *types.JSONRPCMessage
+	nextID    int
+
+	done chan struct{}
+}
+
+// NewServer 创建基于 stdio 的服务器
+func NewServer(handler HandlerFunc, cfg *config.ServerConfig) *Server {
+	if cfg == nil {
+		cfg = &config.ServerConfig{}
+	}
+	return &Server{
+		handler: handler,
+		config:  cfg,
+		reader:  os.Stdin,
+		writer:  os.Stdout,
+		pending: make(map[int]chan *types.JSONRPCMessage),
+		done:    make(chan struct{}),
+	}
+}
+
+// SetIO 替换输入输出（默认 stdin/stdout）
+func (s *Server) SetIO(reader io.Reader, writer io.Writer) {
+	s.reader = reader
+	s.writer = writer
+}
+
+// Run 读取消息直到输入结束
+func (s *Server) Run() error {
+	defer close(s.done)
+
+	// 请求按顺序交给处理函数，超时的请求不阻塞后续请求；
+	// ping 和响应由读循环直接处理，不在耗时的请求后排队
+	requests := make(chan *request, 16)
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		for req := range requests {
+			if req.batch {
+				s.dispatchBatch(req.messages, req.errors)
+			} else {
+				s.dispatch(req.messages[0])
+			}
+		}
+	}()
+
+	if s.config.PingInterval > 0 {
+		go s.keepAlive()
+	}
+
+	scanner := bufio.NewScanner(s.reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		// JSON 数组为批量请求
+		if strings.HasPrefix(line, "[") {
+			if req := s.parseBatch(line); req != nil {
+				requests <- req
+			}
+			continue
+		}
+
+		// 解析输入消息
+		var msg types.JSONRPCMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			log.Printf("Failed to parse JSON: %v", err)
+			s.SendMessage(parseErrorMessage())
+			continue
+		}
+
+		// 客户端对服务器请求的响应
+		if msg.Method == "" && msg.ID != nil {
+			s.deliver(&msg)
+			continue
+		}
+
+		if msg.Method == "ping" && msg.ID != nil {
+			if err := s.SendMessage(pongMessage(&msg)); err != nil {
+				log.Printf("Failed to send response: %v", err)
+			}
+			continue
+		}
+
+		log.Printf("Received message: method=%s, id=%s", msg.Method, formatID(msg.ID))
+		requests <- &request{messages: []*types.JSONRPCMessage{&msg}}
+	}
+
+	close(requests)
+	<-dispatched
+
+	if err := scanner.Err(); err != nil {
+		log.Printf("Scanner error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// dispatch 处理一条请求并发送响应
+func (s *Server) dispatch(msg *types.JSONRPCMessage) {
+	response := s.handleRequest(msg)
+
+	// 通知不需要响应
+	if response == nil || msg.ID == nil {
+		return
+	}
+
+	if err := s.SendMessage(response); err != nil {
+		log.Printf("Failed to send response: %v", err)
+	}
+}
+
+// parseBatch 解析批量请求，客户端响应直接分发，无需处理时返回nil
+func (s *Server) parseBatch(line string) *request {
+	var elements []json.RawMessage
+	if err := json.Unmarshal([]byte(line), &elements); err != nil {
+		log.Printf("Failed to parse JSON batch: %v", err)
+		s.SendMessage(parseErrorMessage())
+		return nil
+	}
+
+	if len(elements) == 0 {
+		s.SendMessage(invalidRequestMessage())
+		return nil
+	}
+
+	req := &request{batch: true}
+	for _, element := range elements {
+		var msg types.JSONRPCMessage
+		if err := json.Unmarshal(element, &msg); err != nil || (msg.Method == "" && msg.ID == nil) {
+			req.errors = append(req.errors, invalidRequestMessage())
+			continue
+		}
+
+		if msg.Method == "" {
+			s.deliver(&msg)
+			continue
+		}
+		req.messages = append(req.messages, &msg)
+	}
+
+	log.Printf("Received batch: requests=%d, invalid=%d", len(req.messages), len(req.errors))
+	if len(req.messages) == 0 && len(req.errors) == 0 {
+		return nil
+	}
+	return req
+}
+
+// dispatchBatch 处理批量请求，按配置顺序或并行执行，响应合并为数组发送
+func (s *Server) dispatchBatch(messages []*types.JSONRPCMessage, errors []*types.JSONRPCMessage) {
+	responses := make([]*types.JSONRPCMessage, len(messages))
+
+	if s.config.BatchParallel {
+		var wg sync.WaitGroup
+		for i, msg := range messages {
+			wg.Add(1)
+			go func(i int, msg *types.JSONRPCMessage) {
+				defer wg.Done()
+				responses[i] = s.handleRequest(msg)
+			}(i, msg)
+		}
+		wg.Wait()
+	} else {
+		for i, msg := range messages {
+			responses[i] = s.handleRequest(msg)
+		}
+	}
+
+	// 通知不出现在批量响应中
+	var batch []*types.JSONRPCMessage
+	for i, response := range responses {
+		if response != nil && messages[i].ID != nil {
+			batch = append(batch, response)
+		}
+	}
+	batch = append(batch, errors...)
+
+	// 全部为通知时不发送任何内容
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := s.send(batch); err != nil {
+		log.Printf("Failed to send batch response: %v", err)
+	}
+}
+
+// handleRequest 执行处理函数，超过 RequestTimeout 时取消其 ctx 并返回超时错误。
+// 超时后不再等待处理函数，未响应取消的处理函数在后台运行结束，结果被丢弃，
+// 因此处理函数之间可能并发执行
+func (s *Server) handleRequest(msg *types.JSONRPCMessage) *types.JSONRPCMessage {
+	if msg.Method == "ping" {
+		return pongMessage(msg)
+	}
+
+	timeout := s.config.RequestTimeout
+	if timeout <= 0 || msg.ID == nil {
+		return s.handler(context.Background(), msg)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	resultCh := make(chan *types.JSONRPCMessage, 1)
+	go func() {
+		resultCh <- s.handler(ctx, msg)
+	}()
+
+	select {
+	case response := <-resultCh:
+		return response
+	case <-ctx.Done():
+		log.Printf("Request timed out: method=%s, id=%v, timeout=%v", msg.Method, *msg.ID, timeout)
+		return &types.JSONRPCMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Error: &types.JSONRPCError{
+				Code:    -32001,
+				Message: fmt.Sprintf("Request timed out after %v", timeout),
+				Data: map[string]interface{}{
+					"method":  msg.Method,
+					"timeout": timeout.String(),
+				},
+			},
+		}
+	}
+}
+
+// SendMessage 发送一条消息（并发安全）
+func (s *Server) SendMessage(msg *types.JSONRPCMessage) error {
+	return s.send(msg)
+}
+
+// send 将消息或消息数组编码为一行写出
+func (s *Server) send(v interface{}) error {
+	msgBytes, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	_, err = s.writer.Write(append(msgBytes, '\n'))
+	return err
+}
+
+// SendNotification 向客户端发送通知
+func (s *Server) SendNotification(method string, params interface{}) error {
+	return s.SendMessage(&types.JSONRPCMessage{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+}
+
+// Request 向客户端发送请求并等待响应
+func (s *Server) Request(method string, params interface{}, timeout time.Duration) (*types.JSONRPCMessage, error) {
+	s.pendingMu.Lock()
+	s.nextID++
+	id := s.nextID
+	responseCh := make(chan *types.JSONRPCMessage, 1)
+	s.pending[id] = responseCh
+	s.pendingMu.Unlock()
+
+	defer func() {
+		s.pendingMu.Lock()
+		delete(s.pending, id)
+		s.pendingMu.Unlock()
+	}()
+
+	err := s.SendMessage(&types.JSONRPCMessage{
+		JSONRPC: "2.0",
+		ID:      &id,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %v", method, err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case response := <-responseCh:
+		if response.Error != nil {
+			return response, fmt.Errorf("%s request failed: %s", method, response.Error.Message)
+		}
+		return response, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%s request timed out after %v", method, timeout)
+	case <-s.done:
+		return nil, fmt.Errorf("server stopped")
+	}
+}
+
+// Ping 向客户端发送 ping 并等待响应
+func (s *Server) Ping() error {
+	_, err := s.Request("ping", nil, s.config.GetPingTimeout())
+	return err
+}
+
+// deliver 将客户端响应交给等待中的请求
+func (s *Server) deliver(msg *types.JSONRPCMessage) {
+	s.pendingMu.Lock()
+	responseCh, ok := s.pending[*msg.ID]
+	s.pendingMu.Unlock()
+
+	if !ok {
+		log.Printf("Received response for unknown request: id=%d", *msg.ID)
+		return
+	}
+
+	select {
+	case responseCh <- msg:
+	default:
+		log.Printf("Dropped duplicate response: id=%d", *msg.ID)
+	}
+}
+
+// keepAlive 定期 ping 客户端
+func (s *Server) keepAlive() {
+	ticker := time.NewTicker(s.config.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Ping(); err != nil {
+				log.Printf("Client ping failed: %v", err)
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// pongMessage ping 的响应
+func pongMessage(msg *types.JSONRPCMessage) *types.JSONRPCMessage {
+	return &types.JSONRPCMessage{
+		JSONRPC: "2.0",
+		ID:      msg.ID,
+		Result:  struct{}{},
+	}
+}
+
+func parseErrorMessage() *types.JSONRPCMessage {
+	return &types.JSONRPCMessage{
+		JSONRPC: "2.0",
+		Error: &types.JSONRPCError{
+			Code:    -32700,
+			Message: "Parse error",
+		},
+	}
+}
+
+func invalidRequestMessage() *types.JSONRPCMessage {
+	return &types.JSONRPCMessage{
+		JSONRPC: "2.0",
+		Error: &types.JSONRPCError{
+			Code:    -32600,
+			Message: "Invalid Request",
+		},
+	}
+}
+
+// formatID 格式化消息ID用于日志
+func formatID(id *int) string {
+	if id == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%d", *id)
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hello-mcp-server/config"
+	"hello-mcp-server/types"
+)
+
+// startServer 通过管道运行服务器，返回写入请求和逐行读取响应的函数
+func startServer(t *testing.T, handler HandlerFunc, cfg *config.ServerConfig) (func(string), func() string) {
+	t.Helper()
+
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+
+	s := NewServer(handler, cfg)
+	s.SetIO(inReader, outWriter)
+	go s.Run()
+	t.Cleanup(func() { inWriter.Close() })
+
+	lines := make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(outReader)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	send := func(line string) {
+		if _, err := io.WriteString(inWriter, line+"\n"); err != nil {
+			t.Fatalf("write request: %v", err)
+		}
+	}
+	receive := func() string {
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(5 * time.Second):
+			t.Fatal("no response")
+			return ""
+		}
+	}
+	return send, receive
+}
+
+// decode 解析单条响应
+func decode(t *testing.T, line string) map[string]interface{} {
+	t.Helper()
+	var msg map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &msg); err != nil {
+		t.Fatalf("invalid response %q: %v", line, err)
+	}
+	return msg
+}
+
+// slowHandler sleep 方法等待 delay 或 ctx 取消，hang 方法忽略 ctx 一直阻塞到 release 关闭；
+// cancelled 统计观察到取消的处理函数数
+func slowHandler(delay time.Duration, release <-chan struct{}, cancelled *int32) HandlerFunc {
+	return func(ctx context.Context, msg *types.JSONRPCMessage) *types.JSONRPCMessage {
+		switch msg.Method {
+		case "sleep":
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				atomic.AddInt32(cancelled, 1)
+			}
+		case "hang":
+			<-release
+		}
+		return &types.JSONRPCMessage{JSONRPC: "2.0", ID: msg.ID, Result: msg.Method}
+	}
+}
+
+func TestPingNotQueuedBehindRequest(t *testing.T) {
+	var cancelled int32
+	send, receive := startServer(t, slowHandler(500*time.Millisecond, nil, &cancelled), nil)
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"sleep"}`)
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	send(`{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+
+	pong := decode(t, receive())
+	if pong["id"] != float64(2) {
+		t.Fatalf("expected ping response first, got %v", pong)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("ping waited %v behind the running request", elapsed)
+	}
+	if response := decode(t, receive()); response["id"] != float64(1) {
+		t.Errorf("expected sleep response, got %v", response)
+	}
+}
+
+// expectTimeout 检查响应是指定请求的超时错误
+func expectTimeout(t *testing.T, response map[string]interface{}, id float64) {
+	t.Helper()
+	errObj, ok := response["error"].(map[string]interface{})
+	if response["id"] != id || !ok || errObj["code"] != float64(-32001) {
+		t.Fatalf("expected timeout error for id %v, got %v", id, response)
+	}
+}
+
+func TestTimedOutHandlerCancelled(t *testing.T) {
+	var cancelled int32
+	cfg := &config.ServerConfig{RequestTimeout: 50 * time.Millisecond}
+	send, receive := startServer(t, slowHandler(5*time.Second, nil, &cancelled), cfg)
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"sleep"}`)
+	expectTimeout(t, decode(t, receive()), 1)
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&cancelled) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("handler context was not cancelled after the timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHungHandlerDoesNotStallLaterRequests(t *testing.T) {
+	var cancelled int32
+	release := make(chan struct{})
+	defer close(release)
+	cfg := &config.ServerConfig{RequestTimeout: 50 * time.Millisecond}
+	send, receive := startServer(t, slowHandler(0, release, &cancelled), cfg)
+
+	// 超过请求队列容量的请求排在忽略取消的处理函数之后
+	send(`{"jsonrpc":"2.0","id":1,"method":"hang"}`)
+	expectTimeout(t, decode(t, receive()), 1)
+	for id := 2; id <= 40; id++ {
+		send(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"fast"}`, id))
+		if response := decode(t, receive()); response["id"] != float64(id) {
+			t.Fatalf("expected response for id %d, got %v", id, response)
+		}
+	}
+
+	send(`{"jsonrpc":"2.0","id":41,"method":"ping"}`)
+	if response := decode(t, receive()); response["id"] != float64(41) {
+		t.Fatalf("expected ping response, got %v", response)
+	}
+}
+
+func TestBatchDoesNotWaitForTimedOutHandlers(t *testing.T) {
+	var cancelled int32
+	release := make(chan struct{})
+	defer close(release)
+	cfg := &config.ServerConfig{RequestTimeout: 50 * time.Millisecond}
+	send, receive := startServer(t, slowHandler(0, release, &cancelled), cfg)
+
+	send(`[{"jsonrpc":"2.0","id":1,"method":"hang"},{"jsonrpc":"2.0","id":2,"method":"fast"}]`)
+	send(`{"jsonrpc":"2.0","id":3,"method":"fast"}`)
+
+	var batch []map[string]interface{}
+	if err := json.Unmarshal([]byte(receive()), &batch); err != nil || len(batch) != 2 {
+		t.Fatalf("expected batch response with 2 elements: %v", err)
+	}
+	expectTimeout(t, batch[0], 1)
+	if batch[1]["id"] != float64(2) {
+		t.Errorf("expected response for id 2, got %v", batch[1])
+	}
+	if response := decode(t, receive()); response["id"] != float64(3) {
+		t.Fatalf("expected response for id 3, got %v", response)
+	}
+}
@@ -0,0 +1,41 @@
+package transport
+
+import (
+	"io"
+)
+
+// Conn 内存连接的一端，读取对端写入的数据
+type Conn struct {
+	reader *io.PipeReader
+	writer *io.PipeWriter
+}
+
+// NewMemoryPair 创建一对相互连接的内存连接，分别交给客户端和服务器
+func NewMemoryPair() (clientConn *Conn, serverConn *Conn) {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	clientConn = &Conn{reader: clientReader, writer: clientWriter}
+	serverConn = &Conn{reader: serverReader, writer: serverWriter}
+	return clientConn, serverConn
+}
+
+// Read 读取对端写入的数据
+func (c *Conn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// Write 向对端写入数据，对端未读取时阻塞
+func (c *Conn) Write(p []byte) (int, error) {
+	return c.writer.Write(p)
+}
+
+// Close 关闭写入方向，对端读取到 io.EOF
+func (c *Conn) Close() error {
+	return c.writer.Close()
+}
+
+// CloseRead 关闭读取方向，对端写入返回 io.ErrClosedPipe
+func (c *Conn) CloseRead() error {
+	return c.reader.Close()
+}
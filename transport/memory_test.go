@@ -0,0 +1,59 @@
+package transport
+
+import (
+	"bufio"
+	"io"
+	"testing"
+)
+
+func TestMemoryPairBothDirections(t *testing.T) {
+	clientConn, serverConn := NewMemoryPair()
+
+	tests := []struct {
+		name string
+		from *Conn
+		to   *Conn
+		line string
+	}{
+		{"client to server", clientConn, serverConn, "request"},
+		{"server to client", serverConn, clientConn, "response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			go tt.from.Write([]byte(tt.line + "\n"))
+
+			got, err := bufio.NewReader(tt.to).ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.line+"\n" {
+				t.Errorf("read %q, want %q", got, tt.line+"\n")
+			}
+		})
+	}
+}
+
+func TestCloseSendsEOF(t *testing.T) {
+	clientConn, serverConn := NewMemoryPair()
+
+	clientConn.Close()
+	if _, err := serverConn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	// 另一个方向不受影响
+	go serverConn.Write([]byte("x"))
+	if _, err := clientConn.Read(make([]byte, 1)); err != nil {
+		t.Errorf("reverse direction closed: %v", err)
+	}
+}
+
+func TestCloseReadFailsPeerWrites(t *testing.T) {
+	clientConn, serverConn := NewMemoryPair()
+
+	clientConn.CloseRead()
+	if _, err := serverConn.Write([]byte("x")); err != io.ErrClosedPipe {
+		t.Errorf("expected io.ErrClosedPipe, got %v", err)
+	}
+}